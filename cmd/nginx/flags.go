@@ -25,12 +25,15 @@ import (
 	"github.com/spf13/pflag"
 
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
 	"k8s.io/ingress-nginx/internal/ingress/status"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/nginx"
@@ -65,11 +68,26 @@ If this parameter is not set, or set to the default value of "nginx", it will ha
 		configMap = flags.String("configmap", "",
 			`Name of the ConfigMap containing custom global configurations for the controller.`)
 
+		configurationResource = flags.String("configuration-resource", "",
+			`Name of the NGINXConfiguration resource containing custom global configurations for the controller.
+Takes the form "namespace/name". The settings of the resource take precedence over the ConfigMap.`)
+
+		namespaceDefaultsConfigMap = flags.String("namespace-defaults-configmap", "",
+			`Name of the ConfigMap, located in the namespace of each Ingress, containing the default annotations of the Ingresses of the namespace.
+The keys of the ConfigMap are annotation names without the prefix.`)
+
+		namespaceAnnotations = flags.Bool("enable-namespace-annotations", false,
+			`Use the annotations of the Namespace of each Ingress as default annotations of the Ingress.
+Requires permissions to list and watch Namespaces.`)
+
+		lockedAnnotations = flags.String("locked-annotations", "",
+			`Comma separated list of annotations, without the prefix, whose default values cannot be overridden by the Ingresses.`)
+
 		publishSvc = flags.String("publish-service", "",
 			`Service fronting the Ingress controller.
 Takes the form "namespace/name". When used together with update-status, the
 controller mirrors the address of this service's endpoints to the load-balancer
-status of all Ingress objects it satisfies.`)
+status of all Ingress objects it satisfies. Several Services can be separated by comma.`)
 
 		tcpConfigMapName = flags.String("tcp-services-configmap", "",
 			`Name of the ConfigMap containing the definition of the TCP services to expose.
@@ -115,6 +133,17 @@ Requires setting the publish-service parameter to a valid Service reference.`)
 		updateStatusOnShutdown = flags.Bool("update-status-on-shutdown", true,
 			`Update the load-balancer status of Ingress objects when the controller shuts down.
 Requires the update-status parameter.`)
+		publishAddressSets = flags.StringArray("publish-address-set", nil,
+			`Named set of addresses published in the status of the Ingresses selecting it, in the form "name=source".
+The source is a Service in the form "namespace/name", an IP address or a hostname. Repeat the flag to add sources to a set.`)
+		publishClassAddressSets = flags.StringArray("publish-class-address-set", nil,
+			`Address set published in the status of the Ingresses of an IngressClass, in the form "class=set".
+The annotation publish-address-set of an Ingress takes precedence.`)
+		publishIPFamily = flags.String("publish-ip-family", "",
+			`Family of the IP addresses published in the status of the Ingresses, IPv4 or IPv6. All the families are published by default.`)
+		publishPorts = flags.Bool("publish-ports", false,
+			`Publish the ports of the addresses in the status of the Ingresses.
+Requires a cluster supporting the ports of the load-balancer status.`)
 
 		useNodeInternalIP = flags.Bool("report-node-internal-ip-address", false,
 			`Set the load-balancer status of Ingress objects to internal Node addresses instead of external.
@@ -136,6 +165,22 @@ extension for this to succeed.`)
 
 		syncRateLimit = flags.Float32("sync-rate-limit", 0.3,
 			`Define the sync frequency upper limit`)
+		minReloadInterval = flags.Duration("min-reload-interval", 0,
+			`Minimum time between two reloads of NGINX. Changes received during the interval are applied in a single reload.
+Changes that do not require a reload, like endpoints and certificates, are applied immediately. Disabled by default.`)
+		maxReloadDelay = flags.Duration("max-reload-delay", 0,
+			`Maximum time a change waits for the minimum reload interval before it is applied. Disabled by default.`)
+		maxOldWorkerGenerations = flags.Int("max-old-worker-generations", 0,
+			`Maximum number of previous configurations with NGINX workers still shutting down after a reload.
+Further reloads are delayed while the limit is exceeded, up to the worker-shutdown-timeout. Disabled by default.`)
+
+		shardID = flags.Int("shard-id", 0,
+			`ID of the shard of hosts rendered by the controller, from 0 to --shard-count minus one.`)
+		shardCount = flags.Int("shard-count", 0,
+			`Number of shards the hosts are split across. The controller renders only the hosts of its shard
+and publishes the status of the Ingresses of these hosts. Disabled by default.`)
+		ingressSelector = flags.String("ingress-selector", "",
+			`Label selector to filter the Ingresses handled by the controller. Disabled by default.`)
 
 		publishStatusAddress = flags.String("publish-status-address", "",
 			`Customized address (or addresses, separated by comma) to set as the load-balancer status of Ingress objects this controller satisfies.
@@ -150,6 +195,11 @@ Requires the update-status parameter.`)
 		httpPort  = flags.Int("http-port", 80, `Port to use for servicing HTTP traffic.`)
 		httpsPort = flags.Int("https-port", 443, `Port to use for servicing HTTPS traffic.`)
 
+		enableHTTP3 = flags.Bool("enable-http3", false,
+			`Enable HTTP/3 (QUIC) support. Requires NGINX built with the HTTP/3 module.
+Only servers using TLSv1.3 are configured with a QUIC listener.`)
+		http3Port = flags.Int("http3-port", 443, `UDP port to use for servicing HTTP/3 (QUIC) traffic.`)
+
 		sslProxyPort  = flags.Int("ssl-passthrough-proxy-port", 442, `Port to use internally for SSL Passthrough.`)
 		defServerPort = flags.Int("default-server-port", 8181, `Port to use for exposing the default server (catch-all).`)
 		healthzPort   = flags.Int("healthz-port", 10254, "Port to use for the healthz endpoint.")
@@ -164,6 +214,10 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 			`The path of the validating webhook certificate PEM.`)
 		validationWebhookKey = flags.String("validating-webhook-key", "",
 			`The path of the validating webhook key PEM.`)
+		strictAnnotationValidation = flags.Bool("strict-annotation-validation", false,
+			`Reject Ingresses with annotation values that cannot be parsed or do not match the annotations reference in the validating webhook, instead of using the default values.`)
+		strictConfigMapValidation = flags.Bool("strict-configmap-validation", false,
+			`Keep the previous configuration when the configuration ConfigMap contains unknown keys or invalid values, instead of using the default values for the invalid settings.`)
 
 		statusPort = flags.Int("status-port", 10246, `Port to use for the lua HTTP endpoint configuration.`)
 		streamPort = flags.Int("stream-port", 10247, "Port to use for the lua TCP/UDP endpoint configuration.")
@@ -173,6 +227,31 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 		statusUpdateInterval = flags.Int("status-update-interval", status.UpdateInterval, "Time interval in seconds in which the status should check if an update is required. Default is 60 seconds")
 
 		shutdownGracePeriod = flags.Int("shutdown-grace-period", 0, "Seconds to wait after receiving the shutdown signal, before stopping the nginx process.")
+
+		shutdownEndpointsTimeout = flags.Duration("shutdown-endpoints-timeout", 30*time.Second,
+			`Maximum time to wait during the shutdown for the removal of the pod from the endpoints of the published Services.`)
+		shutdownDrainTimeout = flags.Duration("shutdown-drain-timeout", 0,
+			`Maximum time to drain the NGINX connections during the shutdown. While draining, new connections are closed
+and the responses to keepalive connections include the header "Connection: close". Disabled by default.`)
+		shutdownDrainConnections = flags.Int("shutdown-drain-connections", 0,
+			`Number of active NGINX connections that finishes the drain before the shutdown-drain-timeout.`)
+
+		enableIncrementalSync = flags.Bool("enable-incremental-sync", false,
+			`Compute only the servers and upstreams affected by a change in Ingresses, Services, Endpoints or Secrets
+instead of the complete configuration in every sync.`)
+
+		enableExternalNameResolution = flags.Bool("enable-external-name-resolution", false,
+			`Resolve the names of ExternalName Services in the controller every 30 seconds,
+and configure the resolved addresses as endpoints without reloading NGINX.
+The TTL of the DNS records is not used, the resolver of Go does not return it.
+Named ports use the port of the SRV record _<port name>._<protocol>.<external name> when it exists.`)
+
+		externalNamePreferIPv6 = flags.Bool("external-name-prefer-ipv6", false,
+			`Use the IPv6 addresses of ExternalName Services resolved by the controller when the name has both IPv4 and IPv6 addresses.`)
+
+		maxmindUpdateInterval = flags.Duration("maxmind-update-interval", 24*time.Hour,
+			`Interval between downloads of new versions of the Maxmind databases. NGINX is reloaded only when a database changes.
+A value of 0 disables the updates.`)
 	)
 
 	flags.StringVar(&nginx.MaxmindMirror, "maxmind-mirror", "", `Maxmind mirror url (example: http://geoip.local/databases`)
@@ -217,6 +296,9 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 	}
 
 	parser.AnnotationsPrefix = *annotationsPrefix
+	annotations.NamespaceDefaultsConfigMap = *namespaceDefaultsConfigMap
+	annotations.NamespaceAnnotations = *namespaceAnnotations
+	annotations.LockedAnnotations = *lockedAnnotations
 
 	// check port collisions
 	if !ing_net.IsPortAvailable(*httpPort) {
@@ -251,10 +333,50 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		return false, nil, fmt.Errorf("port %v is already in use. Please check the flag --ssl-passthrough-proxy-port", *sslProxyPort)
 	}
 
+	// the SSL Passthrough proxy only uses TCP, so NGINX can listen directly in the UDP port
+	var quicPort int
+	if *enableHTTP3 {
+		if !nginx.IsHTTP3Supported() {
+			return false, nil, fmt.Errorf("flag --enable-http3 requires NGINX built with the HTTP/3 module (%v)", nginx.HTTP3Module)
+		}
+
+		if !ing_net.IsUDPPortAvailable(*http3Port) {
+			return false, nil, fmt.Errorf("UDP port %v is already in use. Please check the flag --http3-port", *http3Port)
+		}
+
+		quicPort = *http3Port
+	}
+
 	if *publishSvc != "" && *publishStatusAddress != "" {
 		return false, nil, fmt.Errorf("flags --publish-service and --publish-status-address are mutually exclusive")
 	}
 
+	addressSets, err := status.ParseAddressSets(*publishAddressSets)
+	if err != nil {
+		return false, nil, fmt.Errorf("%v. Please check the flag --publish-address-set", err)
+	}
+
+	classAddressSets, err := status.ParseClassAddressSets(*publishClassAddressSets, addressSets)
+	if err != nil {
+		return false, nil, fmt.Errorf("%v. Please check the flag --publish-class-address-set", err)
+	}
+
+	ipFamily := apiv1.IPFamily(*publishIPFamily)
+	if ipFamily != "" && ipFamily != apiv1.IPv4Protocol && ipFamily != apiv1.IPv6Protocol {
+		return false, nil, fmt.Errorf("invalid IP family %q. Please check the flag --publish-ip-family", *publishIPFamily)
+	}
+
+	controllerShard := shard.Shard{ID: *shardID, Count: *shardCount}
+	if err := controllerShard.Validate(); err != nil {
+		return false, nil, fmt.Errorf("%v. Please check the flags --shard-id and --shard-count", err)
+	}
+
+	if *ingressSelector != "" {
+		if _, err := labels.Parse(*ingressSelector); err != nil {
+			return false, nil, fmt.Errorf("invalid label selector %q: %v. Please check the flag --ingress-selector", *ingressSelector, err)
+		}
+	}
+
 	nginx.HealthPath = *defHealthzURL
 
 	if *defHealthCheckTimeout > 0 {
@@ -277,6 +399,7 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		DefaultService:         *defaultSvc,
 		Namespace:              *watchNamespace,
 		ConfigMapName:          *configMap,
+		ConfigurationResource:  *configurationResource,
 		TCPConfigMapName:       *tcpConfigMapName,
 		UDPConfigMapName:       *udpConfigMapName,
 		DefaultSSLCertificate:  *defSSLCertificate,
@@ -286,17 +409,36 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		ShutdownGracePeriod:    *shutdownGracePeriod,
 		UseNodeInternalIP:      *useNodeInternalIP,
 		SyncRateLimit:          *syncRateLimit,
+		MinReloadInterval:      *minReloadInterval,
+		MaxReloadDelay:         *maxReloadDelay,
 		ListenPorts: &ngx_config.ListenPorts{
 			Default:  *defServerPort,
 			Health:   *healthzPort,
 			HTTP:     *httpPort,
 			HTTPS:    *httpsPort,
 			SSLProxy: *sslProxyPort,
+			QUIC:     quicPort,
 		},
-		DisableCatchAll:           *disableCatchAll,
-		ValidationWebhook:         *validationWebhook,
-		ValidationWebhookCertPath: *validationWebhookCert,
-		ValidationWebhookKeyPath:  *validationWebhookKey,
+		DisableCatchAll:            *disableCatchAll,
+		ValidationWebhook:          *validationWebhook,
+		ValidationWebhookCertPath:  *validationWebhookCert,
+		ValidationWebhookKeyPath:   *validationWebhookKey,
+		StrictAnnotationValidation: *strictAnnotationValidation,
+		StrictConfigMapValidation:  *strictConfigMapValidation,
+		EnableIncrementalSync:      *enableIncrementalSync,
+		MaxOldWorkerGenerations:    *maxOldWorkerGenerations,
+		Shard:                      controllerShard,
+		IngressSelector:            *ingressSelector,
+		PublishAddressSets:         addressSets,
+		PublishClassAddressSets:    classAddressSets,
+		PublishIPFamily:            ipFamily,
+		PublishPorts:               *publishPorts,
+		ShutdownEndpointsTimeout:   *shutdownEndpointsTimeout,
+		ShutdownDrainTimeout:       *shutdownDrainTimeout,
+		ShutdownDrainConnections:   *shutdownDrainConnections,
+
+		EnableExternalNameResolution: *enableExternalNameResolution,
+		ExternalNamePreferIPv6:       *externalNamePreferIPv6,
 	}
 
 	if *apiserverHost != "" {
@@ -312,6 +454,7 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 			klog.ErrorS(err, "unexpected error downloading GeoIP2 database")
 		}
 		config.MaxmindEditionFiles = nginx.MaxmindEditionFiles
+		config.MaxmindUpdateInterval = *maxmindUpdateInterval
 	}
 
 	return false, config, nil
@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	discovery "k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -78,6 +79,13 @@ func main() {
 		handleFatalInitError(err)
 	}
 
+	if conf.ConfigurationResource != "" {
+		conf.DynamicClient, err = createDynamicClient(conf.APIServerHost, conf.RootCAFile, conf.KubeConfigFile)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+	}
+
 	if len(conf.DefaultService) > 0 {
 		err := checkService(conf.DefaultService, kubeClient)
 		if err != nil {
@@ -207,7 +215,8 @@ func handleSigterm(ngx *controller.NGINXController, exit exiter) {
 // If neither apiserverHost nor kubeConfig is passed in, we assume the
 // controller runs inside Kubernetes and fallback to the in-cluster config. If
 // the in-cluster config is missing or fails, we fallback to the default config.
-func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kubernetes.Clientset, error) {
+// createRestConfig creates the configuration used by the clients of the API server
+func createRestConfig(apiserverHost, rootCAFile, kubeConfig string) (*rest.Config, error) {
 	cfg, err := clientcmd.BuildConfigFromFlags(apiserverHost, kubeConfig)
 	if err != nil {
 		return nil, err
@@ -238,6 +247,25 @@ func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kuber
 		cfg.TLSClientConfig = tlsClientConfig
 	}
 
+	return cfg, nil
+}
+
+// createDynamicClient creates a client for the custom resources
+func createDynamicClient(apiserverHost, rootCAFile, kubeConfig string) (dynamic.Interface, error) {
+	cfg, err := createRestConfig(apiserverHost, rootCAFile, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamic.NewForConfig(cfg)
+}
+
+func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kubernetes.Clientset, error) {
+	cfg, err := createRestConfig(apiserverHost, rootCAFile, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	klog.InfoS("Creating API client", "host", cfg.Host)
 
 	client, err := kubernetes.NewForConfig(cfg)
@@ -311,6 +339,12 @@ func registerHealthz(healthPath string, ic *controller.NGINXController, mux *htt
 		healthz.PingHealthz,
 		ic,
 	)
+
+	// expose the liveness, readiness and startup endpoints
+	// with the result of each check in JSON
+	mux.Handle("/livez", ic.LivezHandler())
+	mux.Handle("/readyz", ic.ReadyzHandler())
+	mux.Handle("/startupz", ic.StartupzHandler())
 }
 
 func registerMetrics(reg *prometheus.Registry, mux *http.ServeMux) {
@@ -31,6 +31,7 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 
 	"k8s.io/ingress-nginx/internal/ingress/controller"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
@@ -103,7 +104,7 @@ func TestHandleSigterm(t *testing.T) {
 	}
 	conf.Client = clientSet
 
-	ngx := controller.NewNGINXController(conf, nil)
+	ngx := controller.NewNGINXController(conf, metric.DummyCollector{})
 
 	go handleSigterm(ngx, func(code int) {
 		if code != 1 {
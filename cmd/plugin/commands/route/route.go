@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"k8s.io/ingress-nginx/cmd/plugin/request"
+	"k8s.io/ingress-nginx/cmd/plugin/util"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+)
+
+// CreateCommand creates and returns this cobra subcommand
+func CreateCommand(flags *genericclioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "route <uri>",
+		Short: "Show the Ingress path matching a request and the URI sent to the backend",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			host, err := cmd.Flags().GetString("host")
+			if err != nil {
+				return err
+			}
+
+			allNamespaces, err := cmd.Flags().GetBool("all-namespaces")
+			if err != nil {
+				return err
+			}
+
+			util.PrintError(route(flags, host, args[0], allNamespaces))
+			return nil
+		},
+	}
+	cmd.Flags().String("host", "", "Hostname of the request")
+	cmd.Flags().Bool("all-namespaces", false, "Find ingress definitions from all namespaces")
+	cobra.MarkFlagRequired(cmd.Flags(), "host")
+
+	return cmd
+}
+
+func route(flags *genericclioptions.ConfigFlags, host, uri string, allNamespaces bool) error {
+	var namespace string
+	if allNamespaces {
+		namespace = ""
+	} else {
+		namespace = util.GetNamespace(flags)
+	}
+
+	requestURL, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return fmt.Errorf("invalid URI %v: %v", uri, err)
+	}
+
+	ingresses, err := request.GetIngressDefinitions(flags, namespace)
+	if err != nil {
+		return err
+	}
+
+	match, err := findRoute(ingresses, host, requestURL.Path)
+	if err != nil {
+		return err
+	}
+	if match == nil {
+		return fmt.Errorf("no Ingress path matches the request to %v%v", host, requestURL.Path)
+	}
+
+	path, query := match.rewrite(requestURL.Path, requestURL.Query())
+
+	printer := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', 0)
+	defer printer.Flush()
+
+	fmt.Fprintf(printer, "INGRESS\t%v/%v\n", match.ingress.Namespace, match.ingress.Name)
+	fmt.Fprintf(printer, "PATH\t%v\n", match.path.Path)
+	fmt.Fprintf(printer, "SERVICE\t%v:%v\n", match.path.Backend.ServiceName, match.path.Backend.ServicePort.String())
+	if rule := match.matchedRule; rule != nil {
+		fmt.Fprintf(printer, "REWRITE RULE\t%v %v %v\n", rule.Regex, rule.Replacement, rule.Flag)
+	}
+	fmt.Fprintf(printer, "UPSTREAM URI\t%v\n", (&url.URL{Path: path, RawQuery: query.Encode()}).RequestURI())
+	if match.regex || match.matchedRule != nil {
+		fmt.Fprintf(printer, "NOTE\tregular expressions evaluated with the Go regexp syntax, NGINX uses PCRE and the result may differ\n")
+	}
+
+	return nil
+}
+
+// routeMatch contains the Ingress path matching a request
+type routeMatch struct {
+	ingress *networking.Ingress
+	path    *networking.HTTPIngressPath
+	exact   bool
+
+	// regex is true when the paths of the Ingress are regular expressions
+	regex bool
+
+	// matchedRule is the rewrite rule applied by rewrite, nil if none matched
+	matchedRule *rewrite.Rule
+}
+
+// findRoute returns the path of the Ingresses matching a request like NGINX
+// would: exact paths first, then the longest prefix or regular expression.
+// When use-regex is enabled the paths of the Ingress are compared as case
+// insensitive regular expressions, without the ordering of NGINX. An error
+// is returned when a path cannot be evaluated by the Go regexp package.
+func findRoute(ingresses []networking.Ingress, host, path string) (*routeMatch, error) {
+	var matches []*routeMatch
+	for i := range ingresses {
+		ing := &ingresses[i]
+		useRegex := annotationValue(ing, "use-regex") == "true" || annotationValue(ing, "rewrite-target") != ""
+
+		for _, rule := range ing.Spec.Rules {
+			if !hostMatches(rule.Host, host) || rule.HTTP == nil {
+				continue
+			}
+
+			for j := range rule.HTTP.Paths {
+				ingPath := &rule.HTTP.Paths[j]
+				if ingPath.Path == "" {
+					continue
+				}
+
+				exact := ingPath.PathType != nil && *ingPath.PathType == networking.PathTypeExact
+				matched, err := pathMatches(ingPath.Path, path, exact, useRegex)
+				if err != nil {
+					return nil, fmt.Errorf("path %v of the Ingress %v/%v: %v", ingPath.Path, ing.Namespace, ing.Name, err)
+				}
+				if !matched {
+					continue
+				}
+
+				matches = append(matches, &routeMatch{ingress: ing, path: ingPath, exact: exact, regex: useRegex && !exact})
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].exact != matches[j].exact {
+			return matches[i].exact
+		}
+		return len(matches[i].path.Path) > len(matches[j].path.Path)
+	})
+
+	return matches[0], nil
+}
+
+func hostMatches(ruleHost, host string) bool {
+	if ruleHost == "" || strings.EqualFold(ruleHost, host) {
+		return true
+	}
+
+	if strings.HasPrefix(ruleHost, "*.") {
+		suffix := strings.ToLower(ruleHost[1:])
+		host = strings.ToLower(host)
+		return strings.HasSuffix(host, suffix) && !strings.Contains(strings.TrimSuffix(host, suffix), ".")
+	}
+
+	return false
+}
+
+func pathMatches(ingPath, path string, exact, useRegex bool) (bool, error) {
+	if exact {
+		return ingPath == path, nil
+	}
+
+	if useRegex {
+		re, err := compileRegex("(?i)^" + ingPath)
+		if err != nil {
+			return false, err
+		}
+
+		return re.MatchString(path), nil
+	}
+
+	return strings.HasPrefix(path, ingPath), nil
+}
+
+// compileRegex compiles a regular expression of NGINX. The constructs of
+// PCRE without an equivalent in the Go regexp package, like lookarounds or
+// backreferences, are rejected instead of being evaluated differently.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("the regular expression uses PCRE syntax not supported by the Go regexp package: %v", err)
+	}
+
+	return re, nil
+}
+
+// rewrite applies the rewrite-rules, rewrite-target and query-params-*
+// annotations of the Ingress to the path and the query of a request.
+// The location search restarted by the flag last is not reproduced.
+func (m *routeMatch) rewrite(path string, query url.Values) (string, url.Values) {
+	rules, err := rewrite.ParseRules(annotationValue(m.ingress, "rewrite-rules"))
+	if err != nil {
+		rules = nil
+	}
+
+	// both flags stop the processing of the rewrite directives of the location
+	for i := range rules {
+		re, err := regexp.Compile(rules[i].Regex)
+		if err != nil {
+			continue
+		}
+
+		if submatches := re.FindStringSubmatchIndex(path); submatches != nil {
+			m.matchedRule = &rules[i]
+			path = string(re.ExpandString(nil, nginxReplacement(rules[i].Replacement), path, submatches))
+			return path, applyQueryParams(m.ingress, query)
+		}
+	}
+
+	if target := annotationValue(m.ingress, "rewrite-target"); target != "" && target != m.path.Path {
+		re, err := regexp.Compile("(?i)" + m.path.Path)
+		if err == nil {
+			if submatches := re.FindStringSubmatchIndex(path); submatches != nil {
+				path = string(re.ExpandString(nil, nginxReplacement(target), path, submatches))
+			}
+		}
+	}
+
+	return path, applyQueryParams(m.ingress, query)
+}
+
+// nginxCaptureRegex matches the references to capture groups used by NGINX, like $1
+var nginxCaptureRegex = regexp.MustCompile(`\$([0-9])`)
+
+// nginxReplacement converts a replacement of NGINX to the template syntax of Go
+func nginxReplacement(replacement string) string {
+	return nginxCaptureRegex.ReplaceAllString(replacement, "$${$1}")
+}
+
+// applyQueryParams removes, renames and adds the query parameters like the
+// query-params-* annotations. Invalid annotations are ignored.
+func applyQueryParams(ing *networking.Ingress, query url.Values) url.Values {
+	for _, name := range strings.Split(annotationValue(ing, "query-params-remove"), ",") {
+		query.Del(strings.TrimSpace(name))
+	}
+
+	for _, pair := range strings.Split(annotationValue(ing, "query-params-rename"), ",") {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		oldName, newName := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if values, ok := query[oldName]; ok {
+			query[newName] = values
+			query.Del(oldName)
+		}
+	}
+
+	for _, pair := range strings.Split(annotationValue(ing, "query-params-add"), ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		query.Set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+
+	return query
+}
+
+// annotationValue returns the value of an annotation of ingress-nginx,
+// regardless of the prefix configured in the ingress controller
+func annotationValue(ing *networking.Ingress, name string) string {
+	for key, value := range ing.Annotations {
+		if strings.HasSuffix(key, "/"+name) {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"net/url"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func buildIngress(name, host string, annotations map[string]string, paths ...networking.HTTPIngressPath) networking.Ingress {
+	return networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{Paths: paths},
+					},
+				},
+			},
+		},
+	}
+}
+
+func buildPath(path string, pathType networking.PathType) networking.HTTPIngressPath {
+	return networking.HTTPIngressPath{
+		Path:     path,
+		PathType: &pathType,
+		Backend: networking.IngressBackend{
+			ServiceName: "app",
+			ServicePort: intstr.FromInt(80),
+		},
+	}
+}
+
+func TestFindRoute(t *testing.T) {
+	regex := map[string]string{"nginx.ingress.kubernetes.io/use-regex": "true"}
+
+	testCases := []struct {
+		title     string
+		ingresses []networking.Ingress
+		host      string
+		path      string
+		expected  string
+		regex     bool
+		expectErr bool
+	}{
+		{
+			title: "longest prefix",
+			ingresses: []networking.Ingress{
+				buildIngress("root", "example.com", nil, buildPath("/", networking.PathTypePrefix)),
+				buildIngress("api", "example.com", nil, buildPath("/api", networking.PathTypePrefix)),
+			},
+			host:     "example.com",
+			path:     "/api/users",
+			expected: "/api",
+		},
+		{
+			title: "exact path before a longer prefix",
+			ingresses: []networking.Ingress{
+				buildIngress("prefix", "example.com", nil, buildPath("/api/users", networking.PathTypePrefix)),
+				buildIngress("exact", "example.com", nil, buildPath("/api", networking.PathTypeExact)),
+			},
+			host:     "example.com",
+			path:     "/api",
+			expected: "/api",
+		},
+		{
+			title: "wildcard host",
+			ingresses: []networking.Ingress{
+				buildIngress("wildcard", "*.example.com", nil, buildPath("/", networking.PathTypePrefix)),
+			},
+			host:     "Foo.Example.com",
+			path:     "/",
+			expected: "/",
+		},
+		{
+			title: "wildcard host does not match more than one label",
+			ingresses: []networking.Ingress{
+				buildIngress("wildcard", "*.example.com", nil, buildPath("/", networking.PathTypePrefix)),
+			},
+			host: "foo.bar.example.com",
+			path: "/",
+		},
+		{
+			title: "other host",
+			ingresses: []networking.Ingress{
+				buildIngress("other", "other.com", nil, buildPath("/", networking.PathTypePrefix)),
+			},
+			host: "example.com",
+			path: "/",
+		},
+		{
+			title: "case insensitive regular expression",
+			ingresses: []networking.Ingress{
+				buildIngress("regex", "example.com", regex, buildPath("/users/[0-9]+", networking.PathTypeImplementationSpecific)),
+			},
+			host:     "example.com",
+			path:     "/Users/42",
+			expected: "/users/[0-9]+",
+			regex:    true,
+		},
+		{
+			title: "regular expression with PCRE syntax",
+			ingresses: []networking.Ingress{
+				buildIngress("pcre", "example.com", regex, buildPath("/(?!admin).*", networking.PathTypeImplementationSpecific)),
+			},
+			host:      "example.com",
+			path:      "/users",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			match, err := findRoute(tc.ingresses, tc.host, tc.path)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but none returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expected == "" {
+				if match != nil {
+					t.Errorf("expected no match but %v returned", match.path.Path)
+				}
+				return
+			}
+
+			if match == nil {
+				t.Fatalf("expected %v but no match returned", tc.expected)
+			}
+			if match.path.Path != tc.expected {
+				t.Errorf("expected %v but %v returned", tc.expected, match.path.Path)
+			}
+			if match.regex != tc.regex {
+				t.Errorf("expected regex %v but %v returned", tc.regex, match.regex)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	testCases := []struct {
+		title        string
+		annotations  map[string]string
+		path         string
+		uri          string
+		expected     string
+		expectedRule string
+	}{
+		{
+			title:    "without rewrite",
+			path:     "/api",
+			uri:      "/api/users?id=1",
+			expected: "/api/users?id=1",
+		},
+		{
+			title:       "rewrite-target with a capture group",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/$2"},
+			path:        "/api(/|$)(.*)",
+			uri:         "/api/users",
+			expected:    "/users",
+		},
+		{
+			title: "first matching rewrite rule",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/rewrite-rules": "^/old/(.*) /new/$1 break\n^/old/page /other last",
+			},
+			path:         "/old",
+			uri:          "/old/page",
+			expected:     "/new/page",
+			expectedRule: "^/old/(.*)",
+		},
+		{
+			title: "rewrite rule before rewrite-target",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/rewrite-rules":  "^/v1/(.*) /v2/$1",
+				"nginx.ingress.kubernetes.io/rewrite-target": "/",
+			},
+			path:         "/v1",
+			uri:          "/v1/users",
+			expected:     "/v2/users",
+			expectedRule: "^/v1/(.*)",
+		},
+		{
+			title: "query parameters",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/query-params-remove": "utm_source",
+				"nginx.ingress.kubernetes.io/query-params-rename": "q:query",
+			},
+			path:     "/",
+			uri:      "/search?utm_source=mail&q=test",
+			expected: "/search?query=test",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			ing := buildIngress("app", "example.com", tc.annotations, buildPath(tc.path, networking.PathTypeImplementationSpecific))
+			match := &routeMatch{ingress: &ing, path: &ing.Spec.Rules[0].HTTP.Paths[0]}
+
+			requestURL, err := url.ParseRequestURI(tc.uri)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			path, query := match.rewrite(requestURL.Path, requestURL.Query())
+			actual := (&url.URL{Path: path, RawQuery: query.Encode()}).RequestURI()
+			if actual != tc.expected {
+				t.Errorf("expected %v but %v returned", tc.expected, actual)
+			}
+
+			rule := ""
+			if match.matchedRule != nil {
+				rule = match.matchedRule.Regex
+			}
+			if rule != tc.expectedRule {
+				t.Errorf("expected the rewrite rule %q but %q returned", tc.expectedRule, rule)
+			}
+		})
+	}
+}
+
+func TestApplyQueryParams(t *testing.T) {
+	testCases := []struct {
+		title       string
+		annotations map[string]string
+		query       string
+		expected    string
+	}{
+		{
+			title:    "without annotations",
+			query:    "a=1&b=2",
+			expected: "a=1&b=2",
+		},
+		{
+			title:       "remove",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/query-params-remove": "a, c"},
+			query:       "a=1&b=2&c=3",
+			expected:    "b=2",
+		},
+		{
+			title:       "rename keeps all the values",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/query-params-rename": "a:x,missing:y"},
+			query:       "a=1&a=2&b=3",
+			expected:    "b=3&x=1&x=2",
+		},
+		{
+			title:       "add replaces the existing value",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/query-params-add": "a=new,c=3"},
+			query:       "a=1&b=2",
+			expected:    "a=new&b=2&c=3",
+		},
+		{
+			title:       "invalid pairs are ignored",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/query-params-rename": "a", "nginx.ingress.kubernetes.io/query-params-add": "c"},
+			query:       "a=1",
+			expected:    "a=1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ing := buildIngress("app", "example.com", tc.annotations)
+			if actual := applyQueryParams(&ing, query).Encode(); actual != tc.expected {
+				t.Errorf("expected %v but %v returned", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shards
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"k8s.io/ingress-nginx/cmd/plugin/request"
+	"k8s.io/ingress-nginx/cmd/plugin/util"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+// CreateCommand creates and returns this cobra subcommand
+func CreateCommand(flags *genericclioptions.ConfigFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shards",
+		Short: "Show the shard of the ingress controllers rendering each host",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			count, err := cmd.Flags().GetInt("shard-count")
+			if err != nil {
+				return err
+			}
+
+			if count < 1 {
+				return fmt.Errorf("--shard-count must be greater than zero")
+			}
+
+			allNamespaces, err := cmd.Flags().GetBool("all-namespaces")
+			if err != nil {
+				return err
+			}
+
+			util.PrintError(shards(flags, count, allNamespaces))
+			return nil
+		},
+	}
+	cmd.Flags().Int("shard-count", 0, "Number of shards, the value of the flag --shard-count of the ingress controllers")
+	cmd.Flags().Bool("all-namespaces", false, "Find ingress definitions from all namespaces")
+	cobra.MarkFlagRequired(cmd.Flags(), "shard-count")
+
+	return cmd
+}
+
+func shards(flags *genericclioptions.ConfigFlags, count int, allNamespaces bool) error {
+	var namespace string
+	if allNamespaces {
+		namespace = ""
+	} else {
+		namespace = util.GetNamespace(flags)
+	}
+
+	ingresses, err := request.GetIngressDefinitions(flags, namespace)
+	if err != nil {
+		return err
+	}
+
+	printer := tabwriter.NewWriter(os.Stdout, 6, 4, 3, ' ', 0)
+	defer printer.Flush()
+
+	fmt.Fprintln(printer, "HOST\tSHARD\tINGRESSES")
+	for _, row := range getShardRows(ingresses, count) {
+		fmt.Fprintf(printer, "%v\t%v\t%v\n", row.Host, row.Shard, strings.Join(row.Ingresses, ","))
+	}
+
+	return nil
+}
+
+type shardRow struct {
+	Host      string
+	Shard     int
+	Ingresses []string
+}
+
+func getShardRows(ingresses []networking.Ingress, count int) []shardRow {
+	ingressesByHost := make(map[string][]string)
+	for i := range ingresses {
+		ing := &ingresses[i]
+		for _, host := range shard.Hosts(ing) {
+			ingressesByHost[host] = append(ingressesByHost[host], fmt.Sprintf("%v/%v", ing.Namespace, ing.Name))
+		}
+	}
+
+	rows := make([]shardRow, 0, len(ingressesByHost))
+	for host, names := range ingressesByHost {
+		sort.Strings(names)
+		rows = append(rows, shardRow{
+			Host:      host,
+			Shard:     shard.ForHost(host, count),
+			Ingresses: names,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Shard != rows[j].Shard {
+			return rows[i].Shard < rows[j].Shard
+		}
+		return rows[i].Host < rows[j].Host
+	})
+
+	return rows
+}
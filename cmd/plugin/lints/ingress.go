@@ -23,6 +23,8 @@ import (
 	networking "k8s.io/api/networking/v1beta1"
 	kmeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/ingress-nginx/cmd/plugin/util"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
 )
 
 // IngressLint is a validation for an ingress
@@ -73,6 +75,18 @@ func GetIngressLints() []IngressLint {
 			version: "0.22.0",
 			f:       rewriteTargetWithoutCaptureGroup,
 		},
+		{
+			message: "The rewrite-rules annotation contains an invalid rule. Expected one <regex> <replacement> [last|break] rule per line",
+			f:       invalidRewriteRules,
+		},
+		{
+			message: "Contains an annotation with the prefix 'nginx.ingress.kubernetes.io' not supported by this version of ingress-nginx",
+			f:       unknownAnnotation,
+		},
+		{
+			message: "Contains an annotation with a value that does not match the type or the allowed values of the annotation",
+			f:       invalidAnnotationValue,
+		},
 		{
 			message: "Contains an annotation with the prefix 'nginx.org'. This is a prefix for https://github.com/nginxinc/kubernetes-ingress",
 			f:       annotationPrefixIsNginxOrg,
@@ -103,6 +117,16 @@ func xForwardedPrefixIsBool(ing networking.Ingress) bool {
 	return false
 }
 
+func unknownAnnotation(ing networking.Ingress) bool {
+	unknown, _ := annotations.ValidateAnnotations(ing.Annotations)
+	return len(unknown) > 0
+}
+
+func invalidAnnotationValue(ing networking.Ingress) bool {
+	_, errs := annotations.ValidateAnnotations(ing.Annotations)
+	return len(errs) > 0
+}
+
 func annotationPrefixIsNginxCom(ing networking.Ingress) bool {
 	for name := range ing.Annotations {
 		if strings.HasPrefix(name, "nginx.com/") {
@@ -130,6 +154,17 @@ func rewriteTargetWithoutCaptureGroup(ing networking.Ingress) bool {
 	return false
 }
 
+func invalidRewriteRules(ing networking.Ingress) bool {
+	for name, val := range ing.Annotations {
+		if strings.HasSuffix(name, "/rewrite-rules") {
+			if _, err := rewrite.ParseRules(val); err != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func removedAnnotation(annotationName string, issueNumber int, version string) IngressLint {
 	return IngressLint{
 		message: fmt.Sprintf("Contains the removed %v annotation.", annotationName),
@@ -37,6 +37,8 @@ import (
 	"k8s.io/ingress-nginx/cmd/plugin/commands/ingresses"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/lint"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/logs"
+	"k8s.io/ingress-nginx/cmd/plugin/commands/route"
+	"k8s.io/ingress-nginx/cmd/plugin/commands/shards"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/ssh"
 )
 
@@ -60,6 +62,8 @@ func main() {
 	rootCmd.AddCommand(exec.CreateCommand(flags))
 	rootCmd.AddCommand(ssh.CreateCommand(flags))
 	rootCmd.AddCommand(lint.CreateCommand(flags))
+	rootCmd.AddCommand(shards.CreateCommand(flags))
+	rootCmd.AddCommand(route.CreateCommand(flags))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
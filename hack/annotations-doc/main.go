@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+)
+
+const header = `<!-- Generated by hack/annotations-doc. DO NOT EDIT. -->
+
+# Annotations reference
+
+The following table is generated from the schema declared by the annotation parsers.
+The same schema is used by the validating admission webhook and the lints of the kubectl plugin.
+
+- **Scope**: ` + "`location`" + ` annotations only affect the paths of the Ingress, ` + "`server`" + ` annotations affect
+  the server block shared by all the Ingresses using the host and ` + "`backend`" + ` annotations affect the upstream
+  shared by all the Ingresses referencing the Service. ` + "`ingress`" + ` annotations do not change the NGINX
+  configuration, only the Ingress object.
+- **Risk**: ` + "`Critical`" + ` annotations inject raw NGINX configuration, ` + "`High`" + ` annotations can expose or bypass
+  access controls and ` + "`Medium`" + ` annotations reference other objects or change how the traffic is routed.
+- **Default**: an empty value means the default is obtained from the [ConfigMap](configmap.md).
+
+`
+
+func main() {
+	fmt.Print(header)
+
+	if err := annotations.WriteSchemaDocs(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error generating the annotations reference: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/template"
+)
+
+const header = `# Generated by hack/configuration-crd. DO NOT EDIT.
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: nginxconfigurations.ingress-nginx.k8s.io
+  annotations:
+    api-approved.kubernetes.io: "unapproved, experimental"
+spec:
+  group: ingress-nginx.k8s.io
+  names:
+    kind: NGINXConfiguration
+    listKind: NGINXConfigurationList
+    plural: nginxconfigurations
+    singular: nginxconfiguration
+  scope: Namespaced
+  versions:
+    - name: v1alpha1
+      served: true
+      storage: true
+      subresources:
+        status: {}
+      additionalPrinterColumns:
+        - name: Valid
+          type: string
+          jsonPath: .status.conditions[?(@.type=="Valid")].status
+        - name: Applied
+          type: string
+          jsonPath: .status.conditions[?(@.type=="Applied")].status
+        - name: Age
+          type: date
+          jsonPath: .metadata.creationTimestamp
+      schema:
+        openAPIV3Schema:
+          type: object
+          description: NGINXConfiguration contains the global configuration of the ingress controller.
+          properties:
+            spec:
+              type: object
+              properties:
+                settings:
+                  type: object
+                  description: Settings contains the options of the configuration ConfigMap. Lists can be defined as arrays.
+                  properties:
+`
+
+const footer = `            status:
+              type: object
+              properties:
+                observedGeneration:
+                  type: integer
+                  format: int64
+                  description: Generation of the resource used in the last sync.
+                lastAppliedChecksum:
+                  type: string
+                  description: Checksum of the configuration applied in the last successful reload.
+                validationErrors:
+                  type: array
+                  description: Unknown settings and invalid values found in the configuration.
+                  items:
+                    type: string
+                conditions:
+                  type: array
+                  items:
+                    type: object
+                    required:
+                      - type
+                      - status
+                    properties:
+                      type:
+                        type: string
+                      status:
+                        type: string
+                      reason:
+                        type: string
+                      message:
+                        type: string
+                      lastTransitionTime:
+                        type: string
+                        format: date-time
+`
+
+func main() {
+	settings := template.Settings()
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(header)
+
+	indent := strings.Repeat(" ", 20)
+	for _, name := range names {
+		setting := settings[name]
+		fmt.Fprintf(&b, "%v%v:\n", indent, name)
+		fmt.Fprintf(&b, "%v  type: %v\n", indent, setting.Type)
+		if setting.Items != "" {
+			fmt.Fprintf(&b, "%v  items:\n", indent)
+			fmt.Fprintf(&b, "%v    type: %v\n", indent, setting.Items)
+		}
+	}
+
+	b.WriteString(footer)
+	fmt.Print(b.String())
+}
@@ -60,6 +60,15 @@ export LUA_RESTY_GLOBAL_THROTTLE_VERSION=0.2.0
 
 export BUILD_PATH=/tmp/build
 
+# Build the HTTP/3 variant of the image, using the nginx-quic branch and
+# quictls instead of the NGINX release and the OpenSSL of the system.
+# The revisions are pinned and verified like any other source.
+export BUILD_HTTP3=${BUILD_HTTP3:-false}
+export NGINX_QUIC_REVISION=${NGINX_QUIC_REVISION:-}
+export NGINX_QUIC_SHA256=${NGINX_QUIC_SHA256:-}
+export QUICTLS_COMMIT=${QUICTLS_COMMIT:-}
+export QUICTLS_SHA256=${QUICTLS_SHA256:-}
+
 ARCH=$(uname -m)
 
 get_src()
@@ -121,8 +130,26 @@ mkdir --verbose -p "$BUILD_PATH"
 cd "$BUILD_PATH"
 
 # download, verify and extract the source files
-get_src b11195a02b1d3285ddf2987e02c6b6d28df41bb1b1dd25f33542848ef4fc33b5 \
-        "https://nginx.org/download/nginx-$NGINX_VERSION.tar.gz"
+if [[ "$BUILD_HTTP3" == "true" ]]; then
+  if [[ -z "$NGINX_QUIC_REVISION" || -z "$NGINX_QUIC_SHA256" || -z "$QUICTLS_COMMIT" || -z "$QUICTLS_SHA256" ]]; then
+    echo "BUILD_HTTP3 requires NGINX_QUIC_REVISION, NGINX_QUIC_SHA256, QUICTLS_COMMIT and QUICTLS_SHA256"
+    exit 1
+  fi
+
+  # the nginx-quic branch of the release $NGINX_VERSION
+  get_src $NGINX_QUIC_SHA256 \
+          "https://hg.nginx.org/nginx-quic/archive/$NGINX_QUIC_REVISION.tar.gz"
+  mv "nginx-quic-$NGINX_QUIC_REVISION" "nginx-$NGINX_VERSION"
+  cp "nginx-$NGINX_VERSION/auto/configure" "nginx-$NGINX_VERSION/"
+
+  # OpenSSL with the QUIC API required by the HTTP/3 module
+  get_src $QUICTLS_SHA256 \
+          "https://github.com/quictls/openssl/archive/$QUICTLS_COMMIT.tar.gz"
+  mv "openssl-$QUICTLS_COMMIT" quictls
+else
+  get_src b11195a02b1d3285ddf2987e02c6b6d28df41bb1b1dd25f33542848ef4fc33b5 \
+          "https://nginx.org/download/nginx-$NGINX_VERSION.tar.gz"
+fi
 
 get_src 0e971105e210d272a497567fa2e2c256f4e39b845a5ba80d373e26ba1abfbd85 \
         "https://github.com/simpl/ngx_devel_kit/archive/v$NDK_VERSION.tar.gz"
@@ -474,6 +501,10 @@ cd "$BUILD_PATH/nginx-$NGINX_VERSION"
 # apply nginx patches
 for PATCH in `ls /patches`;do
   echo "Patch: $PATCH"
+  if ! patch -p1 --dry-run < /patches/$PATCH > /dev/null; then
+    echo "Patch $PATCH does not apply to the source of nginx-$NGINX_VERSION (HTTP/3: $BUILD_HTTP3)"
+    exit 1
+  fi
   patch -p1 < /patches/$PATCH
 done
 
@@ -521,6 +552,10 @@ if [[ ${ARCH} == "x86_64" ]]; then
   CC_OPT+=' -m64 -mtune=native'
 fi
 
+if [[ "$BUILD_HTTP3" == "true" ]]; then
+  WITH_FLAGS+=" --with-http_v3_module --with-openssl=$BUILD_PATH/quictls"
+fi
+
 WITH_MODULES=" \
   --add-module=$BUILD_PATH/ngx_devel_kit-$NDK_VERSION \
   --add-module=$BUILD_PATH/set-misc-nginx-module-$SETMISC_VERSION \
@@ -29,6 +29,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/klog/v2"
+
+	ingressstatus "k8s.io/ingress-nginx/internal/ingress/status"
 )
 
 // Checker must return an error if the ingress provided as argument
@@ -97,6 +99,20 @@ func (ia *IngressAdmission) HandleAdmission(obj runtime.Object) (runtime.Object,
 		return convertResponse(review, outputVersion), nil
 	}
 
+	// the conditions written by the ingress controller are not validated,
+	// an invalid Ingress would prevent the update of its conditions
+	if review.Request.OldObject.Raw != nil {
+		oldIngress := networking.Ingress{}
+		_, _, err := codec.Decode(review.Request.OldObject.Raw, nil, &oldIngress)
+		if err == nil && ingressstatus.OnlyConditionsChanged(&oldIngress, &ingress) {
+			klog.V(3).InfoS("only the conditions changed, accepting", "ingress", fmt.Sprintf("%v/%v", review.Request.Name, review.Request.Namespace))
+			status.Allowed = true
+			review.Response = status
+
+			return convertResponse(review, outputVersion), nil
+		}
+	}
+
 	if err := ia.Checker.CheckIngress(&ingress); err != nil {
 		klog.ErrorS(err, "invalid ingress configuration", "ingress", fmt.Sprintf("%v/%v", review.Request.Name, review.Request.Namespace))
 		status.Allowed = false
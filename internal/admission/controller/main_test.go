@@ -25,6 +25,8 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
+
+	"k8s.io/ingress-nginx/internal/ingress/status"
 )
 
 const testIngressName = "testIngressName"
@@ -116,4 +118,22 @@ func TestHandleAdmission(t *testing.T) {
 	if !review.Response.Allowed {
 		t.Fatalf("when the checker returns no error, the request should be allowed")
 	}
+
+	raw, err = json.Marshal(networking.Ingress{ObjectMeta: v1.ObjectMeta{
+		Name:        testIngressName,
+		Annotations: map[string]string{status.ConditionsAnnotation: "[]"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to prepare test ingress data: %v", err.Error())
+	}
+
+	review.Request.OldObject.Raw = review.Request.Object.Raw
+	review.Request.Object.Raw = raw
+
+	adm.Checker = failTestChecker{t: t}
+
+	adm.HandleAdmission(review)
+	if !review.Response.Allowed {
+		t.Fatalf("when only the conditions change, the request should be allowed")
+	}
 }
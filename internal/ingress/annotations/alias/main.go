@@ -27,6 +27,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var aliasAnnotations = parser.AnnotationFields{
+	"server-alias": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskHigh,
+		Documentation: "Comma separated list of additional server names of the host",
+	},
+}
+
 type alias struct {
 	r resolver.Resolver
 }
@@ -61,3 +70,8 @@ func (a alias) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return l, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a alias) GetDocumentation() parser.AnnotationFields {
+	return aliasAnnotations
+}
@@ -17,16 +17,20 @@ limitations under the License.
 package annotations
 
 import (
+	"fmt"
+
 	"github.com/imdario/mergo"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/publishaddressset"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	"k8s.io/klog/v2"
 
 	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/alias"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
@@ -37,6 +41,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodybuffersize"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/customerrorpages"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customhttperrors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
@@ -83,6 +88,7 @@ type Ingress struct {
 	ConfigurationSnippet string
 	Connection           connection.Config
 	CorsConfig           cors.Config
+	CustomErrorPages     string
 	CustomHTTPErrors     []int
 	DefaultBackend       *apiv1.Service
 	//TODO: Change this back into an error when https://github.com/imdario/mergo/issues/100 is resolved
@@ -115,16 +121,19 @@ type Ingress struct {
 	InfluxDB           influxdb.Config
 	ModSecurity        modsecurity.Config
 	Mirror             mirror.Config
+	PublishAddressSet  string
 }
 
 // Extractor defines the annotation parsers to be used in the extraction of annotations
 type Extractor struct {
+	cfg         resolver.Resolver
 	annotations map[string]parser.IngressAnnotation
 }
 
 // NewAnnotationExtractor creates a new annotations extractor
 func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 	return Extractor{
+		cfg,
 		map[string]parser.IngressAnnotation{
 			"Aliases":              alias.NewParser(cfg),
 			"BasicDigestAuth":      auth.NewParser(auth.AuthDirectory, cfg),
@@ -134,6 +143,7 @@ func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 			"ConfigurationSnippet": snippet.NewParser(cfg),
 			"Connection":           connection.NewParser(cfg),
 			"CorsConfig":           cors.NewParser(cfg),
+			"CustomErrorPages":     customerrorpages.NewParser(cfg),
 			"CustomHTTPErrors":     customhttperrors.NewParser(cfg),
 			"DefaultBackend":       defaultbackend.NewParser(cfg),
 			"FastCGI":              fastcgi.NewParser(cfg),
@@ -165,16 +175,25 @@ func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 			"BackendProtocol":      backendprotocol.NewParser(cfg),
 			"ModSecurity":          modsecurity.NewParser(cfg),
 			"Mirror":               mirror.NewParser(cfg),
+			"PublishAddressSet":    publishaddressset.NewParser(cfg),
 		},
 	}
 }
 
-// Extract extracts the annotations from an Ingress
-func (e Extractor) Extract(ing *networking.Ingress) *Ingress {
+// Extract extracts the annotations from an Ingress. The returned error
+// contains all the errors found reading the annotations. The fields of the
+// parsed annotations with errors contain the default values. The default
+// annotations of the IngressClass and the namespace are applied before parsing.
+func (e Extractor) Extract(ing *networking.Ingress) (*Ingress, error) {
 	pia := &Ingress{
 		ObjectMeta: ing.ObjectMeta,
 	}
 
+	ing, errs := withDefaults(e.cfg, ing)
+
+	_, schemaErrs := ValidateAnnotations(ing.GetAnnotations())
+	errs = append(errs, schemaErrs...)
+
 	data := make(map[string]interface{})
 	for name, annotationParser := range e.annotations {
 		val, err := annotationParser.Parse(ing)
@@ -184,6 +203,8 @@ func (e Extractor) Extract(ing *networking.Ingress) *Ingress {
 				continue
 			}
 
+			errs = append(errs, err)
+
 			if !errors.IsLocationDenied(err) {
 				continue
 			}
@@ -212,10 +233,32 @@ func (e Extractor) Extract(ing *networking.Ingress) *Ingress {
 		}
 	}
 
-	err := mergo.MapWithOverwrite(pia, data)
-	if err != nil {
-		klog.ErrorS(err, "unexpected error merging extracted annotations")
+	// merge each field independently to report the fields that cannot be merged
+	for name, val := range data {
+		err := mergo.MapWithOverwrite(pia, map[string]interface{}{name: val})
+		if err != nil {
+			klog.ErrorS(err, "unexpected error merging extracted annotations", "field", name, "ingress", klog.KObj(ing))
+			errs = append(errs, fmt.Errorf("unexpected error merging the value of %v: %v", name, err))
+		}
+	}
+
+	return pia, utilerrors.NewAggregate(uniqueErrors(errs))
+}
+
+// uniqueErrors removes the errors with the same message. The validation
+// of the schema and the parsers can report the same error.
+func uniqueErrors(errs []error) []error {
+	seen := make(map[string]bool, len(errs))
+
+	var unique []error
+	for _, err := range errs {
+		if seen[err.Error()] {
+			continue
+		}
+
+		seen[err.Error()] = true
+		unique = append(unique, err)
 	}
 
-	return pia
+	return unique
 }
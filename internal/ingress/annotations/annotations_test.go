@@ -22,6 +22,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -126,7 +127,8 @@ func TestSSLPassthrough(t *testing.T) {
 
 	for _, foo := range fooAnns {
 		ing.SetAnnotations(foo.annotations)
-		r := ec.Extract(ing).SSLPassthrough
+		pia, _ := ec.Extract(ing)
+		r := pia.SSLPassthrough
 		if r != foo.er {
 			t.Errorf("Returned %v but expected %v", r, foo.er)
 		}
@@ -150,7 +152,8 @@ func TestUpstreamHashBy(t *testing.T) {
 
 	for _, foo := range fooAnns {
 		ing.SetAnnotations(foo.annotations)
-		r := ec.Extract(ing).UpstreamHashBy.UpstreamHashBy
+		pia, _ := ec.Extract(ing)
+		r := pia.UpstreamHashBy.UpstreamHashBy
 		if r != foo.er {
 			t.Errorf("Returned %v but expected %v", r, foo.er)
 		}
@@ -176,7 +179,8 @@ func TestAffinitySession(t *testing.T) {
 
 	for _, foo := range fooAnns {
 		ing.SetAnnotations(foo.annotations)
-		r := ec.Extract(ing).SessionAffinity
+		pia, _ := ec.Extract(ing)
+		r := pia.SessionAffinity
 		t.Logf("Testing pass %v %v", foo.affinitytype, foo.name)
 
 		if r.Mode != foo.affinitymode {
@@ -211,7 +215,8 @@ func TestCors(t *testing.T) {
 
 	for _, foo := range fooAnns {
 		ing.SetAnnotations(foo.annotations)
-		r := ec.Extract(ing).CorsConfig
+		pia, _ := ec.Extract(ing)
+		r := pia.CorsConfig
 		t.Logf("Testing pass %v %v %v %v %v", foo.corsenabled, foo.methods, foo.headers, foo.origin, foo.credentials)
 
 		if r.CorsEnabled != foo.corsenabled {
@@ -254,7 +259,8 @@ func TestCustomHTTPErrors(t *testing.T) {
 
 	for _, foo := range fooAnns {
 		ing.SetAnnotations(foo.annotations)
-		r := ec.Extract(ing).CustomHTTPErrors
+		pia, _ := ec.Extract(ing)
+		r := pia.CustomHTTPErrors
 
 		// Check that expected codes were created
 		for i := range foo.er {
@@ -316,3 +322,40 @@ func TestMergeLocationAnnotations(t *testing.T) {
 	}
 }
 */
+
+func TestExtractErrors(t *testing.T) {
+	ec := NewAnnotationExtractor(mockCfg{})
+	ing := buildIngress()
+
+	ing.SetAnnotations(map[string]string{
+		annotationCorsEnabled:                                "true",
+		annotationCorsAllowMethods:                           "GET;POST",
+		parser.GetAnnotationWithPrefix("proxy-read-timeout"): "30s",
+	})
+
+	pia, err := ec.Extract(ing)
+	if err == nil {
+		t.Fatalf("expected an error but returned nil")
+	}
+
+	if !pia.CorsConfig.CorsEnabled {
+		t.Errorf("expected the valid annotations to be extracted")
+	}
+
+	if pia.CorsConfig.CorsAllowMethods != defaultCorsMethods {
+		t.Errorf("expected the default methods %v but returned %v", defaultCorsMethods, pia.CorsConfig.CorsAllowMethods)
+	}
+
+	errs := err.(utilerrors.Aggregate).Errors()
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors but returned %v", errs)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		annotationCorsEnabled: "true",
+	})
+
+	if _, err := ec.Extract(ing); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
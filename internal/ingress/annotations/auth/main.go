@@ -33,6 +33,36 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var authAnnotations = parser.AnnotationFields{
+	"auth-type": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"basic", "digest"},
+		Documentation: "Type of HTTP authentication",
+	},
+	"auth-secret": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the Secret containing the user and password list, optionally prefixed by the namespace",
+	},
+	"auth-secret-type": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "auth-file",
+		AllowedValues: []string{"auth-file", "auth-map"},
+		Documentation: "Format of the auth-secret Secret",
+	},
+	"auth-realm": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Realm (message) to display with the authentication request",
+	},
+}
+
 var (
 	authTypeRegex = regexp.MustCompile(`basic|digest`)
 	// AuthDirectory default directory used to store files
@@ -173,6 +203,11 @@ func (a auth) Parse(ing *networking.Ingress) (interface{}, error) {
 	}, nil
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (a auth) GetDocumentation() parser.AnnotationFields {
+	return authAnnotations
+}
+
 // dumpSecret dumps the content of a secret into a file
 // in the expected format for the specified authorization
 func dumpSecretAuthFile(filename string, secret *api.Secret) error {
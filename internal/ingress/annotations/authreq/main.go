@@ -31,6 +31,70 @@ import (
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var authreqAnnotations = parser.AnnotationFields{
+	"auth-url": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskHigh,
+		Documentation: "URL of the external authentication service",
+	},
+	"auth-method": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "HTTP method used in the request to the external authentication service",
+	},
+	"auth-signin": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskHigh,
+		Documentation: "URL of the page used to redirect unauthenticated requests",
+	},
+	"auth-signin-redirect-param": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Query parameter containing the URL of the original request in the sign in page",
+	},
+	"auth-snippet": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskCritical,
+		Documentation: "Custom NGINX configuration added to the external authentication location",
+	},
+	"auth-cache-key": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Key used to cache the responses of the external authentication service",
+	},
+	"auth-cache-duration": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "200 202 401 5m",
+		Documentation: "Caching time of the responses of the external authentication service, per status code",
+	},
+	"auth-response-headers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Comma separated list of headers copied from the authentication response to the upstream request",
+	},
+	"auth-proxy-set-headers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the ConfigMap with the headers sent to the external authentication service",
+	},
+	"auth-request-redirect": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the X-Auth-Request-Redirect header sent to the external authentication service",
+	},
+}
+
 // Config returns external authentication configuration for an Ingress rule
 type Config struct {
 	URL string `json:"url"`
@@ -122,6 +186,7 @@ func ValidHeader(header string) bool {
 // ValidCacheDuration checks if the provided string is a valid cache duration
 // spec: [code ...] [time ...];
 // with: code is an http status code
+//
 //       time must match the time regex and may appear multiple times, e.g. `1h 30m`
 func ValidCacheDuration(duration string) bool {
 	elements := strings.Split(duration, " ")
@@ -253,6 +318,11 @@ func (a authReq) Parse(ing *networking.Ingress) (interface{}, error) {
 	}, nil
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (a authReq) GetDocumentation() parser.AnnotationFields {
+	return authreqAnnotations
+}
+
 // ParseStringToCacheDurations parses and validates the provided string
 // into a list of cache durations.
 // It will always return at least one duration (the default duration)
@@ -23,6 +23,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var authreqglobalAnnotations = parser.AnnotationFields{
+	"enable-global-auth": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskHigh,
+		Default:       "true",
+		Documentation: "Enables the global external authentication configured in the ConfigMap",
+	},
+}
+
 type authReqGlobal struct {
 	r resolver.Resolver
 }
@@ -43,3 +53,8 @@ func (a authReqGlobal) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return enableGlobalAuth, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a authReqGlobal) GetDocumentation() parser.AnnotationFields {
+	return authreqglobalAnnotations
+}
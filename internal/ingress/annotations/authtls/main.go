@@ -30,6 +30,64 @@ import (
 	"k8s.io/ingress-nginx/internal/k8s"
 )
 
+var authtlsAnnotations = parser.AnnotationFields{
+	"auth-tls-secret": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the Secret containing the CA certificate used to verify client certificates",
+	},
+	"auth-tls-verify-client": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskMedium,
+		Default:       "on",
+		AllowedValues: []string{"on", "off", "optional", "optional_no_ca"},
+		Documentation: "Enables verification of client certificates",
+	},
+	"auth-tls-verify-depth": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "1",
+		Documentation: "Validation depth of the client certificate chain",
+	},
+	"auth-tls-error-page": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "URL used to redirect requests with a certificate verification error",
+	},
+	"auth-tls-pass-certificate-to-upstream": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Passes the client certificate to the upstream in the ssl-client-cert header",
+	},
+	"auth-tls-ocsp": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "off",
+		AllowedValues: []string{"on", "off", "leaf"},
+		Documentation: "Enables OCSP validation of the client certificate chain",
+	},
+	"auth-tls-ocsp-responder": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "URL of the OCSP responder used to validate client certificates",
+	},
+	"auth-tls-ocsp-cache": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "off",
+		Documentation: "Cache used to store the OCSP responses",
+	},
+}
+
 const (
 	defaultAuthTLSDepth     = 1
 	defaultAuthVerifyClient = "on"
@@ -168,3 +226,8 @@ func (a authTLS) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a authTLS) GetDocumentation() parser.AnnotationFields {
+	return authtlsAnnotations
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceupstream"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// BackendAnnotations contains the annotations that can be defined in a Service.
+// The values are used by every Ingress referencing the Service unless the
+// Ingress defines the same annotation.
+var BackendAnnotations = []string{
+	"backend-protocol",
+	"proxy-ssl-secret",
+	"proxy-ssl-ciphers",
+	"proxy-ssl-protocols",
+	"proxy-ssl-name",
+	"proxy-ssl-verify",
+	"proxy-ssl-verify-depth",
+	"proxy-ssl-server-name",
+	"load-balance",
+	"upstream-hash-by",
+	"upstream-hash-by-subset",
+	"upstream-hash-by-subset-size",
+	"service-upstream",
+}
+
+// BackendExtractor defines the annotation parsers used to extract the
+// configuration of a backend from a Service and the Ingress referencing it
+type BackendExtractor struct {
+	cfg         resolver.Resolver
+	annotations map[string]parser.IngressAnnotation
+}
+
+// NewBackendExtractor creates a new backend annotations extractor
+func NewBackendExtractor(cfg resolver.Resolver) BackendExtractor {
+	return BackendExtractor{
+		cfg,
+		map[string]parser.IngressAnnotation{
+			"BackendProtocol": backendprotocol.NewParser(cfg),
+			"ProxySSL":        proxyssl.NewParser(cfg),
+			"LoadBalancing":   loadbalancing.NewParser(cfg),
+			"UpstreamHashBy":  upstreamhashby.NewParser(cfg),
+			"ServiceUpstream": serviceupstream.NewParser(cfg),
+		},
+	}
+}
+
+// serviceBackendAnnotations returns the backend annotations defined in a Service
+func serviceBackendAnnotations(svc *apiv1.Service) map[string]string {
+	anns := make(map[string]string)
+	if svc == nil {
+		return anns
+	}
+
+	for _, key := range BackendAnnotations {
+		name := parser.GetAnnotationWithPrefix(key)
+		val, ok := svc.GetAnnotations()[name]
+		if !ok {
+			continue
+		}
+
+		if key == "proxy-ssl-secret" {
+			// a Service can only reference secrets located in its own namespace
+			ns, _, err := k8s.ParseNameNS(val)
+			if err != nil || ns != svc.Namespace {
+				klog.Warningf("Annotation %v in Service %v/%v must reference a secret in namespace %v. Ignoring", name, svc.Namespace, svc.Name, svc.Namespace)
+				continue
+			}
+		}
+
+		anns[name] = val
+	}
+
+	return anns
+}
+
+// ServiceProxySSLSecret returns the secret, with the format namespace/name,
+// referenced by the proxy-ssl-secret annotation of a Service
+func ServiceProxySSLSecret(svc *apiv1.Service) string {
+	return serviceBackendAnnotations(svc)[parser.GetAnnotationWithPrefix("proxy-ssl-secret")]
+}
+
+// HasBackendAnnotations returns true if the Service defines at least one of
+// the annotations contained in BackendAnnotations
+func HasBackendAnnotations(svc *apiv1.Service) bool {
+	return len(serviceBackendAnnotations(svc)) > 0
+}
+
+// Extract returns a copy of the parsed annotations of an Ingress with the
+// backend annotations of the referenced Service applied. Annotations present
+// in the Ingress, including the default annotations of the IngressClass and
+// the namespace, take precedence over the ones defined in the Service.
+// If the Service does not contain backend annotations anns is returned.
+func (e BackendExtractor) Extract(ing *networking.Ingress, anns *Ingress, svc *apiv1.Service) *Ingress {
+	merged := serviceBackendAnnotations(svc)
+	if len(merged) == 0 {
+		return anns
+	}
+
+	ing, _ = withDefaults(e.cfg, ing)
+	for name, val := range ing.GetAnnotations() {
+		merged[name] = val
+	}
+
+	backendIng := &networking.Ingress{
+		ObjectMeta: *ing.ObjectMeta.DeepCopy(),
+	}
+	backendIng.SetAnnotations(merged)
+
+	pia := *anns
+	for name, annotationParser := range e.annotations {
+		val, err := annotationParser.Parse(backendIng)
+		if err != nil {
+			if !errors.IsMissingAnnotations(err) {
+				klog.Warningf("Error reading annotation %v from Service %v/%v: %v", name, svc.Namespace, svc.Name, err)
+			}
+
+			continue
+		}
+
+		switch name {
+		case "BackendProtocol":
+			pia.BackendProtocol = val.(string)
+		case "ProxySSL":
+			pia.ProxySSL = *val.(*proxyssl.Config)
+		case "LoadBalancing":
+			pia.LoadBalancing = val.(string)
+		case "UpstreamHashBy":
+			pia.UpstreamHashBy = *val.(*upstreamhashby.Config)
+		case "ServiceUpstream":
+			pia.ServiceUpstream = val.(bool)
+		}
+	}
+
+	return &pia
+}
+
+// BackendConflicts returns the names of the annotations that configure an
+// upstream and contain different values in a and b
+func BackendConflicts(a, b *Ingress) []string {
+	var conflicts []string
+
+	if a.LoadBalancing != b.LoadBalancing {
+		conflicts = append(conflicts, "load-balance")
+	}
+
+	if a.UpstreamHashBy != b.UpstreamHashBy {
+		conflicts = append(conflicts, "upstream-hash-by")
+	}
+
+	if a.ServiceUpstream != b.ServiceUpstream {
+		conflicts = append(conflicts, "service-upstream")
+	}
+
+	return conflicts
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
+)
+
+func buildService(annotations map[string]string) *apiv1.Service {
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default-backend",
+			Namespace:   apiv1.NamespaceDefault,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestBackendExtractor(t *testing.T) {
+	ec := NewAnnotationExtractor(mockCfg{})
+	bec := NewBackendExtractor(mockCfg{
+		MockSecrets: map[string]*apiv1.Secret{
+			"default/backend-ca": {},
+			"other/backend-ca":   {},
+		},
+	})
+
+	annotationBackendProtocol := parser.GetAnnotationWithPrefix("backend-protocol")
+	annotationLoadBalance := parser.GetAnnotationWithPrefix("load-balance")
+	annotationProxySSLSecret := parser.GetAnnotationWithPrefix("proxy-ssl-secret")
+
+	testCases := []struct {
+		title              string
+		svcAnnotations     map[string]string
+		ingAnnotations     map[string]string
+		expProtocol        string
+		expLoadBalance     string
+		expUpstreamHashBy  string
+		expProxySSLSecret  string
+		expServiceUpstream bool
+	}{
+		{"no annotations", nil, nil, "HTTP", "", "", "", false},
+		{"only ingress annotations", nil, map[string]string{annotationBackendProtocol: "HTTPS"}, "HTTPS", "", "", "", false},
+		{"service annotations", map[string]string{
+			annotationBackendProtocol:                          "GRPC",
+			annotationLoadBalance:                              "ewma",
+			annotationUpstreamHashBy:                           "$request_uri",
+			parser.GetAnnotationWithPrefix("service-upstream"): "true",
+		}, nil, "GRPC", "ewma", "$request_uri", "", true},
+		{"ingress annotations override service annotations", map[string]string{
+			annotationBackendProtocol: "GRPC",
+			annotationLoadBalance:     "ewma",
+		}, map[string]string{
+			annotationLoadBalance: "round_robin",
+		}, "GRPC", "round_robin", "", "", false},
+		{"service annotations referencing a secret in the same namespace", map[string]string{
+			annotationProxySSLSecret: "default/backend-ca",
+		}, nil, "HTTP", "", "", "default/backend-ca", false},
+		{"service annotations referencing a secret in other namespace", map[string]string{
+			annotationProxySSLSecret: "other/backend-ca",
+		}, nil, "HTTP", "", "", "", false},
+	}
+
+	for _, testCase := range testCases {
+		ing := buildIngress()
+		ing.SetAnnotations(testCase.ingAnnotations)
+
+		pia, _ := ec.Extract(ing)
+		anns := bec.Extract(ing, pia, buildService(testCase.svcAnnotations))
+
+		if anns.BackendProtocol != testCase.expProtocol {
+			t.Errorf("%v: expected backend protocol %v but returned %v", testCase.title, testCase.expProtocol, anns.BackendProtocol)
+		}
+		if anns.LoadBalancing != testCase.expLoadBalance {
+			t.Errorf("%v: expected load balance %v but returned %v", testCase.title, testCase.expLoadBalance, anns.LoadBalancing)
+		}
+		if anns.UpstreamHashBy.UpstreamHashBy != testCase.expUpstreamHashBy {
+			t.Errorf("%v: expected upstream hash by %v but returned %v", testCase.title, testCase.expUpstreamHashBy, anns.UpstreamHashBy.UpstreamHashBy)
+		}
+		if anns.ProxySSL.Secret != testCase.expProxySSLSecret {
+			t.Errorf("%v: expected proxy ssl secret %v but returned %v", testCase.title, testCase.expProxySSLSecret, anns.ProxySSL.Secret)
+		}
+		if anns.ServiceUpstream != testCase.expServiceUpstream {
+			t.Errorf("%v: expected service upstream %v but returned %v", testCase.title, testCase.expServiceUpstream, anns.ServiceUpstream)
+		}
+	}
+}
+
+func TestBackendConflicts(t *testing.T) {
+	a := &Ingress{
+		LoadBalancing:  "ewma",
+		UpstreamHashBy: upstreamhashby.Config{UpstreamHashBy: "$request_uri"},
+	}
+
+	if conflicts := BackendConflicts(a, a); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts but returned %v", conflicts)
+	}
+
+	b := &Ingress{
+		LoadBalancing:   "round_robin",
+		UpstreamHashBy:  upstreamhashby.Config{UpstreamHashBy: "$request_uri"},
+		ServiceUpstream: true,
+	}
+
+	expected := []string{"load-balance", "service-upstream"}
+	if conflicts := BackendConflicts(a, b); !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("expected %v but returned %v", expected, conflicts)
+	}
+}
@@ -27,6 +27,17 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var backendprotocolAnnotations = parser.AnnotationFields{
+	"backend-protocol": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "HTTP",
+		AllowedValues: []string{"HTTP", "HTTPS", "AJP", "GRPC", "GRPCS", "FCGI"},
+		Documentation: "Protocol used to connect to the backend",
+	},
+}
+
 // HTTP protocol
 const HTTP = "HTTP"
 
@@ -63,3 +74,8 @@ func (a backendProtocol) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return proto, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a backendProtocol) GetDocumentation() parser.AnnotationFields {
+	return backendprotocolAnnotations
+}
@@ -24,6 +24,47 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var canaryAnnotations = parser.AnnotationFields{
+	"canary": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Default:       "false",
+		Documentation: "Enables the canary mode of the Ingress",
+	},
+	"canary-weight": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "0",
+		Documentation: "Percentage of requests routed to the canary backend",
+	},
+	"canary-by-header": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Header used to route requests to the canary backend",
+	},
+	"canary-by-header-value": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the canary-by-header header that routes requests to the canary backend",
+	},
+	"canary-by-header-pattern": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Regular expression matching the canary-by-header header that routes requests to the canary backend",
+	},
+	"canary-by-cookie": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Cookie used to route requests to the canary backend",
+	},
+}
+
 type canary struct {
 	r resolver.Resolver
 }
@@ -86,3 +127,8 @@ func (c canary) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (c canary) GetDocumentation() parser.AnnotationFields {
+	return canaryAnnotations
+}
@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var clientbodybuffersizeAnnotations = parser.AnnotationFields{
+	"client-body-buffer-size": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Size of the buffer used to read the client request body",
+	},
+}
+
 type clientBodyBufferSize struct {
 	r resolver.Resolver
 }
@@ -37,3 +46,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (cbbs clientBodyBufferSize) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("client-body-buffer-size", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (cbbs clientBodyBufferSize) GetDocumentation() parser.AnnotationFields {
+	return clientbodybuffersizeAnnotations
+}
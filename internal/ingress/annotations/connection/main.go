@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var connectionAnnotations = parser.AnnotationFields{
+	"connection-proxy-header": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the Connection header sent to the upstream",
+	},
+}
+
 // Config returns the connection header configuration for an Ingress rule
 type Config struct {
 	Header  string `json:"header"`
@@ -53,6 +62,11 @@ func (a connection) Parse(ing *networking.Ingress) (interface{}, error) {
 	}, nil
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (a connection) GetDocumentation() parser.AnnotationFields {
+	return connectionAnnotations
+}
+
 // Equal tests for equality between two Connection types
 func (r1 *Config) Equal(r2 *Config) bool {
 	if r1 == r2 {
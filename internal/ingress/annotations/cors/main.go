@@ -17,14 +17,78 @@ limitations under the License.
 package cors
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var corsAnnotations = parser.AnnotationFields{
+	"enable-cors": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Enables Cross-Origin Resource Sharing",
+	},
+	"cors-allow-origin": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Default:       "*",
+		Documentation: "Comma separated list of origins, wildcard origins or regular expressions allowed",
+	},
+	"cors-path-allow-origin": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "CORS policies per path, one path followed by its allowed origins per line",
+	},
+	"cors-allow-headers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "DNT,X-CustomHeader,Keep-Alive,User-Agent,X-Requested-With,If-Modified-Since,Cache-Control,Content-Type,Authorization",
+		Validator:     parser.ValidateRegex(corsHeadersRegex),
+		Documentation: "Value of the Access-Control-Allow-Headers header",
+	},
+	"cors-allow-methods": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "GET, PUT, POST, DELETE, PATCH, OPTIONS",
+		Validator:     parser.ValidateRegex(corsMethodsRegex),
+		Documentation: "Value of the Access-Control-Allow-Methods header",
+	},
+	"cors-allow-credentials": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "true",
+		Documentation: "Value of the Access-Control-Allow-Credentials header",
+	},
+	"cors-expose-headers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     parser.ValidateRegex(corsExposeHeadersRegex),
+		Documentation: "Value of the Access-Control-Expose-Headers header",
+	},
+	"cors-max-age": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "1728000",
+		Documentation: "Value of the Access-Control-Max-Age header",
+	},
+}
+
 const (
 	// Default values
 	defaultCorsMethods = "GET, PUT, POST, DELETE, PATCH, OPTIONS"
@@ -37,6 +101,8 @@ var (
 	// that could cause the Response to contain some internal value/variable (like returning $pid, $upstream_addr, etc)
 	// Origin must contain a http/s Origin (including or not the port) or the value '*'
 	corsOriginRegex = regexp.MustCompile(`^(https?://[A-Za-z0-9\-\.]*(:[0-9]+)?|\*)?$`)
+	// Wildcard origins replace the first label of the host with '*' (https://*.example.com)
+	corsWildcardOriginRegex = regexp.MustCompile(`^https?://\*\.[A-Za-z0-9\-\.]+(:[0-9]+)?$`)
 	// Method must contain valid methods list (PUT, GET, POST, BLA)
 	// May contain or not spaces between each verb
 	corsMethodsRegex = regexp.MustCompile(`^([A-Za-z]+,?\s?)+$`)
@@ -54,13 +120,49 @@ type cors struct {
 
 // Config contains the Cors configuration to be used in the Ingress
 type Config struct {
-	CorsEnabled          bool   `json:"corsEnabled"`
-	CorsAllowOrigin      string `json:"corsAllowOrigin"`
-	CorsAllowMethods     string `json:"corsAllowMethods"`
-	CorsAllowHeaders     string `json:"corsAllowHeaders"`
-	CorsAllowCredentials bool   `json:"corsAllowCredentials"`
-	CorsExposeHeaders    string `json:"corsExposeHeaders"`
-	CorsMaxAge           int    `json:"corsMaxAge"`
+	CorsEnabled     bool   `json:"corsEnabled"`
+	CorsAllowOrigin string `json:"corsAllowOrigin"`
+	// CorsAllowOriginList contains the allowed origins when more than one origin,
+	// a wildcard or a regular expression (prefixed with ~) is configured.
+	// In that case the origin of the request is returned if it matches.
+	CorsAllowOriginList  []string `json:"corsAllowOriginList,omitempty"`
+	CorsAllowMethods     string   `json:"corsAllowMethods"`
+	CorsAllowHeaders     string   `json:"corsAllowHeaders"`
+	CorsAllowCredentials bool     `json:"corsAllowCredentials"`
+	CorsExposeHeaders    string   `json:"corsExposeHeaders"`
+	CorsMaxAge           int      `json:"corsMaxAge"`
+	// PathPolicies contains the allowed origins for specific paths of the Ingress.
+	// It is only used while the locations are created (see ForPath)
+	PathPolicies map[string]OriginPolicy `json:"pathPolicies,omitempty"`
+}
+
+// OriginPolicy contains the origins allowed in a path
+type OriginPolicy struct {
+	CorsAllowOrigin     string   `json:"corsAllowOrigin"`
+	CorsAllowOriginList []string `json:"corsAllowOriginList,omitempty"`
+}
+
+// Equal tests for equality between two OriginPolicy types
+func (p1 OriginPolicy) Equal(p2 OriginPolicy) bool {
+	if p1.CorsAllowOrigin != p2.CorsAllowOrigin {
+		return false
+	}
+
+	return sets.StringElementsMatch(p1.CorsAllowOriginList, p2.CorsAllowOriginList)
+}
+
+// ForPath returns the CORS configuration to be used in a location
+// with the origins defined for the path, if any
+func (c Config) ForPath(path string) Config {
+	policy, ok := c.PathPolicies[path]
+	c.PathPolicies = nil
+	if !ok {
+		return c
+	}
+
+	c.CorsAllowOrigin = policy.CorsAllowOrigin
+	c.CorsAllowOriginList = policy.CorsAllowOriginList
+	return c
 }
 
 // NewParser creates a new CORS annotation parser
@@ -94,6 +196,18 @@ func (c1 *Config) Equal(c2 *Config) bool {
 	if c1.CorsAllowOrigin != c2.CorsAllowOrigin {
 		return false
 	}
+	if !sets.StringElementsMatch(c1.CorsAllowOriginList, c2.CorsAllowOriginList) {
+		return false
+	}
+	if len(c1.PathPolicies) != len(c2.PathPolicies) {
+		return false
+	}
+	for path, p1 := range c1.PathPolicies {
+		p2, ok := c2.PathPolicies[path]
+		if !ok || !p1.Equal(p2) {
+			return false
+		}
+	}
 	if c1.CorsEnabled != c2.CorsEnabled {
 		return false
 	}
@@ -112,9 +226,21 @@ func (c cors) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.CorsEnabled = false
 	}
 
-	config.CorsAllowOrigin, err = parser.GetStringAnnotation("cors-allow-origin", ing)
-	if err != nil || !corsOriginRegex.MatchString(config.CorsAllowOrigin) {
-		config.CorsAllowOrigin = "*"
+	origins, err := parser.GetStringAnnotation("cors-allow-origin", ing)
+	if err != nil {
+		origins = "*"
+	}
+	policy := parseOrigins(origins)
+	config.CorsAllowOrigin = policy.CorsAllowOrigin
+	config.CorsAllowOriginList = policy.CorsAllowOriginList
+
+	pathOrigins, err := parser.GetStringAnnotation("cors-path-allow-origin", ing)
+	if err == nil {
+		config.PathPolicies, err = parsePathPolicies(pathOrigins)
+		if err != nil {
+			klog.Warningf("Annotation cors-path-allow-origin contains an invalid value: %v", err)
+			config.PathPolicies = nil
+		}
 	}
 
 	config.CorsAllowHeaders, err = parser.GetStringAnnotation("cors-allow-headers", ing)
@@ -145,3 +271,114 @@ func (c cors) Parse(ing *networking.Ingress) (interface{}, error) {
 	return config, nil
 
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (c cors) GetDocumentation() parser.AnnotationFields {
+	return corsAnnotations
+}
+
+// parseOrigins parses a comma separated list of origins. Each origin can be
+// an exact origin (https://example.com:8080), a wildcard (https://*.example.com)
+// or a regular expression prefixed with ~. Invalid origins are ignored.
+// If no valid origin is found the value * is used.
+func parseOrigins(value string) OriginPolicy {
+	origins := []string{}
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+
+		if err := validateOrigin(origin); err != nil {
+			klog.Warningf("Annotation cors-allow-origin contains an invalid origin: %v", err)
+			continue
+		}
+
+		origins = append(origins, origin)
+	}
+
+	if len(origins) == 0 {
+		return OriginPolicy{CorsAllowOrigin: "*"}
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			if len(origins) > 1 {
+				klog.Warningf("Annotation cors-allow-origin contains * with other origins. Using *")
+			}
+
+			return OriginPolicy{CorsAllowOrigin: "*"}
+		}
+	}
+
+	// a single exact origin does not require to match the origin of the request
+	if len(origins) == 1 && corsOriginRegex.MatchString(origins[0]) {
+		return OriginPolicy{CorsAllowOrigin: origins[0]}
+	}
+
+	return OriginPolicy{CorsAllowOriginList: origins}
+}
+
+func validateOrigin(origin string) error {
+	if strings.HasPrefix(origin, "~") {
+		expr := origin[1:]
+		if expr == "" || strings.ContainsAny(expr, "'\"; \t") {
+			return fmt.Errorf("invalid regular expression %q", expr)
+		}
+
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", expr, err)
+		}
+
+		return nil
+	}
+
+	if corsOriginRegex.MatchString(origin) || corsWildcardOriginRegex.MatchString(origin) {
+		return nil
+	}
+
+	return fmt.Errorf("%q is not a valid origin", origin)
+}
+
+// parsePathPolicies parses the allowed origins per path, one path per line,
+// with the format <path> <origins>
+func parsePathPolicies(value string) (map[string]OriginPolicy, error) {
+	policies := map[string]OriginPolicy{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "/") {
+			return nil, fmt.Errorf("invalid value %q: expected the format <path> <origins>", line)
+		}
+
+		policies[fields[0]] = parseOrigins(fields[1])
+	}
+
+	return policies, nil
+}
+
+// OriginRegex returns a regular expression that matches the allowed origins
+// of CorsAllowOriginList
+func OriginRegex(origins []string) string {
+	exprs := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if strings.HasPrefix(origin, "~") {
+			exprs = append(exprs, origin[1:])
+			continue
+		}
+
+		if corsWildcardOriginRegex.MatchString(origin) {
+			parts := strings.SplitN(origin, "*", 2)
+			exprs = append(exprs, fmt.Sprintf("^%v[A-Za-z0-9\\-]+%v$", regexp.QuoteMeta(parts[0]), regexp.QuoteMeta(parts[1])))
+			continue
+		}
+
+		exprs = append(exprs, fmt.Sprintf("^%v$", regexp.QuoteMeta(origin)))
+	}
+
+	return fmt.Sprintf("(%v)", strings.Join(exprs, "|"))
+}
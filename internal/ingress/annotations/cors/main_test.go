@@ -17,6 +17,8 @@ limitations under the License.
 package cors
 
 import (
+	"reflect"
+	"regexp"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -169,3 +171,101 @@ func TestIngressCorsConfigInvalid(t *testing.T) {
 		t.Errorf("expected %v but returned %v", defaultCorsMaxAge, nginxCors.CorsMaxAge)
 	}
 }
+
+func TestIngressCorsAllowOriginList(t *testing.T) {
+	testCases := []struct {
+		title        string
+		origins      string
+		expected     string
+		expectedList []string
+	}{
+		{"single origin", "https://origin.test.com", "https://origin.test.com", nil},
+		{"multiple origins", "https://a.test.com, https://b.test.com:8443", "", []string{"https://a.test.com", "https://b.test.com:8443"}},
+		{"wildcard origin", "https://*.test.com", "", []string{"https://*.test.com"}},
+		{"regex origin", `~^https://app[0-9]+\.test\.com$`, "", []string{`~^https://app[0-9]+\.test\.com$`}},
+		{"invalid origins are ignored", "https://a.test.com, $nginx, ~^(invalid", "https://a.test.com", nil},
+		{"wildcard with other origins", "https://a.test.com, *", "*", nil},
+		{"only invalid origins", "https://a.test.com/path, ~'$host'", "*", nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			ing := buildIngress()
+			ing.SetAnnotations(map[string]string{
+				parser.GetAnnotationWithPrefix("enable-cors"):       "true",
+				parser.GetAnnotationWithPrefix("cors-allow-origin"): testCase.origins,
+			})
+
+			i, err := NewParser(&resolver.Mock{}).Parse(ing)
+			if err != nil {
+				t.Fatalf("error parsing annotations: %v", err)
+			}
+
+			config := i.(*Config)
+			if config.CorsAllowOrigin != testCase.expected {
+				t.Errorf("expected %v but returned %v", testCase.expected, config.CorsAllowOrigin)
+			}
+
+			if !reflect.DeepEqual(config.CorsAllowOriginList, testCase.expectedList) {
+				t.Errorf("expected %v but returned %v", testCase.expectedList, config.CorsAllowOriginList)
+			}
+		})
+	}
+}
+
+func TestIngressCorsPathAllowOrigin(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-cors"):            "true",
+		parser.GetAnnotationWithPrefix("cors-allow-origin"):      "https://www.test.com",
+		parser.GetAnnotationWithPrefix("cors-path-allow-origin"): "/api https://a.test.com, https://b.test.com\n/public *",
+	})
+
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("error parsing annotations: %v", err)
+	}
+
+	config := i.(*Config)
+
+	api := config.ForPath("/api")
+	if api.CorsAllowOrigin != "" || len(api.CorsAllowOriginList) != 2 {
+		t.Errorf("expected two origins for /api but returned %v", api.CorsAllowOriginList)
+	}
+
+	if api.PathPolicies != nil {
+		t.Errorf("expected no path policies in the configuration of a path")
+	}
+
+	public := config.ForPath("/public")
+	if public.CorsAllowOrigin != "*" {
+		t.Errorf("expected * for /public but returned %v", public.CorsAllowOrigin)
+	}
+
+	other := config.ForPath("/other")
+	if other.CorsAllowOrigin != "https://www.test.com" {
+		t.Errorf("expected https://www.test.com for /other but returned %v", other.CorsAllowOrigin)
+	}
+}
+
+func TestOriginRegex(t *testing.T) {
+	origins := []string{"https://a.test.com:8443", "https://*.test.com", `~^https://app[0-9]+\.test\.com$`}
+
+	expr := OriginRegex(origins)
+	re := regexp.MustCompile("(?i)" + expr)
+
+	for origin, expected := range map[string]bool{
+		"https://a.test.com:8443":    true,
+		"https://test.com":           false,
+		"https://foo.test.com":       true,
+		"https://foo.bar.test.com":   false,
+		"https://app1.test.com":      true,
+		"https://attacker.com":       false,
+		"https://test.com.evil.com":  false,
+		"https://a.test.com:8443.io": false,
+	} {
+		if re.MatchString(origin) != expected {
+			t.Errorf("expected %v for origin %v using %v", expected, origin, expr)
+		}
+	}
+}
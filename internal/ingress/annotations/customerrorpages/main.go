@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customerrorpages
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var customerrorpagesAnnotations = parser.AnnotationFields{
+	"custom-error-pages": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateConfigMapName,
+		Documentation: "Name of the ConfigMap in the namespace of the Ingress with the custom error pages",
+	},
+}
+
+type customerrorpages struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new custom error pages annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return customerrorpages{r}
+}
+
+// validateConfigMapName checks the value is the name of a ConfigMap.
+// ConfigMaps in other namespaces cannot be referenced.
+func validateConfigMapName(value string) error {
+	if errs := validation.IsDNS1123Subdomain(value); len(errs) > 0 {
+		return fmt.Errorf("%v is not a valid ConfigMap name: %v", value, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// Parse parses the annotations contained in the ingress to use custom
+// error pages. It returns the ConfigMap with the pages as namespace/name.
+func (e customerrorpages) Parse(ing *networking.Ingress) (interface{}, error) {
+	name, err := parser.GetStringAnnotation("custom-error-pages", ing)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConfigMapName(name); err != nil {
+		return nil, ing_errors.NewInvalidAnnotationConfiguration("custom-error-pages", err.Error())
+	}
+
+	return fmt.Sprintf("%v/%v", ing.Namespace, name), nil
+}
+
+// GetDocumentation returns the annotations read by the parser
+func (e customerrorpages) GetDocumentation() parser.AnnotationFields {
+	return customerrorpagesAnnotations
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package customhttperrors
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -26,6 +27,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var customhttperrorsAnnotations = parser.AnnotationFields{
+	"custom-http-errors": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateStatusCodes,
+		Documentation: "Comma separated list of status codes sent to the default backend",
+	},
+}
+
 type customhttperrors struct {
 	r resolver.Resolver
 }
@@ -35,6 +46,17 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 	return customhttperrors{r}
 }
 
+// validateStatusCodes checks the value is a comma separated list of status codes
+func validateStatusCodes(value string) error {
+	for _, code := range strings.Split(value, ",") {
+		if _, err := strconv.Atoi(code); err != nil {
+			return fmt.Errorf("%v is not a valid status code", code)
+		}
+	}
+
+	return nil
+}
+
 // Parse parses the annotations contained in the ingress to use
 // custom http errors
 func (e customhttperrors) Parse(ing *networking.Ingress) (interface{}, error) {
@@ -55,3 +77,8 @@ func (e customhttperrors) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return codes, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (e customhttperrors) GetDocumentation() parser.AnnotationFields {
+	return customhttperrorsAnnotations
+}
@@ -26,6 +26,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var defaultbackendAnnotations = parser.AnnotationFields{
+	"default-backend": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the Service used to handle requests without a matching backend or with a custom error",
+	},
+}
+
 type backend struct {
 	r resolver.Resolver
 }
@@ -51,3 +60,8 @@ func (db backend) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return svc, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (db backend) GetDocumentation() parser.AnnotationFields {
+	return defaultbackendAnnotations
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// NamespaceDefaultsConfigMap sets the name of the ConfigMap, located in the
+// namespace of the Ingress, that contains the default annotations of the
+// Ingresses of the namespace. An empty value disables the namespace defaults.
+var NamespaceDefaultsConfigMap = ""
+
+// NamespaceAnnotations enables the default annotations defined in the
+// annotations of the Namespace of the Ingress
+var NamespaceAnnotations = false
+
+// LockedAnnotations contains the comma separated list of annotations, without
+// the prefix, whose default values cannot be overridden by the Ingresses.
+// Unlike the defaults of a namespace, it can only be changed in the ingress
+// controller.
+var LockedAnnotations = ""
+
+// LockedAnnotationsKey is the key of the ConfigMap referenced by the
+// IngressClass containing the comma separated list of annotations that
+// cannot be overridden. It is ignored in the ConfigMaps of the namespaces,
+// because the users of a namespace could remove the locks.
+const LockedAnnotationsKey = "locked-annotations"
+
+// annotationDefaults contains the annotations defined in a defaults ConfigMap
+type annotationDefaults struct {
+	// source contains the key of the ConfigMap
+	source string
+	// values contains the annotations, including the prefix
+	values map[string]string
+	// locked contains the annotations, including the prefix, that cannot be overridden
+	locked sets.String
+}
+
+// classDefaultsConfigMap returns the key of the ConfigMap referenced in the
+// parameters of the IngressClass. The ConfigMap must be located in the
+// namespace of the ingress controller.
+func classDefaultsConfigMap() string {
+	if k8s.IngressClass == nil || k8s.IngressPodDetails == nil {
+		return ""
+	}
+
+	params := k8s.IngressClass.Spec.Parameters
+	if params == nil || params.Kind != "ConfigMap" {
+		return ""
+	}
+
+	if params.APIGroup != nil && *params.APIGroup != "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%v/%v", k8s.IngressPodDetails.Namespace, params.Name)
+}
+
+// namespaceDefaultsConfigMap returns the key of the defaults ConfigMap of a namespace
+func namespaceDefaultsConfigMap(namespace string) string {
+	if NamespaceDefaultsConfigMap == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%v/%v", namespace, NamespaceDefaultsConfigMap)
+}
+
+// IsDefaultsConfigMap returns true if the ConfigMap contains default
+// annotations for the Ingress
+func IsDefaultsConfigMap(key string, ing *networking.Ingress) bool {
+	if key == "" {
+		return false
+	}
+
+	return key == classDefaultsConfigMap() || key == namespaceDefaultsConfigMap(ing.Namespace)
+}
+
+// DefaultAnnotations returns the annotations of a Namespace used as
+// default annotations of the Ingresses, including the prefix
+func DefaultAnnotations(namespace *apiv1.Namespace) map[string]string {
+	anns := make(map[string]string)
+	if namespace == nil {
+		return anns
+	}
+
+	for name, value := range namespace.GetAnnotations() {
+		if !strings.HasPrefix(name, parser.AnnotationsPrefix+"/") {
+			continue
+		}
+
+		if _, ok := schema[strings.TrimPrefix(name, parser.AnnotationsPrefix+"/")]; !ok {
+			klog.Warningf("Ignoring unknown annotation %v in Namespace %v", name, namespace.Name)
+			continue
+		}
+
+		anns[name] = value
+	}
+
+	return anns
+}
+
+// readNamespaceDefaults reads the default annotations defined in a Namespace
+func readNamespaceDefaults(cfg resolver.Resolver, name string) *annotationDefaults {
+	if !NamespaceAnnotations || cfg == nil {
+		return nil
+	}
+
+	namespace, err := cfg.GetNamespace(name)
+	if err != nil || namespace == nil {
+		return nil
+	}
+
+	values := DefaultAnnotations(namespace)
+	if len(values) == 0 {
+		return nil
+	}
+
+	return &annotationDefaults{
+		source: fmt.Sprintf("Namespace %v", name),
+		values: values,
+		locked: sets.NewString(),
+	}
+}
+
+// readDefaults reads the annotations defined in a defaults ConfigMap.
+// Only the annotations declared in the schema are accepted, and the
+// locked annotations are only read if readLocks is true.
+func readDefaults(cfg resolver.Resolver, key string, readLocks bool) *annotationDefaults {
+	if cfg == nil || key == "" {
+		return nil
+	}
+
+	cm, err := cfg.GetConfigMap(key)
+	if err != nil || cm == nil {
+		return nil
+	}
+
+	d := &annotationDefaults{
+		source: fmt.Sprintf("ConfigMap %v", key),
+		values: make(map[string]string),
+		locked: sets.NewString(),
+	}
+
+	for name, value := range cm.Data {
+		if name == LockedAnnotationsKey {
+			continue
+		}
+
+		if _, ok := schema[name]; !ok {
+			klog.Warningf("Ignoring unknown annotation %v in ConfigMap %v", name, key)
+			continue
+		}
+
+		d.values[parser.GetAnnotationWithPrefix(name)] = value
+	}
+
+	if !readLocks {
+		if _, ok := cm.Data[LockedAnnotationsKey]; ok {
+			klog.Warningf("Ignoring %v in ConfigMap %v. Locked annotations are only read from the ConfigMap of the IngressClass and the flag --locked-annotations", LockedAnnotationsKey, key)
+		}
+
+		return d
+	}
+
+	d.locked = lockedAnnotations(cm.Data[LockedAnnotationsKey])
+
+	return d
+}
+
+// lockedAnnotations returns the annotations, including the prefix,
+// contained in a comma separated list of annotations
+func lockedAnnotations(value string) sets.String {
+	locked := sets.NewString()
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		locked.Insert(parser.GetAnnotationWithPrefix(name))
+	}
+
+	return locked
+}
+
+// mergeAnnotations returns the annotations of the Ingress merged over the
+// default annotations, from lowest to highest precedence. Annotations locked
+// in a layer are not overridden by the layers with higher precedence nor the
+// Ingress, while the annotations contained in locked, including the prefix,
+// keep the value of the defaults. An error is returned for every annotation
+// of the Ingress that is ignored.
+func mergeAnnotations(ing *networking.Ingress, locked sets.String, layers ...*annotationDefaults) (map[string]string, []error) {
+	merged := make(map[string]string)
+	lockedBy := make(map[string]string)
+
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+
+		for name, value := range layer.values {
+			if _, locked := lockedBy[name]; locked {
+				continue
+			}
+
+			merged[name] = value
+		}
+
+		for name := range layer.locked {
+			if _, locked := lockedBy[name]; !locked {
+				lockedBy[name] = layer.source
+			}
+		}
+	}
+
+	for name := range locked {
+		if _, ok := merged[name]; !ok {
+			continue
+		}
+
+		if _, ok := lockedBy[name]; !ok {
+			lockedBy[name] = "the ingress controller"
+		}
+	}
+
+	var errs []error
+	for name, value := range ing.GetAnnotations() {
+		if source, locked := lockedBy[name]; locked {
+			if merged[name] != value {
+				errs = append(errs, fmt.Errorf("annotation %v is locked by %v and cannot be overridden", name, source))
+			}
+
+			continue
+		}
+
+		merged[name] = value
+	}
+
+	return merged, errs
+}
+
+// withDefaults returns a copy of the Ingress containing the default
+// annotations of the IngressClass and the namespace. The precedence,
+// from lowest to highest, is IngressClass, namespace ConfigMap, Namespace
+// annotations and Ingress. If there are no defaults the Ingress is returned.
+func withDefaults(cfg resolver.Resolver, ing *networking.Ingress) (*networking.Ingress, []error) {
+	classDefaults := readDefaults(cfg, classDefaultsConfigMap(), true)
+	namespaceDefaults := readDefaults(cfg, namespaceDefaultsConfigMap(ing.Namespace), false)
+	namespaceAnnotations := readNamespaceDefaults(cfg, ing.Namespace)
+	if classDefaults == nil && namespaceDefaults == nil && namespaceAnnotations == nil {
+		return ing, nil
+	}
+
+	merged, errs := mergeAnnotations(ing, lockedAnnotations(LockedAnnotations), classDefaults, namespaceDefaults, namespaceAnnotations)
+
+	copyIng := &networking.Ingress{
+		ObjectMeta: *ing.ObjectMeta.DeepCopy(),
+		Spec:       ing.Spec,
+		Status:     ing.Status,
+	}
+	copyIng.SetAnnotations(merged)
+
+	return copyIng, errs
+}
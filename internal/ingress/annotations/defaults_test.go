@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestMergeAnnotations(t *testing.T) {
+	bodySize := parser.GetAnnotationWithPrefix("proxy-body-size")
+	readTimeout := parser.GetAnnotationWithPrefix("proxy-read-timeout")
+	whitelist := parser.GetAnnotationWithPrefix("whitelist-source-range")
+
+	classDefaults := &annotationDefaults{
+		source: "ConfigMap ingress-nginx/class-defaults",
+		values: map[string]string{
+			bodySize:    "1m",
+			readTimeout: "30",
+			whitelist:   "10.0.0.0/8",
+		},
+		locked: sets.NewString(whitelist),
+	}
+
+	namespaceDefaults := &annotationDefaults{
+		source: "ConfigMap default/defaults",
+		values: map[string]string{
+			bodySize:  "8m",
+			whitelist: "0.0.0.0/0",
+		},
+		locked: sets.NewString(),
+	}
+
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		bodySize:    "16m",
+		readTimeout: "300",
+		whitelist:   "10.0.0.0/8",
+	})
+
+	merged, errs := mergeAnnotations(ing, sets.NewString(readTimeout), classDefaults, namespaceDefaults)
+
+	expected := map[string]string{
+		bodySize:    "16m",
+		readTimeout: "30",
+		whitelist:   "10.0.0.0/8",
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v but returned %v", expected, merged)
+	}
+
+	// the Ingress uses the locked value of the whitelist, only the timeout is reported
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error but returned %v", errs)
+	}
+
+	if !strings.Contains(errs[0].Error(), readTimeout) || !strings.Contains(errs[0].Error(), "the ingress controller") {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+}
+
+func TestExtractNamespaceDefaults(t *testing.T) {
+	NamespaceDefaultsConfigMap = "ingress-defaults"
+	defer func() {
+		NamespaceDefaultsConfigMap = ""
+	}()
+
+	cfg := mockCfg{
+		Mock: resolver.Mock{
+			ConfigMaps: map[string]*apiv1.ConfigMap{
+				"default/ingress-defaults": {
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress-defaults"},
+					Data: map[string]string{
+						"proxy-body-size":        "8m",
+						"whitelist-source-range": "10.0.0.0/8",
+						"unknown-annotation":     "value",
+						LockedAnnotationsKey:     "whitelist-source-range",
+					},
+				},
+			},
+		},
+	}
+
+	ing := buildIngress()
+	if !IsDefaultsConfigMap("default/ingress-defaults", ing) {
+		t.Errorf("expected the ConfigMap to contain the defaults of the Ingress")
+	}
+
+	if IsDefaultsConfigMap("other/ingress-defaults", ing) {
+		t.Errorf("expected the ConfigMap of other namespace to be ignored")
+	}
+
+	pia, err := NewAnnotationExtractor(cfg).Extract(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pia.Proxy.BodySize != "8m" {
+		t.Errorf("expected body size 8m but returned %v", pia.Proxy.BodySize)
+	}
+
+	if !reflect.DeepEqual(pia.Whitelist.CIDR, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected whitelist 10.0.0.0/8 but returned %v", pia.Whitelist.CIDR)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("proxy-body-size"):        "16m",
+		parser.GetAnnotationWithPrefix("whitelist-source-range"): "0.0.0.0/0",
+	})
+
+	// the locks of the ConfigMap of the namespace are ignored
+	pia, err = NewAnnotationExtractor(cfg).Extract(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(pia.Whitelist.CIDR, []string{"0.0.0.0/0"}) {
+		t.Errorf("expected whitelist 0.0.0.0/0 but returned %v", pia.Whitelist.CIDR)
+	}
+
+	LockedAnnotations = "whitelist-source-range"
+	defer func() {
+		LockedAnnotations = ""
+	}()
+
+	pia, err = NewAnnotationExtractor(cfg).Extract(ing)
+	if err == nil {
+		t.Errorf("expected an error overriding a locked annotation")
+	}
+
+	if pia.Proxy.BodySize != "16m" {
+		t.Errorf("expected body size 16m but returned %v", pia.Proxy.BodySize)
+	}
+
+	if !reflect.DeepEqual(pia.Whitelist.CIDR, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected the locked whitelist 10.0.0.0/8 but returned %v", pia.Whitelist.CIDR)
+	}
+}
+
+func TestExtractNamespaceAnnotations(t *testing.T) {
+	NamespaceDefaultsConfigMap = "ingress-defaults"
+	NamespaceAnnotations = true
+	defer func() {
+		NamespaceDefaultsConfigMap = ""
+		NamespaceAnnotations = false
+	}()
+
+	cfg := mockCfg{
+		Mock: resolver.Mock{
+			ConfigMaps: map[string]*apiv1.ConfigMap{
+				"default/ingress-defaults": {
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress-defaults"},
+					Data: map[string]string{
+						"proxy-body-size":    "8m",
+						"proxy-read-timeout": "120",
+					},
+				},
+			},
+			Namespaces: map[string]*apiv1.Namespace{
+				"default": {
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+						Annotations: map[string]string{
+							parser.GetAnnotationWithPrefix("proxy-body-size"):    "16m",
+							parser.GetAnnotationWithPrefix("unknown-annotation"): "value",
+							"example.com/proxy-read-timeout":                     "300",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := map[string]string{
+		parser.GetAnnotationWithPrefix("proxy-body-size"): "16m",
+	}
+	if anns := DefaultAnnotations(cfg.Namespaces["default"]); !reflect.DeepEqual(anns, expected) {
+		t.Errorf("expected %v but returned %v", expected, anns)
+	}
+
+	ing := buildIngress()
+	pia, err := NewAnnotationExtractor(cfg).Extract(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pia.Proxy.BodySize != "16m" {
+		t.Errorf("expected body size 16m but returned %v", pia.Proxy.BodySize)
+	}
+
+	if pia.Proxy.ReadTimeout != 120 {
+		t.Errorf("expected read timeout 120 but returned %v", pia.Proxy.ReadTimeout)
+	}
+}
@@ -29,6 +29,21 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var fastcgiAnnotations = parser.AnnotationFields{
+	"fastcgi-index": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the fastcgi_index directive",
+	},
+	"fastcgi-params-configmap": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the ConfigMap with the FastCGI parameters",
+	},
+}
+
 type fastcgi struct {
 	r resolver.Resolver
 }
@@ -105,3 +120,8 @@ func (a fastcgi) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return fcgiConfig, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a fastcgi) GetDocumentation() parser.AnnotationFields {
+	return fastcgiAnnotations
+}
@@ -30,6 +30,35 @@ import (
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var globalratelimitAnnotations = parser.AnnotationFields{
+	"global-rate-limit": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of requests allowed per window in all the replicas",
+	},
+	"global-rate-limit-window": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     parser.ValidateDuration,
+		Documentation: "Duration of the global rate limit window",
+	},
+	"global-rate-limit-key": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "$remote_addr",
+		Documentation: "Key used to count requests of the global rate limit",
+	},
+	"global-rate-limit-ignored-cidrs": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Comma separated list of CIDRs excluded from the global rate limit",
+	},
+}
+
 const defaultKey = "$remote_addr"
 
 // Config encapsulates all global rate limit attributes
@@ -109,3 +138,8 @@ func (a globalratelimit) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a globalratelimit) GetDocumentation() parser.AnnotationFields {
+	return globalratelimitAnnotations
+}
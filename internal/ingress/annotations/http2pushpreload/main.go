@@ -23,6 +23,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var http2pushpreloadAnnotations = parser.AnnotationFields{
+	"http2-push-preload": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Enables automatic HTTP/2 push of the links in Link headers",
+	},
+}
+
 type http2PushPreload struct {
 	r resolver.Resolver
 }
@@ -37,3 +47,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (h2pp http2PushPreload) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetBoolAnnotation("http2-push-preload", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (h2pp http2PushPreload) GetDocumentation() parser.AnnotationFields {
+	return http2pushpreloadAnnotations
+}
@@ -23,6 +23,40 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var influxdbAnnotations = parser.AnnotationFields{
+	"enable-influxdb": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Enables the InfluxDB metrics module",
+	},
+	"influxdb-measurement": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Measurement name used in InfluxDB",
+	},
+	"influxdb-port": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Port of the InfluxDB server",
+	},
+	"influxdb-host": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Address of the InfluxDB server",
+	},
+	"influxdb-server-name": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Name of the server reported to InfluxDB",
+	},
+}
+
 type influxdb struct {
 	r resolver.Resolver
 }
@@ -76,6 +110,11 @@ func (c influxdb) Parse(ing *networking.Ingress) (interface{}, error) {
 	return config, nil
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (c influxdb) GetDocumentation() parser.AnnotationFields {
+	return influxdbAnnotations
+}
+
 // Equal tests for equality between two Config types
 func (e1 *Config) Equal(e2 *Config) bool {
 	if e1 == e2 {
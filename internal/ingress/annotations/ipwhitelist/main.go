@@ -31,6 +31,15 @@ import (
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var ipwhitelistAnnotations = parser.AnnotationFields{
+	"whitelist-source-range": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Comma separated list of CIDRs allowed to access the locations",
+	},
+}
+
 // SourceRange returns the CIDR
 type SourceRange struct {
 	CIDR []string `json:"cidr,omitempty"`
@@ -91,3 +100,8 @@ func (a ipwhitelist) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return &SourceRange{cidrs}, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a ipwhitelist) GetDocumentation() parser.AnnotationFields {
+	return ipwhitelistAnnotations
+}
@@ -23,6 +23,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var loadbalancingAnnotations = parser.AnnotationFields{
+	"load-balance": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"round_robin", "ewma"},
+		Documentation: "Load balancing algorithm used in the upstream",
+	},
+}
+
 type loadbalancing struct {
 	r resolver.Resolver
 }
@@ -38,3 +48,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (a loadbalancing) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("load-balance", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a loadbalancing) GetDocumentation() parser.AnnotationFields {
+	return loadbalancingAnnotations
+}
@@ -23,6 +23,23 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var logAnnotations = parser.AnnotationFields{
+	"enable-access-log": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "true",
+		Documentation: "Enables the access log",
+	},
+	"enable-rewrite-log": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Enables the rewrite log",
+	},
+}
+
 type log struct {
 	r resolver.Resolver
 }
@@ -69,3 +86,8 @@ func (l log) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (l log) GetDocumentation() parser.AnnotationFields {
+	return logAnnotations
+}
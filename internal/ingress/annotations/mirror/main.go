@@ -18,18 +18,113 @@ package mirror
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 
 	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var mirrorAnnotations = parser.AnnotationFields{
+	"mirror-target": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "URL or Service used as mirror target",
+	},
+	"mirror-targets": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Comma separated list of additional mirror targets",
+	},
+	"mirror-request-body": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "on",
+		AllowedValues: []string{"on", "off"},
+		Documentation: "Sends the request body to the mirror targets",
+	},
+	"mirror-sample-percentage": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "100",
+		Documentation: "Percentage of requests sent to the mirror targets",
+	},
+	"mirror-headers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Headers added to the mirrored requests, one name: value pair per line",
+	},
+}
+
+const defaultSamplePercentage = 100
+
+var (
+	// headerNameRegex contains the characters allowed in the name of a mirror header
+	headerNameRegex = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	// headerValueRegex avoids the injection of NGINX variables or directives in a mirror header
+	headerValueRegex = regexp.MustCompile(`^[^"'$;{}\\]*$`)
+	// serviceRegex matches a mirror target referencing a Service (<name>:<port>)
+	serviceRegex = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?):([a-zA-Z0-9\-]+)$`)
+)
+
 // Config returns the mirror to use in a given location
 type Config struct {
 	Source      string `json:"source"`
 	RequestBody string `json:"requestBody"`
 	Target      string `json:"target"`
+	// Targets contains all the destinations of the mirrored requests.
+	// The first one corresponds to Source and Target
+	Targets []Target `json:"targets,omitempty"`
+	// SamplePercentage is the percentage (0-100) of requests to mirror
+	SamplePercentage int `json:"samplePercentage"`
+	// Headers contains additional headers sent in the mirrored requests
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Target describes a destination of the mirrored requests
+type Target struct {
+	// Source is the internal location used to mirror the request
+	Source string `json:"source"`
+	// URL of the destination, if the target is not a Service
+	URL string `json:"url,omitempty"`
+	// Service is the name of the Service in the namespace of the Ingress
+	Service string `json:"service,omitempty"`
+	// ServicePort is the port of the Service
+	ServicePort intstr.IntOrString `json:"servicePort,omitempty"`
+	// UpstreamName is the name of the backend of the Service.
+	// It is set by the controller
+	UpstreamName string `json:"upstreamName,omitempty"`
+}
+
+// Equal tests for equality between two Target types
+func (t1 Target) Equal(t2 Target) bool {
+	if t1.Source != t2.Source {
+		return false
+	}
+	if t1.URL != t2.URL {
+		return false
+	}
+	if t1.Service != t2.Service {
+		return false
+	}
+	if t1.ServicePort != t2.ServicePort {
+		return false
+	}
+	if t1.UpstreamName != t2.UpstreamName {
+		return false
+	}
+
+	return true
 }
 
 // Equal tests for equality between two Configuration types
@@ -54,6 +149,30 @@ func (m1 *Config) Equal(m2 *Config) bool {
 		return false
 	}
 
+	if m1.SamplePercentage != m2.SamplePercentage {
+		return false
+	}
+
+	if len(m1.Targets) != len(m2.Targets) {
+		return false
+	}
+
+	for i := range m1.Targets {
+		if !m1.Targets[i].Equal(m2.Targets[i]) {
+			return false
+		}
+	}
+
+	if len(m1.Headers) != len(m2.Headers) {
+		return false
+	}
+
+	for name, value := range m1.Headers {
+		if v, ok := m2.Headers[name]; !ok || v != value {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -79,11 +198,120 @@ func (a mirror) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.RequestBody = "on"
 	}
 
-	config.Target, err = parser.GetStringAnnotation("mirror-target", ing)
-	if err != nil {
-		config.Target = ""
+	targets := []string{}
+
+	target, err := parser.GetStringAnnotation("mirror-target", ing)
+	if err == nil {
+		targets = append(targets, target)
+	}
+
+	extraTargets, err := parser.GetStringAnnotation("mirror-targets", ing)
+	if err == nil {
+		for _, t := range strings.Split(extraTargets, ",") {
+			t = strings.TrimSpace(t)
+			if t != "" {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	for _, t := range targets {
+		mt, err := parseTarget(t)
+		if err != nil {
+			klog.Warningf("Annotation mirror-targets contains an invalid value: %v", err)
+			continue
+		}
+
+		mt.Source = config.Source
+		if len(config.Targets) > 0 {
+			mt.Source = fmt.Sprintf("%v-%v", config.Source, len(config.Targets))
+		}
+
+		config.Targets = append(config.Targets, mt)
+	}
+
+	if len(config.Targets) == 0 {
 		config.Source = ""
+		config.Target = ""
+		return config, nil
+	}
+
+	config.Target = config.Targets[0].URL
+
+	config.SamplePercentage, err = parser.GetIntAnnotation("mirror-sample-percentage", ing)
+	if err != nil {
+		config.SamplePercentage = defaultSamplePercentage
+	}
+
+	if config.SamplePercentage < 0 || config.SamplePercentage > 100 {
+		klog.Warningf("Annotation mirror-sample-percentage must be a value between 0 and 100 (%v). Using %v", config.SamplePercentage, defaultSamplePercentage)
+		config.SamplePercentage = defaultSamplePercentage
+	}
+
+	headers, err := parser.GetStringAnnotation("mirror-headers", ing)
+	if err == nil {
+		config.Headers, err = parseHeaders(headers)
+		if err != nil {
+			klog.Warningf("Annotation mirror-headers contains an invalid value: %v", err)
+			config.Headers = nil
+		}
 	}
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a mirror) GetDocumentation() parser.AnnotationFields {
+	return mirrorAnnotations
+}
+
+// parseTarget parses a mirror target. Valid values are URLs or
+// references to a Service in the namespace of the Ingress (<name>:<port>)
+func parseTarget(target string) (Target, error) {
+	if m := serviceRegex.FindStringSubmatch(target); m != nil {
+		return Target{
+			Service:     m[1],
+			ServicePort: intstr.Parse(m[3]),
+		}, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return Target{}, fmt.Errorf("%v is not a valid URL: %v", target, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Target{}, fmt.Errorf("%v is not a valid URL or Service reference", target)
+	}
+
+	return Target{URL: target}, nil
+}
+
+// parseHeaders parses a list of headers, one per line, with the format <name>: <value>
+func parseHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		nv := strings.SplitN(line, ":", 2)
+		if len(nv) != 2 {
+			return nil, fmt.Errorf("invalid header %q: expected the format <name>: <value>", line)
+		}
+
+		name, val := strings.TrimSpace(nv[0]), strings.TrimSpace(nv[1])
+		if !headerNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+
+		if !headerValueRegex.MatchString(val) {
+			return nil, fmt.Errorf("invalid value for header %q", name)
+		}
+
+		headers[name] = val
+	}
+
+	return headers, nil
+}
@@ -23,6 +23,7 @@ import (
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
@@ -30,6 +31,9 @@ import (
 func TestParse(t *testing.T) {
 	requestBody := parser.GetAnnotationWithPrefix("mirror-request-body")
 	backendURL := parser.GetAnnotationWithPrefix("mirror-target")
+	targets := parser.GetAnnotationWithPrefix("mirror-targets")
+	samplePercent := parser.GetAnnotationWithPrefix("mirror-sample-percentage")
+	headers := parser.GetAnnotationWithPrefix("mirror-headers")
 
 	ap := NewParser(&resolver.Mock{})
 	if ap == nil {
@@ -45,6 +49,40 @@ func TestParse(t *testing.T) {
 			Source:      ngxURI,
 			RequestBody: "on",
 			Target:      "https://test.env.com/$request_uri",
+			Targets: []Target{
+				{Source: ngxURI, URL: "https://test.env.com/$request_uri"},
+			},
+			SamplePercentage: 100,
+		}},
+		{map[string]string{
+			backendURL:    "https://test.env.com/$request_uri",
+			targets:       "shadow:8080, https://other.env.com/$request_uri, invalid:target:value",
+			samplePercent: "10",
+			headers:       "X-Shadow: true\nX-Env: test",
+		}, &Config{
+			Source:      ngxURI,
+			RequestBody: "on",
+			Target:      "https://test.env.com/$request_uri",
+			Targets: []Target{
+				{Source: ngxURI, URL: "https://test.env.com/$request_uri"},
+				{Source: ngxURI + "-1", Service: "shadow", ServicePort: intstr.FromInt(8080)},
+				{Source: ngxURI + "-2", URL: "https://other.env.com/$request_uri"},
+			},
+			SamplePercentage: 10,
+			Headers:          map[string]string{"X-Shadow": "true", "X-Env": "test"},
+		}},
+		{map[string]string{
+			targets:       "shadow:http",
+			samplePercent: "200",
+			headers:       "X-Shadow: $host",
+		}, &Config{
+			Source:      ngxURI,
+			RequestBody: "on",
+			Target:      "",
+			Targets: []Target{
+				{Source: ngxURI, Service: "shadow", ServicePort: intstr.FromString("http")},
+			},
+			SamplePercentage: 100,
 		}},
 		{map[string]string{requestBody: "off"}, &Config{
 			Source:      "",
@@ -17,11 +17,120 @@ limitations under the License.
 package modsecurity
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+
 	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+const (
+	// ModeOn blocks the requests matching the rules
+	ModeOn = "On"
+	// ModeDetectionOnly only logs the requests matching the rules
+	ModeDetectionOnly = "DetectionOnly"
+)
+
+var modeRegex = regexp.MustCompile(`^(On|DetectionOnly)$`)
+
+var modsecurityAnnotations = parser.AnnotationFields{
+	"enable-modsecurity": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Default:       "false",
+		Documentation: "Enables the ModSecurity web application firewall",
+	},
+	"enable-owasp-core-rules": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Enables the OWASP Core Rule Set",
+	},
+	"modsecurity-transaction-id": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Variable used as ModSecurity transaction ID",
+	},
+	"modsecurity-snippet": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskCritical,
+		Documentation: "Custom ModSecurity rules",
+	},
+	"modsecurity-mode": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Validator:     parser.ValidateRegex(modeRegex),
+		Documentation: "Blocks the requests matching the rules with On, or only logs them with DetectionOnly",
+	},
+	"modsecurity-paranoia-level": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateRange(1, 4),
+		Documentation: "Paranoia level of the OWASP Core Rule Set, from 1 to 4",
+	},
+	"modsecurity-inbound-anomaly-threshold": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateRange(1, maxAnomalyThreshold),
+		Documentation: "Anomaly score of a request blocked by the OWASP Core Rule Set",
+	},
+	"modsecurity-outbound-anomaly-threshold": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateRange(1, maxAnomalyThreshold),
+		Documentation: "Anomaly score of a response blocked by the OWASP Core Rule Set",
+	},
+	"modsecurity-rules-configmap": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Validator:     validateConfigMapName,
+		Documentation: "Name of the ConfigMap in the namespace of the Ingress with rule exclusions and overrides",
+	},
+}
+
+// maxAnomalyThreshold limits the anomaly thresholds to the values used in practice
+const maxAnomalyThreshold = 10000
+
+// validateRange returns a validator of integers between min and max
+func validateRange(min, max int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%v is not an integer", value)
+		}
+
+		if i < min || i > max {
+			return fmt.Errorf("%v is not between %v and %v", value, min, max)
+		}
+
+		return nil
+	}
+}
+
+// validateConfigMapName checks the value is the name of a ConfigMap in the namespace of the Ingress
+func validateConfigMapName(value string) error {
+	if errs := validation.IsDNS1123Subdomain(value); len(errs) > 0 {
+		return fmt.Errorf("%v is not a valid ConfigMap name", value)
+	}
+
+	return nil
+}
+
 // Config contains ModSecurity Configuration items
 type Config struct {
 	Enable        bool   `json:"enable-modsecurity"`
@@ -29,6 +138,20 @@ type Config struct {
 	OWASPRules    bool   `json:"enable-owasp-core-rules"`
 	TransactionID string `json:"modsecurity-transaction-id"`
 	Snippet       string `json:"modsecurity-snippet"`
+
+	// Mode sets the rule engine, On or DetectionOnly. By default the
+	// mode of the ModSecurity configuration is used.
+	Mode string `json:"modsecurity-mode,omitempty"`
+	// ParanoiaLevel sets the paranoia level of the OWASP Core Rule Set
+	ParanoiaLevel int `json:"modsecurity-paranoia-level,omitempty"`
+	// InboundAnomalyThreshold and OutboundAnomalyThreshold set the anomaly
+	// scores of the requests and responses blocked by the OWASP Core Rule Set
+	InboundAnomalyThreshold  int `json:"modsecurity-inbound-anomaly-threshold,omitempty"`
+	OutboundAnomalyThreshold int `json:"modsecurity-outbound-anomaly-threshold,omitempty"`
+	// RulesConfigMap is the ConfigMap, as namespace/name, with the rule overrides
+	RulesConfigMap string `json:"modsecurity-rules-configmap,omitempty"`
+	// Overrides contains the rule exclusions and overrides of the ConfigMap
+	Overrides RuleOverrides `json:"overrides"`
 }
 
 // Equal tests for equality between two Config types
@@ -54,8 +177,23 @@ func (modsec1 *Config) Equal(modsec2 *Config) bool {
 	if modsec1.Snippet != modsec2.Snippet {
 		return false
 	}
+	if modsec1.Mode != modsec2.Mode {
+		return false
+	}
+	if modsec1.ParanoiaLevel != modsec2.ParanoiaLevel {
+		return false
+	}
+	if modsec1.InboundAnomalyThreshold != modsec2.InboundAnomalyThreshold {
+		return false
+	}
+	if modsec1.OutboundAnomalyThreshold != modsec2.OutboundAnomalyThreshold {
+		return false
+	}
+	if modsec1.RulesConfigMap != modsec2.RulesConfigMap {
+		return false
+	}
 
-	return true
+	return modsec1.Overrides.Equal(&modsec2.Overrides)
 }
 
 // NewParser creates a new ModSecurity annotation parser
@@ -95,5 +233,70 @@ func (a modSecurity) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.Snippet = ""
 	}
 
+	// the structured settings are validated, invalid values are ignored
+	// without discarding the rest of the configuration
+	if mode, err := parser.GetStringAnnotation("modsecurity-mode", ing); err == nil {
+		if modeRegex.MatchString(mode) {
+			config.Mode = mode
+		} else {
+			klog.Warningf("Annotation modsecurity-mode contains an invalid value: %v", mode)
+		}
+	}
+
+	intAnnotations := []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"modsecurity-paranoia-level", 4, &config.ParanoiaLevel},
+		{"modsecurity-inbound-anomaly-threshold", maxAnomalyThreshold, &config.InboundAnomalyThreshold},
+		{"modsecurity-outbound-anomaly-threshold", maxAnomalyThreshold, &config.OutboundAnomalyThreshold},
+	}
+	for _, annotation := range intAnnotations {
+		value, err := parser.GetIntAnnotation(annotation.name, ing)
+		if err != nil {
+			if !ing_errors.IsMissingAnnotations(err) {
+				klog.Warningf("Annotation %v contains an invalid value: %v", annotation.name, err)
+			}
+			continue
+		}
+
+		if value < 1 || value > annotation.max {
+			klog.Warningf("Annotation %v must be a value between 1 and %v (%v). Ignoring it", annotation.name, annotation.max, value)
+			continue
+		}
+
+		*annotation.value = value
+	}
+
+	if name, err := parser.GetStringAnnotation("modsecurity-rules-configmap", ing); err == nil {
+		overrides, err := a.readOverrides(ing.Namespace, name)
+		if err != nil {
+			klog.Warningf("Annotation modsecurity-rules-configmap contains an invalid value: %v", err)
+		} else {
+			config.RulesConfigMap = fmt.Sprintf("%v/%v", ing.Namespace, name)
+			config.Overrides = *overrides
+		}
+	}
+
 	return config, nil
 }
+
+// readOverrides reads the rule overrides of a ConfigMap in the namespace of the Ingress
+func (a modSecurity) readOverrides(namespace, name string) (*RuleOverrides, error) {
+	if err := validateConfigMapName(name); err != nil {
+		return nil, err
+	}
+
+	cm, err := a.r.GetConfigMap(fmt.Sprintf("%v/%v", namespace, name))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error reading ConfigMap %v: %v", name, err)
+	}
+
+	return ParseRuleOverrides(cm.Data)
+}
+
+// GetDocumentation returns the annotations read by the parser
+func (a modSecurity) GetDocumentation() parser.AnnotationFields {
+	return modsecurityAnnotations
+}
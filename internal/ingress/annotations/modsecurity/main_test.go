@@ -41,22 +41,22 @@ func TestParse(t *testing.T) {
 		annotations map[string]string
 		expected    Config
 	}{
-		{map[string]string{enable: "true"}, Config{true, true, false, "", ""}},
-		{map[string]string{enable: "false"}, Config{false, true, false, "", ""}},
-		{map[string]string{enable: ""}, Config{false, false, false, "", ""}},
+		{map[string]string{enable: "true"}, Config{Enable: true, EnableSet: true}},
+		{map[string]string{enable: "false"}, Config{EnableSet: true}},
+		{map[string]string{enable: ""}, Config{}},
 
-		{map[string]string{owasp: "true"}, Config{false, false, true, "", ""}},
-		{map[string]string{owasp: "false"}, Config{false, false, false, "", ""}},
-		{map[string]string{owasp: ""}, Config{false, false, false, "", ""}},
+		{map[string]string{owasp: "true"}, Config{OWASPRules: true}},
+		{map[string]string{owasp: "false"}, Config{}},
+		{map[string]string{owasp: ""}, Config{}},
 
-		{map[string]string{transID: "ok"}, Config{false, false, false, "ok", ""}},
-		{map[string]string{transID: ""}, Config{false, false, false, "", ""}},
+		{map[string]string{transID: "ok"}, Config{TransactionID: "ok"}},
+		{map[string]string{transID: ""}, Config{}},
 
-		{map[string]string{snippet: "ModSecurity Rule"}, Config{false, false, false, "", "ModSecurity Rule"}},
-		{map[string]string{snippet: ""}, Config{false, false, false, "", ""}},
+		{map[string]string{snippet: "ModSecurity Rule"}, Config{Snippet: "ModSecurity Rule"}},
+		{map[string]string{snippet: ""}, Config{}},
 
-		{map[string]string{}, Config{false, false, false, "", ""}},
-		{nil, Config{false, false, false, "", ""}},
+		{map[string]string{}, Config{}},
+		{nil, Config{}},
 	}
 
 	ing := &networking.Ingress{
@@ -76,3 +76,77 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStructuredSettings(t *testing.T) {
+	mode := parser.GetAnnotationWithPrefix("modsecurity-mode")
+	paranoia := parser.GetAnnotationWithPrefix("modsecurity-paranoia-level")
+	inbound := parser.GetAnnotationWithPrefix("modsecurity-inbound-anomaly-threshold")
+	outbound := parser.GetAnnotationWithPrefix("modsecurity-outbound-anomaly-threshold")
+	rules := parser.GetAnnotationWithPrefix("modsecurity-rules-configmap")
+
+	ap := NewParser(&resolver.Mock{
+		ConfigMaps: map[string]*api.ConfigMap{
+			"default/waf-rules": {
+				Data: map[string]string{
+					"remove-by-id":        "920350, 942100-942199",
+					"remove-by-tag":       "attack-sqli",
+					"update-target-by-id": "942100 !ARGS:password|!REQUEST_COOKIES:session",
+				},
+			},
+			"default/invalid-rules": {
+				Data: map[string]string{"remove-by-id": "942100;"},
+			},
+		},
+	})
+
+	// invalid values are ignored without discarding the other settings
+	testCases := []struct {
+		annotations map[string]string
+		expected    Config
+	}{
+		{map[string]string{mode: "DetectionOnly"}, Config{Mode: ModeDetectionOnly}},
+		{map[string]string{mode: "On"}, Config{Mode: ModeOn}},
+		{map[string]string{mode: "Off"}, Config{}},
+
+		{map[string]string{paranoia: "2"}, Config{ParanoiaLevel: 2}},
+		{map[string]string{paranoia: "5"}, Config{}},
+		{map[string]string{paranoia: "high"}, Config{}},
+		{map[string]string{mode: "On", paranoia: "5"}, Config{Mode: ModeOn}},
+
+		{map[string]string{inbound: "10", outbound: "8"}, Config{InboundAnomalyThreshold: 10, OutboundAnomalyThreshold: 8}},
+		{map[string]string{inbound: "0", outbound: "8"}, Config{OutboundAnomalyThreshold: 8}},
+
+		{map[string]string{rules: "waf-rules"}, Config{
+			RulesConfigMap: "default/waf-rules",
+			Overrides: RuleOverrides{
+				RemoveByID:    []string{"920350", "942100-942199"},
+				RemoveByTag:   []string{"attack-sqli"},
+				UpdateTargets: []TargetUpdate{{RuleID: "942100", Targets: []string{"!ARGS:password", "!REQUEST_COOKIES:session"}}},
+			},
+		}},
+		{map[string]string{rules: "invalid-rules"}, Config{}},
+		{map[string]string{rules: "missing"}, Config{}},
+		{map[string]string{rules: "../waf-rules", mode: "DetectionOnly"}, Config{Mode: ModeDetectionOnly}},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error parsing the annotations %v: %v", testCase.annotations, err)
+		}
+
+		config := result.(*Config)
+		if !config.Equal(&testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
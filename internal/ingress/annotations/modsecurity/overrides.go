@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modsecurity
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// keys of the ConfigMap with the rule overrides
+const (
+	removeByIDKey    = "remove-by-id"
+	removeByTagKey   = "remove-by-tag"
+	updateTargetsKey = "update-target-by-id"
+)
+
+var (
+	// a rule ID or a range of IDs, like 942100 or 942100-942199
+	ruleIDRegex = regexp.MustCompile(`^[0-9]{1,9}(-[0-9]{1,9})?$`)
+	// a tag of the OWASP Core Rule Set, like attack-sqli or paranoia-level/2
+	ruleTagRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.:/-]*$`)
+	// a variable or collection of a rule, optionally excluded with ! and
+	// with a key, like ARGS:password or !REQUEST_COOKIES:session
+	ruleTargetRegex = regexp.MustCompile(`^!?[A-Z_]+(:[a-zA-Z0-9_.-]+)?$`)
+)
+
+// RuleOverrides contains the exclusions and overrides of the ModSecurity
+// rules read from a ConfigMap. The values are validated so they can be
+// rendered in the configuration without escaping.
+type RuleOverrides struct {
+	// RemoveByID contains the IDs, or ranges of IDs, of the rules removed
+	RemoveByID []string `json:"removeByID,omitempty"`
+	// RemoveByTag contains the tags of the rules removed
+	RemoveByTag []string `json:"removeByTag,omitempty"`
+	// UpdateTargets contains the variables added to or excluded from rules
+	UpdateTargets []TargetUpdate `json:"updateTargets,omitempty"`
+}
+
+// TargetUpdate changes the variables inspected by a rule
+type TargetUpdate struct {
+	RuleID  string   `json:"ruleID"`
+	Targets []string `json:"targets"`
+}
+
+// Equal tests for equality between two RuleOverrides types
+func (o1 *RuleOverrides) Equal(o2 *RuleOverrides) bool {
+	if o1 == o2 {
+		return true
+	}
+	if o1 == nil || o2 == nil {
+		return false
+	}
+
+	return reflect.DeepEqual(o1, o2)
+}
+
+// IsEmpty returns true if there are no overrides
+func (o *RuleOverrides) IsEmpty() bool {
+	return len(o.RemoveByID) == 0 && len(o.RemoveByTag) == 0 && len(o.UpdateTargets) == 0
+}
+
+// ParseRuleOverrides parses the data of a ConfigMap with rule overrides.
+// The key remove-by-id contains the IDs, or ranges of IDs, of the rules removed
+// separated by spaces or commas, and remove-by-tag the tags of the rules removed,
+// one per line. Each line of update-target-by-id contains a rule ID followed by
+// the variables added or excluded separated by |, like "942100 !ARGS:password".
+func ParseRuleOverrides(data map[string]string) (*RuleOverrides, error) {
+	overrides := &RuleOverrides{}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := data[key]
+		switch key {
+		case removeByIDKey:
+			for _, id := range strings.FieldsFunc(value, isSeparator) {
+				if !ruleIDRegex.MatchString(id) {
+					return nil, fmt.Errorf("%v: invalid rule ID %q", key, id)
+				}
+				overrides.RemoveByID = append(overrides.RemoveByID, id)
+			}
+		case removeByTagKey:
+			for _, tag := range nonEmptyLines(value) {
+				if !ruleTagRegex.MatchString(tag) {
+					return nil, fmt.Errorf("%v: invalid tag %q", key, tag)
+				}
+				overrides.RemoveByTag = append(overrides.RemoveByTag, tag)
+			}
+		case updateTargetsKey:
+			for _, line := range nonEmptyLines(value) {
+				update, err := parseTargetUpdate(line)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %v", key, err)
+				}
+				overrides.UpdateTargets = append(overrides.UpdateTargets, *update)
+			}
+		default:
+			return nil, fmt.Errorf("unknown key %v", key)
+		}
+	}
+
+	return overrides, nil
+}
+
+func parseTargetUpdate(line string) (*TargetUpdate, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected a rule ID and its targets but got %q", line)
+	}
+
+	id := fields[0]
+	if !ruleIDRegex.MatchString(id) || strings.Contains(id, "-") {
+		return nil, fmt.Errorf("invalid rule ID %q", id)
+	}
+
+	targets := strings.Split(fields[1], "|")
+	for _, target := range targets {
+		if !ruleTargetRegex.MatchString(target) {
+			return nil, fmt.Errorf("invalid target %q of rule %v", target, id)
+		}
+	}
+
+	return &TargetUpdate{RuleID: id, Targets: targets}, nil
+}
+
+func isSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func nonEmptyLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
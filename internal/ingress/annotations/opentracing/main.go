@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var opentracingAnnotations = parser.AnnotationFields{
+	"enable-opentracing": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Enables OpenTracing",
+	},
+}
+
 type opentracing struct {
 	r resolver.Resolver
 }
@@ -59,3 +68,8 @@ func (s opentracing) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return &Config{Set: true, Enabled: enabled}, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (s opentracing) GetDocumentation() parser.AnnotationFields {
+	return opentracingAnnotations
+}
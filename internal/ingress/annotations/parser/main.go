@@ -37,8 +37,10 @@ var (
 )
 
 // IngressAnnotation has a method to parse annotations located in Ingress
+// and a method returning the annotations read by the parser
 type IngressAnnotation interface {
 	Parse(ing *networking.Ingress) (interface{}, error)
+	GetDocumentation() AnnotationFields
 }
 
 type ingAnnotations map[string]string
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+)
+
+// AnnotationType defines the type of the value of an annotation
+type AnnotationType string
+
+const (
+	// AnnotationTypeString defines an annotation with a non empty string value
+	AnnotationTypeString AnnotationType = "string"
+	// AnnotationTypeBool defines an annotation with a boolean value
+	AnnotationTypeBool AnnotationType = "bool"
+	// AnnotationTypeInt defines an annotation with an integer value
+	AnnotationTypeInt AnnotationType = "int"
+)
+
+// AnnotationScope defines the part of the NGINX configuration affected by an annotation
+type AnnotationScope string
+
+const (
+	// AnnotationScopeLocation defines an annotation that only affects
+	// the locations of the paths defined in the Ingress
+	AnnotationScopeLocation AnnotationScope = "location"
+	// AnnotationScopeServer defines an annotation that affects the server
+	// block of the host, shared by all the Ingresses using the host
+	AnnotationScopeServer AnnotationScope = "server"
+	// AnnotationScopeBackend defines an annotation that affects the upstream,
+	// shared by all the Ingresses referencing the same Service
+	AnnotationScopeBackend AnnotationScope = "backend"
+	// AnnotationScopeIngress defines an annotation that does not affect the
+	// NGINX configuration, only the Ingress object, like its status
+	AnnotationScopeIngress AnnotationScope = "ingress"
+)
+
+// AnnotationRisk defines the risk of allowing users to set an annotation
+type AnnotationRisk int
+
+const (
+	// AnnotationRiskLow annotations only tune the behavior of the Ingress
+	AnnotationRiskLow AnnotationRisk = iota
+	// AnnotationRiskMedium annotations reference other objects or change
+	// the way the traffic is routed
+	AnnotationRiskMedium
+	// AnnotationRiskHigh annotations can expose or bypass access controls
+	AnnotationRiskHigh
+	// AnnotationRiskCritical annotations inject raw NGINX configuration
+	AnnotationRiskCritical
+)
+
+func (r AnnotationRisk) String() string {
+	switch r {
+	case AnnotationRiskLow:
+		return "Low"
+	case AnnotationRiskMedium:
+		return "Medium"
+	case AnnotationRiskHigh:
+		return "High"
+	case AnnotationRiskCritical:
+		return "Critical"
+	default:
+		return "Unknown"
+	}
+}
+
+// AnnotationConfig describes an annotation read by a parser
+type AnnotationConfig struct {
+	Type  AnnotationType
+	Scope AnnotationScope
+	Risk  AnnotationRisk
+	// Default contains the value used when the annotation is not present.
+	// An empty value means the default is obtained from the global configuration.
+	Default string
+	// AllowedValues contains the values accepted by the parser (case insensitive).
+	// An empty list means any value of the declared type is accepted.
+	AllowedValues []string
+	// Validator checks the format of the value, if the type is not enough
+	Validator func(value string) error
+	// Documentation contains a short description of the annotation
+	Documentation string
+}
+
+// AnnotationFields contains the annotations read by a parser,
+// indexed by the name of the annotation without the prefix
+type AnnotationFields map[string]AnnotationConfig
+
+// Validate checks the value of an annotation matches the declared type
+// and, if present, the validator and the list of allowed values
+func (c AnnotationConfig) Validate(name, value string) error {
+	v := normalizeString(value)
+
+	switch c.Type {
+	case AnnotationTypeBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return errors.NewInvalidAnnotationContent(name, value)
+		}
+	case AnnotationTypeInt:
+		if _, err := strconv.Atoi(v); err != nil {
+			return errors.NewInvalidAnnotationContent(name, value)
+		}
+	default:
+		if len(v) == 0 {
+			return errors.NewInvalidAnnotationContent(name, value)
+		}
+	}
+
+	if c.Validator != nil {
+		if err := c.Validator(v); err != nil {
+			return errors.NewInvalidAnnotationConfiguration(name, err.Error())
+		}
+	}
+
+	if len(c.AllowedValues) == 0 {
+		return nil
+	}
+
+	for _, allowed := range c.AllowedValues {
+		if strings.EqualFold(strings.TrimSpace(v), allowed) {
+			return nil
+		}
+	}
+
+	return errors.NewInvalidAnnotationConfiguration(name,
+		"allowed values are "+strings.Join(c.AllowedValues, ", "))
+}
+
+// ValidateRegex returns a validator checking the value matches a regular expression
+func ValidateRegex(re *regexp.Regexp) func(string) error {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("%v does not match the expression %v", value, re.String())
+		}
+
+		return nil
+	}
+}
+
+// ValidateDuration checks the value is a valid duration, like 1m or 30s
+func ValidateDuration(value string) error {
+	_, err := time.ParseDuration(value)
+	return err
+}
@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var portinredirectAnnotations = parser.AnnotationFields{
+	"use-port-in-redirects": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Includes the port in the redirects generated by NGINX",
+	},
+}
+
 type portInRedirect struct {
 	r resolver.Resolver
 }
@@ -42,3 +51,8 @@ func (a portInRedirect) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return up, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a portInRedirect) GetDocumentation() parser.AnnotationFields {
+	return portinredirectAnnotations
+}
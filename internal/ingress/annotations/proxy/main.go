@@ -23,6 +23,114 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var proxyAnnotations = parser.AnnotationFields{
+	"proxy-connect-timeout": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Timeout in seconds to establish a connection with the upstream",
+	},
+	"proxy-send-timeout": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Timeout in seconds to send a request to the upstream",
+	},
+	"proxy-read-timeout": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Timeout in seconds to read a response from the upstream",
+	},
+	"proxy-buffers-number": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of buffers used to read the response from the upstream",
+	},
+	"proxy-buffer-size": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Size of the buffer used to read the first part of the response from the upstream",
+	},
+	"proxy-cookie-path": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the proxy_cookie_path directive",
+	},
+	"proxy-cookie-domain": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the proxy_cookie_domain directive",
+	},
+	"proxy-body-size": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Maximum size of the client request body",
+	},
+	"proxy-next-upstream": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Cases in which the request is passed to the next upstream server",
+	},
+	"proxy-next-upstream-timeout": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Time in seconds allowed to pass a request to the next upstream server",
+	},
+	"proxy-next-upstream-tries": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of tries to pass a request to the next upstream server",
+	},
+	"proxy-request-buffering": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"on", "off"},
+		Documentation: "Enables buffering of the client request body",
+	},
+	"proxy-redirect-from": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Text replaced in the Location and Refresh headers of the response",
+	},
+	"proxy-redirect-to": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Replacement of the proxy-redirect-from text",
+	},
+	"proxy-buffering": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"on", "off"},
+		Documentation: "Enables buffering of the responses from the upstream",
+	},
+	"proxy-http-version": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"1.0", "1.1"},
+		Documentation: "HTTP version used to connect to the upstream",
+	},
+	"proxy-max-temp-file-size": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Maximum size of the temporary file used to buffer responses",
+	},
+}
+
 // Config returns the proxy timeout to use in the upstream server/s
 type Config struct {
 	BodySize             string `json:"bodySize"`
@@ -212,3 +320,8 @@ func (a proxy) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a proxy) GetDocumentation() parser.AnnotationFields {
+	return proxyAnnotations
+}
@@ -29,6 +29,56 @@ import (
 	"k8s.io/ingress-nginx/internal/k8s"
 )
 
+var proxysslAnnotations = parser.AnnotationFields{
+	"proxy-ssl-secret": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Name of the Secret with the client certificate and CA used to connect to the upstream",
+	},
+	"proxy-ssl-ciphers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Ciphers enabled in the connection to the upstream",
+	},
+	"proxy-ssl-protocols": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Protocols enabled in the connection to the upstream",
+	},
+	"proxy-ssl-name": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Server name used to verify the certificate of the upstream",
+	},
+	"proxy-ssl-verify": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "off",
+		AllowedValues: []string{"on", "off"},
+		Documentation: "Enables verification of the certificate of the upstream",
+	},
+	"proxy-ssl-verify-depth": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "1",
+		Documentation: "Verification depth of the certificate chain of the upstream",
+	},
+	"proxy-ssl-server-name": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "off",
+		AllowedValues: []string{"on", "off"},
+		Documentation: "Enables SNI in the connection to the upstream",
+	},
+}
+
 const (
 	defaultProxySSLCiphers     = "DEFAULT"
 	defaultProxySSLProtocols   = "TLSv1 TLSv1.1 TLSv1.2"
@@ -171,3 +221,8 @@ func (p proxySSL) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (p proxySSL) GetDocumentation() parser.AnnotationFields {
+	return proxysslAnnotations
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package publishaddressset
+
+import (
+	networking "k8s.io/api/networking/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var publishAddressSetAnnotations = parser.AnnotationFields{
+	"publish-address-set": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeIngress,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Name of the address set, defined with the flag --publish-address-set, published in the status of the Ingress",
+	},
+}
+
+type publishAddressSet struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new publish address set annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return publishAddressSet{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to select the addresses published in the status of the Ingress
+func (pas publishAddressSet) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parser.GetStringAnnotation("publish-address-set", ing)
+}
+
+// GetDocumentation returns the annotations read by the parser
+func (pas publishAddressSet) GetDocumentation() parser.AnnotationFields {
+	return publishAddressSetAnnotations
+}
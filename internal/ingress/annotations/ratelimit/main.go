@@ -29,6 +29,52 @@ import (
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var ratelimitAnnotations = parser.AnnotationFields{
+	"limit-rate": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Rate limit in kilobytes per second of the responses",
+	},
+	"limit-rate-after": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Size in kilobytes of the response after which the rate limit is applied",
+	},
+	"limit-rpm": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of requests per minute allowed from a single IP address",
+	},
+	"limit-rps": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of requests per second allowed from a single IP address",
+	},
+	"limit-connections": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Number of concurrent connections allowed from a single IP address",
+	},
+	"limit-burst-multiplier": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "5",
+		Documentation: "Multiplier of the rate limit used as burst size",
+	},
+	"limit-whitelist": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Comma separated list of CIDRs excluded from the rate limits",
+	},
+}
+
 const (
 	// allow 5 times the specified limit as burst
 	defBurst = 5
@@ -207,6 +253,11 @@ func (a ratelimit) Parse(ing *networking.Ingress) (interface{}, error) {
 	}, nil
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (a ratelimit) GetDocumentation() parser.AnnotationFields {
+	return ratelimitAnnotations
+}
+
 func encode(s string) string {
 	str := base64.URLEncoding.EncodeToString([]byte(s))
 	return strings.Replace(str, "=", "", -1)
@@ -28,6 +28,35 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var redirectAnnotations = parser.AnnotationFields{
+	"from-to-www-redirect": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Redirects requests between the host and the host prefixed with www",
+	},
+	"temporal-redirect": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "URL used to redirect all the requests with a 302 status code",
+	},
+	"permanent-redirect": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "URL used to redirect all the requests",
+	},
+	"permanent-redirect-code": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "301",
+		Documentation: "Status code of the permanent redirect",
+	},
+}
+
 const defaultPermanentRedirectCode = http.StatusMovedPermanently
 
 // Config returns the redirect configuration for an Ingress rule
@@ -95,6 +124,11 @@ func (r redirect) Parse(ing *networking.Ingress) (interface{}, error) {
 	return nil, errors.ErrMissingAnnotations
 }
 
+// GetDocumentation returns the annotations read by the parser
+func (r redirect) GetDocumentation() parser.AnnotationFields {
+	return redirectAnnotations
+}
+
 // Equal tests for equality between two Redirect types
 func (r1 *Config) Equal(r2 *Config) bool {
 	if r1 == r2 {
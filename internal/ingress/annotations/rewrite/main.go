@@ -17,7 +17,10 @@ limitations under the License.
 package rewrite
 
 import (
+	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 
 	networking "k8s.io/api/networking/v1beta1"
 	"k8s.io/klog/v2"
@@ -25,8 +28,71 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/sets"
 )
 
+var rewriteAnnotations = parser.AnnotationFields{
+	"rewrite-target": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Target URI of the requests",
+	},
+	"rewrite-rules": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		Validator:     validateRules,
+		Documentation: "Ordered rewrite rules, one regex, replacement and optional flag (last or break) per line",
+	},
+	"ssl-redirect": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Redirects HTTP requests to HTTPS when the server has a certificate",
+	},
+	"force-ssl-redirect": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Redirects HTTP requests to HTTPS even without a certificate",
+	},
+	"use-regex": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Interprets the paths of the Ingress as regular expressions",
+	},
+	"app-root": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Path used to redirect requests to /",
+	},
+	"query-params-remove": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateQueryParamList,
+		Documentation: "Comma separated list of query parameters removed from the request",
+	},
+	"query-params-rename": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateQueryParamRename,
+		Documentation: "Comma separated list of old:new query parameter renames",
+	},
+	"query-params-add": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     validateQueryParamAdd,
+		Documentation: "Comma separated list of name=value query parameters added to the request",
+	},
+}
+
 // Config describes the per location redirect config
 type Config struct {
 	// Target URI where the traffic must be redirected
@@ -39,6 +105,88 @@ type Config struct {
 	AppRoot string `json:"appRoot"`
 	// UseRegex indicates whether or not the locations use regex paths
 	UseRegex bool `json:"useRegex"`
+	// Rules contains an ordered list of rewrite rules applied before
+	// the request is sent to the upstream
+	Rules []Rule `json:"rules,omitempty"`
+	// QueryParams describes the manipulation of the query string
+	QueryParams QueryParams `json:"queryParams"`
+}
+
+// Rule describes a single rewrite directive
+type Rule struct {
+	// Regex is the PCRE expression matched against the request URI
+	Regex string `json:"regex"`
+	// Replacement is the new URI. It can reference capture groups
+	Replacement string `json:"replacement"`
+	// Flag is the NGINX rewrite flag (last or break)
+	Flag string `json:"flag"`
+}
+
+// Equal tests for equality between two Rule types
+func (r1 Rule) Equal(r2 Rule) bool {
+	if r1.Regex != r2.Regex {
+		return false
+	}
+	if r1.Replacement != r2.Replacement {
+		return false
+	}
+	if r1.Flag != r2.Flag {
+		return false
+	}
+
+	return true
+}
+
+// QueryParams describes the operations applied to the query string.
+// Operations are applied in the order: remove, rename and add
+type QueryParams struct {
+	// Add contains the parameters (and values) to be added
+	Add map[string]string `json:"add,omitempty"`
+	// Remove contains the name of the parameters to be removed
+	Remove []string `json:"remove,omitempty"`
+	// Rename maps the original name of a parameter to the new one
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// Equal tests for equality between two QueryParams types
+func (q1 *QueryParams) Equal(q2 *QueryParams) bool {
+	if q1 == q2 {
+		return true
+	}
+	if q1 == nil || q2 == nil {
+		return false
+	}
+	if !sets.StringElementsMatch(q1.Remove, q2.Remove) {
+		return false
+	}
+	if !stringMapEqual(q1.Add, q2.Add) {
+		return false
+	}
+	if !stringMapEqual(q1.Rename, q2.Rename) {
+		return false
+	}
+
+	return true
+}
+
+// Empty returns true if there are no operations over the query string
+func (q QueryParams) Empty() bool {
+	return len(q.Add) == 0 && len(q.Remove) == 0 && len(q.Rename) == 0
+}
+
+func stringMapEqual(m1, m2 map[string]string) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+
+	for k, v := range m1 {
+		v2, ok := m2[k]
+		if !ok || v != v2 {
+			return false
+		}
+	}
+
+	return true
 }
 
 // Equal tests for equality between two Redirect types
@@ -64,10 +212,170 @@ func (r1 *Config) Equal(r2 *Config) bool {
 	if r1.UseRegex != r2.UseRegex {
 		return false
 	}
+	if len(r1.Rules) != len(r2.Rules) {
+		return false
+	}
+	for i := range r1.Rules {
+		if !r1.Rules[i].Equal(r2.Rules[i]) {
+			return false
+		}
+	}
+	if !(&r1.QueryParams).Equal(&r2.QueryParams) {
+		return false
+	}
 
 	return true
 }
 
+var (
+	// rewriteFlagRegex contains the flags allowed in a rewrite rule
+	rewriteFlagRegex = regexp.MustCompile(`^(last|break)$`)
+	// rewriteReplacementRegex avoids the injection of NGINX directives in the replacement
+	rewriteReplacementRegex = regexp.MustCompile(`^[^\s;{}"'\\]+$`)
+	// queryParamNameRegex contains the characters allowed in a query parameter name
+	queryParamNameRegex = regexp.MustCompile(`^[A-Za-z0-9_\-\.\[\]]+$`)
+	// queryParamValueRegex contains the characters allowed in a query parameter value
+	queryParamValueRegex = regexp.MustCompile(`^[A-Za-z0-9_\-\.~%:/]*$`)
+)
+
+// ParseRules parses the content of the rewrite-rules annotation.
+// Each line contains a rule with the format: <regex> <replacement> [last|break]
+// If the flag is omitted break is used.
+func ParseRules(value string) ([]Rule, error) {
+	rules := []Rule{}
+
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid rewrite rule %q: expected <regex> <replacement> [last|break]", line)
+		}
+
+		rule := Rule{
+			Regex:       fields[0],
+			Replacement: fields[1],
+			Flag:        "break",
+		}
+
+		if len(fields) == 3 {
+			if !rewriteFlagRegex.MatchString(fields[2]) {
+				return nil, fmt.Errorf("invalid flag %q in rewrite rule %q: only last or break are allowed", fields[2], line)
+			}
+
+			rule.Flag = fields[2]
+		}
+
+		if strings.ContainsAny(rule.Regex, `"'`) {
+			return nil, fmt.Errorf("invalid regex %q in rewrite rule: contains forbidden characters", rule.Regex)
+		}
+
+		// the regex is rendered between double quotes, an unescaped
+		// backslash at the end would escape the closing quote
+		if hasTrailingBackslash(rule.Regex) {
+			return nil, fmt.Errorf("invalid regex %q in rewrite rule: ends with an unescaped backslash", rule.Regex)
+		}
+
+		if _, err := regexp.Compile(rule.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex %q in rewrite rule: %v", rule.Regex, err)
+		}
+
+		if !rewriteReplacementRegex.MatchString(rule.Replacement) {
+			return nil, fmt.Errorf("invalid replacement %q in rewrite rule: contains forbidden characters", rule.Replacement)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// hasTrailingBackslash returns true if the value ends with an odd number of backslashes
+func hasTrailingBackslash(value string) bool {
+	count := 0
+	for i := len(value) - 1; i >= 0 && value[i] == '\\'; i-- {
+		count++
+	}
+
+	return count%2 == 1
+}
+
+func validateRules(value string) error {
+	_, err := ParseRules(value)
+	return err
+}
+
+func validateQueryParamList(value string) error {
+	_, err := parseQueryParamList(value)
+	return err
+}
+
+func validateQueryParamRename(value string) error {
+	_, err := parseQueryParamMap(value, ":", true)
+	return err
+}
+
+func validateQueryParamAdd(value string) error {
+	_, err := parseQueryParamMap(value, "=", false)
+	return err
+}
+
+// parseQueryParamList parses a comma separated list of parameter names
+func parseQueryParamList(value string) ([]string, error) {
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !queryParamNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid query parameter name %q", name)
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// parseQueryParamMap parses a comma separated list of <key><sep><value> pairs.
+// If isName is true the value is validated as a parameter name
+func parseQueryParamMap(value, sep string, isName bool) (map[string]string, error) {
+	params := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, sep, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid query parameter %q: expected the format <name>%v<value>", pair, sep)
+		}
+
+		name, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if !queryParamNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid query parameter name %q", name)
+		}
+
+		if isName && !queryParamNameRegex.MatchString(val) {
+			return nil, fmt.Errorf("invalid query parameter name %q", val)
+		}
+
+		if !isName && !queryParamValueRegex.MatchString(val) {
+			return nil, fmt.Errorf("invalid query parameter value %q", val)
+		}
+
+		params[name] = val
+	}
+
+	return params, nil
+}
+
 type rewrite struct {
 	r resolver.Resolver
 }
@@ -96,6 +404,17 @@ func (a rewrite) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	config.UseRegex, _ = parser.GetBoolAnnotation("use-regex", ing)
 
+	rules, err := parser.GetStringAnnotation("rewrite-rules", ing)
+	if err == nil {
+		config.Rules, err = ParseRules(rules)
+		if err != nil {
+			klog.Warningf("Annotation rewrite-rules contains an invalid value: %v", err)
+			config.Rules = nil
+		}
+	}
+
+	config.QueryParams = parseQueryParams(ing)
+
 	config.AppRoot, err = parser.GetStringAnnotation("app-root", ing)
 	if err != nil {
 		if !errors.IsMissingAnnotations(err) && !errors.IsInvalidContent(err) {
@@ -120,3 +439,40 @@ func (a rewrite) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a rewrite) GetDocumentation() parser.AnnotationFields {
+	return rewriteAnnotations
+}
+
+// parseQueryParams parses the annotations used to manipulate the query string.
+// Invalid annotations are ignored
+func parseQueryParams(ing *networking.Ingress) QueryParams {
+	qp := QueryParams{}
+
+	if val, err := parser.GetStringAnnotation("query-params-remove", ing); err == nil {
+		qp.Remove, err = parseQueryParamList(val)
+		if err != nil {
+			klog.Warningf("Annotation query-params-remove contains an invalid value: %v", err)
+			qp.Remove = nil
+		}
+	}
+
+	if val, err := parser.GetStringAnnotation("query-params-rename", ing); err == nil {
+		qp.Rename, err = parseQueryParamMap(val, ":", true)
+		if err != nil {
+			klog.Warningf("Annotation query-params-rename contains an invalid value: %v", err)
+			qp.Rename = nil
+		}
+	}
+
+	if val, err := parser.GetStringAnnotation("query-params-add", ing); err == nil {
+		qp.Add, err = parseQueryParamMap(val, "=", false)
+		if err != nil {
+			klog.Warningf("Annotation query-params-add contains an invalid value: %v", err)
+			qp.Add = nil
+		}
+	}
+
+	return qp
+}
@@ -219,3 +219,121 @@ func TestUseRegex(t *testing.T) {
 		t.Errorf("Unexpected value got in UseRegex")
 	}
 }
+
+func TestRewriteRules(t *testing.T) {
+	testCases := []struct {
+		title    string
+		rules    string
+		expected []Rule
+	}{
+		{"single rule without flag uses break", "^/old/(.*) /new/$1", []Rule{
+			{Regex: "^/old/(.*)", Replacement: "/new/$1", Flag: "break"},
+		}},
+		{"rules are kept in order", "^/a /b last\n# comment\n\n^/c/(.*) /d/$1 break", []Rule{
+			{Regex: "^/a", Replacement: "/b", Flag: "last"},
+			{Regex: "^/c/(.*)", Replacement: "/d/$1", Flag: "break"},
+		}},
+		{"invalid flag is rejected", "^/a /b permanent", nil},
+		{"invalid regex is rejected", "^/a(.* /b", nil},
+		{"missing replacement is rejected", "^/a", nil},
+		{"replacement with directives is rejected", "^/a /b;return", nil},
+		{"regex ending with a backslash is rejected", `^/a\\\ /b`, nil},
+		{"regex ending with an escaped backslash is accepted", `^/a\\ /b`, []Rule{
+			{Regex: `^/a\\`, Replacement: "/b", Flag: "break"},
+		}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.title, func(t *testing.T) {
+			ing := buildIngress()
+			ing.Annotations[parser.GetAnnotationWithPrefix("rewrite-rules")] = testCase.rules
+
+			i, err := NewParser(mockBackend{}).Parse(ing)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rewrite, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a rewrite Config")
+			}
+
+			if len(rewrite.Rules) != len(testCase.expected) {
+				t.Fatalf("expected %v rules but %v were returned", len(testCase.expected), len(rewrite.Rules))
+			}
+
+			for i := range testCase.expected {
+				if !rewrite.Rules[i].Equal(testCase.expected[i]) {
+					t.Errorf("expected rule %v but %v was returned", testCase.expected[i], rewrite.Rules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	ing := buildIngress()
+	ing.Annotations[parser.GetAnnotationWithPrefix("query-params-add")] = "source=ingress, version=v2"
+	ing.Annotations[parser.GetAnnotationWithPrefix("query-params-remove")] = "utm_source,utm_medium"
+	ing.Annotations[parser.GetAnnotationWithPrefix("query-params-rename")] = "q:query"
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rewrite, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a rewrite Config")
+	}
+
+	expected := QueryParams{
+		Add:    map[string]string{"source": "ingress", "version": "v2"},
+		Remove: []string{"utm_source", "utm_medium"},
+		Rename: map[string]string{"q": "query"},
+	}
+
+	if !(&rewrite.QueryParams).Equal(&expected) {
+		t.Errorf("expected %v but %v was returned", expected, rewrite.QueryParams)
+	}
+
+	ing.Annotations[parser.GetAnnotationWithPrefix("query-params-add")] = "source=$remote_addr"
+	ing.Annotations[parser.GetAnnotationWithPrefix("query-params-rename")] = "q"
+
+	i, _ = NewParser(mockBackend{}).Parse(ing)
+	rewrite = i.(*Config)
+	if len(rewrite.QueryParams.Add) != 0 {
+		t.Errorf("expected invalid query-params-add to be ignored but %v was returned", rewrite.QueryParams.Add)
+	}
+	if len(rewrite.QueryParams.Rename) != 0 {
+		t.Errorf("expected invalid query-params-rename to be ignored but %v was returned", rewrite.QueryParams.Rename)
+	}
+}
+
+func TestValidateAnnotations(t *testing.T) {
+	testCases := []struct {
+		annotation string
+		value      string
+		valid      bool
+	}{
+		{"rewrite-rules", "^/old/(.*) /new/$1 last", true},
+		{"rewrite-rules", "^/old/(.*) /new/$1 permanent", false},
+		{"rewrite-rules", `^/old\\\ /new`, false},
+		{"query-params-remove", "utm_source,utm_medium", true},
+		{"query-params-remove", "utm source", false},
+		{"query-params-rename", "q:query", true},
+		{"query-params-rename", "q", false},
+		{"query-params-add", "source=ingress", true},
+		{"query-params-add", "source=$remote_addr", false},
+	}
+
+	for _, testCase := range testCases {
+		err := rewriteAnnotations[testCase.annotation].Validate(testCase.annotation, testCase.value)
+		if testCase.valid && err != nil {
+			t.Errorf("unexpected error validating %v: %v", testCase.value, err)
+		}
+		if !testCase.valid && err == nil {
+			t.Errorf("expected an error validating %v", testCase.value)
+		}
+	}
+}
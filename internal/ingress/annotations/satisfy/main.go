@@ -23,6 +23,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var satisfyAnnotations = parser.AnnotationFields{
+	"satisfy": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskMedium,
+		AllowedValues: []string{"any", "all"},
+		Documentation: "Access is allowed when any or all the access controls are satisfied",
+	},
+}
+
 type satisfy struct {
 	r resolver.Resolver
 }
@@ -42,3 +52,8 @@ func (s satisfy) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return satisfy, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (s satisfy) GetDocumentation() parser.AnnotationFields {
+	return satisfyAnnotations
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// schema contains the annotations declared by all the annotation parsers
+var schema = NewAnnotationExtractor(nil).Schema()
+
+// Schema returns the annotations read by the parsers of the extractor,
+// indexed by the name of the annotation without the prefix
+func (e Extractor) Schema() parser.AnnotationFields {
+	fields := parser.AnnotationFields{}
+	for _, annotationParser := range e.annotations {
+		for name, config := range annotationParser.GetDocumentation() {
+			fields[name] = config
+		}
+	}
+
+	return fields
+}
+
+// Schema returns the annotations supported by the ingress controller,
+// indexed by the name of the annotation without the prefix
+func Schema() parser.AnnotationFields {
+	fields := make(parser.AnnotationFields, len(schema))
+	for name, config := range schema {
+		fields[name] = config
+	}
+
+	return fields
+}
+
+// ValidateAnnotations checks the annotations with the ingress controller
+// prefix against the schema. It returns the names of the annotations not
+// supported by the ingress controller, and the errors found in the values
+// of the supported annotations.
+func ValidateAnnotations(annotations map[string]string) ([]string, []error) {
+	var unknown []string
+	var errs []error
+
+	prefix := parser.AnnotationsPrefix + "/"
+	for key, value := range annotations {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		config, ok := schema[strings.TrimPrefix(key, prefix)]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+
+		if err := config.Validate(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sort.Strings(unknown)
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	return unknown, errs
+}
+
+// WriteSchemaDocs writes a markdown table with the annotations
+// supported by the ingress controller
+func WriteSchemaDocs(w io.Writer) error {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("|Name | Type | Scope | Risk | Default | Allowed values | Description |\n")
+	b.WriteString("|-----|------|-------|------|---------|----------------|-------------|\n")
+
+	for _, name := range names {
+		config := schema[name]
+
+		def := ""
+		if config.Default != "" {
+			def = fmt.Sprintf("`%v`", config.Default)
+		}
+
+		var allowed []string
+		for _, value := range config.AllowedValues {
+			allowed = append(allowed, fmt.Sprintf("`%v`", value))
+		}
+
+		fmt.Fprintf(&b, "|`%v/%v`|%v|%v|%v|%v|%v|%v|\n", parser.DefaultAnnotationsPrefix, name,
+			config.Type, config.Scope, config.Risk, def, strings.Join(allowed, ", "), config.Documentation)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
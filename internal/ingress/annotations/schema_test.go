@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+var getAnnotationRegex = regexp.MustCompile(`parser\.Get(String|Bool|Int)Annotation\("([a-z0-9-]+)"`)
+
+func TestSchemaDeclaresParsedAnnotations(t *testing.T) {
+	for name, annotationParser := range NewAnnotationExtractor(mockCfg{}).annotations {
+		if len(annotationParser.GetDocumentation()) == 0 {
+			t.Errorf("parser %v does not declare annotations", name)
+		}
+	}
+
+	files, err := filepath.Glob("*/*.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	types := map[string]parser.AnnotationType{
+		"String": parser.AnnotationTypeString,
+		"Bool":   parser.AnnotationTypeBool,
+		"Int":    parser.AnnotationTypeInt,
+	}
+
+	s := Schema()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || strings.HasPrefix(file, "parser/") {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unexpected error reading %v: %v", file, err)
+		}
+
+		for _, match := range getAnnotationRegex.FindAllStringSubmatch(string(content), -1) {
+			config, ok := s[match[2]]
+			if !ok {
+				t.Errorf("annotation %v read in %v is not declared in the schema", match[2], file)
+				continue
+			}
+
+			if config.Type != types[match[1]] {
+				t.Errorf("annotation %v read in %v as %v is declared as %v", match[2], file, types[match[1]], config.Type)
+			}
+		}
+	}
+}
+
+func TestValidateAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		parser.GetAnnotationWithPrefix("enable-cors"):           "true",
+		parser.GetAnnotationWithPrefix("proxy-read-timeout"):    "30s",
+		parser.GetAnnotationWithPrefix("backend-protocol"):      "grpc",
+		parser.GetAnnotationWithPrefix("load-balance"):          "random",
+		parser.GetAnnotationWithPrefix("secure-backends"):       "true",
+		parser.GetAnnotationWithPrefix("configuration-snippet"): "more_set_headers \"Foo: bar\";",
+		"kubernetes.io/ingress.class":                           "nginx",
+	}
+
+	unknown, errs := ValidateAnnotations(annotations)
+
+	expUnknown := []string{parser.GetAnnotationWithPrefix("secure-backends")}
+	if !reflect.DeepEqual(unknown, expUnknown) {
+		t.Errorf("expected unknown annotations %v but returned %v", expUnknown, unknown)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors but returned %v", errs)
+	}
+
+	if !strings.Contains(errs[0].Error(), "load-balance") {
+		t.Errorf("expected an error in the load-balance annotation but returned %v", errs[0])
+	}
+
+	if !strings.Contains(errs[1].Error(), "proxy-read-timeout") {
+		t.Errorf("expected an error in the proxy-read-timeout annotation but returned %v", errs[1])
+	}
+}
+
+func TestWriteSchemaDocs(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteSchemaDocs(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "|`nginx.ingress.kubernetes.io/satisfy`|string|location|Medium||`any`, `all`|Access is allowed when any or all the access controls are satisfied|\n"
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("expected the documentation to contain %q", expected)
+	}
+}
@@ -24,6 +24,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var secureupstreamAnnotations = parser.AnnotationFields{
+	"secure-verify-ca-secret": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskMedium,
+		Documentation: "Removed. Use proxy-ssl-secret instead",
+	},
+}
+
 // Config describes SSL backend configuration
 type Config struct {
 	CACert resolver.AuthSSLCert `json:"caCert"`
@@ -46,3 +55,8 @@ func (a su) Parse(ing *networking.Ingress) (secure interface{}, err error) {
 	}
 	return
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a su) GetDocumentation() parser.AnnotationFields {
+	return secureupstreamAnnotations
+}
@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var serversnippetAnnotations = parser.AnnotationFields{
+	"server-snippet": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskCritical,
+		Documentation: "Custom NGINX configuration added to the server block",
+	},
+}
+
 type serverSnippet struct {
 	r resolver.Resolver
 }
@@ -38,3 +47,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (a serverSnippet) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("server-snippet", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a serverSnippet) GetDocumentation() parser.AnnotationFields {
+	return serversnippetAnnotations
+}
@@ -23,6 +23,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var serviceupstreamAnnotations = parser.AnnotationFields{
+	"service-upstream": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Uses the ClusterIP of the Service instead of the endpoints in the upstream",
+	},
+}
+
 type serviceUpstream struct {
 	r resolver.Resolver
 }
@@ -35,3 +45,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (s serviceUpstream) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetBoolAnnotation("service-upstream", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (s serviceUpstream) GetDocumentation() parser.AnnotationFields {
+	return serviceupstreamAnnotations
+}
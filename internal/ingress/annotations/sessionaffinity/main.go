@@ -26,6 +26,72 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var sessionaffinityAnnotations = parser.AnnotationFields{
+	"affinity": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"cookie"},
+		Documentation: "Type of session affinity",
+	},
+	"affinity-mode": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "balanced",
+		AllowedValues: []string{"balanced", "persistent"},
+		Documentation: "Stickiness of the session affinity when the upstream is scaled",
+	},
+	"session-cookie-name": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "INGRESSCOOKIE",
+		Documentation: "Name of the session affinity cookie",
+	},
+	"session-cookie-expires": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     parser.ValidateRegex(affinityCookieExpiresRegex),
+		Documentation: "Expiration in seconds of the session affinity cookie",
+	},
+	"session-cookie-max-age": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Validator:     parser.ValidateRegex(affinityCookieExpiresRegex),
+		Documentation: "Max-Age in seconds of the session affinity cookie",
+	},
+	"session-cookie-path": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Path of the session affinity cookie",
+	},
+	"session-cookie-samesite": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		AllowedValues: []string{"None", "Lax", "Strict"},
+		Documentation: "SameSite attribute of the session affinity cookie",
+	},
+	"session-cookie-conditional-samesite-none": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Omits SameSite=None for clients that do not support it",
+	},
+	"session-cookie-change-on-failure": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Creates a new cookie when the upstream server of the session fails",
+	},
+}
+
 const (
 	annotationAffinityType = "affinity"
 	annotationAffinityMode = "affinity-mode"
@@ -174,3 +240,8 @@ func (a affinity) Parse(ing *networking.Ingress) (interface{}, error) {
 		Cookie: *cookie,
 	}, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a affinity) GetDocumentation() parser.AnnotationFields {
+	return sessionaffinityAnnotations
+}
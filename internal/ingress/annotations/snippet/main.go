@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var snippetAnnotations = parser.AnnotationFields{
+	"configuration-snippet": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskCritical,
+		Documentation: "Custom NGINX configuration added to the locations",
+	},
+}
+
 type snippet struct {
 	r resolver.Resolver
 }
@@ -38,3 +47,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (a snippet) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("configuration-snippet", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a snippet) GetDocumentation() parser.AnnotationFields {
+	return snippetAnnotations
+}
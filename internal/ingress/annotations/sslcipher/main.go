@@ -23,6 +23,21 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var sslcipherAnnotations = parser.AnnotationFields{
+	"ssl-ciphers": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Ciphers enabled in the server",
+	},
+	"ssl-prefer-server-ciphers": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Prefers the server ciphers over the client ciphers",
+	},
+}
+
 type sslCipher struct {
 	r resolver.Resolver
 }
@@ -60,3 +75,8 @@ func (sc sslCipher) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return config, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (sc sslCipher) GetDocumentation() parser.AnnotationFields {
+	return sslcipherAnnotations
+}
@@ -24,6 +24,16 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var sslpassthroughAnnotations = parser.AnnotationFields{
+	"ssl-passthrough": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeServer,
+		Risk:          parser.AnnotationRiskMedium,
+		Default:       "false",
+		Documentation: "Sends the TLS connections to the backend without terminating TLS",
+	},
+}
+
 type sslpt struct {
 	r resolver.Resolver
 }
@@ -42,3 +52,8 @@ func (a sslpt) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return parser.GetBoolAnnotation("ssl-passthrough", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a sslpt) GetDocumentation() parser.AnnotationFields {
+	return sslpassthroughAnnotations
+}
@@ -23,6 +23,29 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var upstreamhashbyAnnotations = parser.AnnotationFields{
+	"upstream-hash-by": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Key used for consistent hashing in the upstream",
+	},
+	"upstream-hash-by-subset": {
+		Type:          parser.AnnotationTypeBool,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "false",
+		Documentation: "Maps the requests to a subset of the upstream servers",
+	},
+	"upstream-hash-by-subset-size": {
+		Type:          parser.AnnotationTypeInt,
+		Scope:         parser.AnnotationScopeBackend,
+		Risk:          parser.AnnotationRiskLow,
+		Default:       "3",
+		Documentation: "Number of upstream servers of each subset",
+	},
+}
+
 type upstreamhashby struct {
 	r resolver.Resolver
 }
@@ -51,3 +74,8 @@ func (a upstreamhashby) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	return &Config{upstreamHashBy, upstreamHashBySubset, upstreamHashbySubsetSize}, nil
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a upstreamhashby) GetDocumentation() parser.AnnotationFields {
+	return upstreamhashbyAnnotations
+}
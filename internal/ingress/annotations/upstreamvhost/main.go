@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var upstreamvhostAnnotations = parser.AnnotationFields{
+	"upstream-vhost": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the Host header sent to the upstream",
+	},
+}
+
 type upstreamVhost struct {
 	r resolver.Resolver
 }
@@ -38,3 +47,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (a upstreamVhost) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("upstream-vhost", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (a upstreamVhost) GetDocumentation() parser.AnnotationFields {
+	return upstreamvhostAnnotations
+}
@@ -23,6 +23,15 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+var xforwardedprefixAnnotations = parser.AnnotationFields{
+	"x-forwarded-prefix": {
+		Type:          parser.AnnotationTypeString,
+		Scope:         parser.AnnotationScopeLocation,
+		Risk:          parser.AnnotationRiskLow,
+		Documentation: "Value of the X-Forwarded-Prefix header sent to the upstream",
+	},
+}
+
 type xforwardedprefix struct {
 	r resolver.Resolver
 }
@@ -37,3 +46,8 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 func (cbbs xforwardedprefix) Parse(ing *networking.Ingress) (interface{}, error) {
 	return parser.GetStringAnnotation("x-forwarded-prefix", ing)
 }
+
+// GetDocumentation returns the annotations read by the parser
+func (cbbs xforwardedprefix) GetDocumentation() parser.AnnotationFields {
+	return xforwardedprefixAnnotations
+}
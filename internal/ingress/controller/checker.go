@@ -36,11 +36,19 @@ func (n NGINXController) Name() string {
 
 // Check returns if the nginx healthz endpoint is returning ok (status code 200)
 func (n *NGINXController) Check(_ *http.Request) error {
-	if n.isShuttingDown {
-		return fmt.Errorf("the ingress controller is shutting down")
+	if err := n.checkShutdown(); err != nil {
+		return err
 	}
 
-	// check the nginx master process is running
+	if err := checkNGINXProcess(); err != nil {
+		return err
+	}
+
+	return checkDynamicLoadBalancer()
+}
+
+// checkNGINXProcess returns an error if the NGINX master process is not running
+func checkNGINXProcess() error {
 	fs, err := proc.NewFS("/proc", false)
 	if err != nil {
 		return errors.Wrap(err, "reading /proc directory")
@@ -61,6 +69,11 @@ func (n *NGINXController) Check(_ *http.Request) error {
 		return errors.Wrapf(err, "checking for NGINX process with PID %v", pid)
 	}
 
+	return nil
+}
+
+// checkDynamicLoadBalancer returns an error if the backends are not configured in Lua
+func checkDynamicLoadBalancer() error {
 	statusCode, _, err := nginx.NewGetStatusRequest("/is-dynamic-lb-initialized")
 	if err != nil {
 		return errors.Wrapf(err, "checking if the dynamic load balancer started")
@@ -138,6 +138,12 @@ type Configuration struct {
 	// By default this is disabled
 	EnableModsecurity bool `json:"enable-modsecurity"`
 
+	// EnableModsecurityAuditEvents writes the ModSecurity audit log in JSON
+	// format, parsed by the controller to export metrics and log the events
+	// of each Ingress
+	// By default this is disabled
+	EnableModsecurityAuditEvents bool `json:"enable-modsecurity-audit-events"`
+
 	// EnableOCSP enables the OCSP support in SSL connections
 	// By default this is disabled
 	EnableOCSP bool `json:"enable-ocsp"`
@@ -417,6 +423,12 @@ type Configuration struct {
 	// Default: true
 	UseHTTP2 bool `json:"use-http2,omitempty"`
 
+	// Defines the max-age, in seconds, of the Alt-Svc header used to advertise
+	// HTTP/3 support. HTTP/3 is enabled using the flag --enable-http3
+	// https://nginx.org/en/docs/http/ngx_http_v3_module.html
+	// Default: 86400
+	HTTP3AltSvcMaxAge int `json:"http3-alt-svc-max-age,omitempty"`
+
 	// gzip Compression Level that will be used
 	GzipLevel int `json:"gzip-level,omitempty"`
 
@@ -681,6 +693,12 @@ type Configuration struct {
 	// should not get authenticated
 	NoAuthLocations string `json:"no-auth-locations"`
 
+	// EnableDynamicLocations routes the requests of the locations of a server
+	// using Lua, so adding or removing paths, whitelists, redirects and global
+	// rate limits do not require a reload. Only locations with the same
+	// settings as the root location of the server are routed dynamically.
+	EnableDynamicLocations bool `json:"enable-dynamic-locations"`
+
 	// GlobalExternalAuth indicates the access to all locations requires
 	// authentication using an external provider
 	// +optional
@@ -831,6 +849,7 @@ func NewDefault() Configuration {
 		VariablesHashBucketSize:          256,
 		VariablesHashMaxSize:             2048,
 		UseHTTP2:                         true,
+		HTTP3AltSvcMaxAge:                86400,
 		ProxyStreamTimeout:               "600s",
 		ProxyStreamNextUpstream:          true,
 		ProxyStreamNextUpstreamTimeout:   "600s",
@@ -918,6 +937,7 @@ type TemplateConfig struct {
 	Cfg                      Configuration
 	IsIPV6Enabled            bool
 	IsSSLPassthroughEnabled  bool
+	IsHTTP3Enabled           bool
 	NginxStatusIpv4Whitelist []string
 	NginxStatusIpv6Whitelist []string
 	RedirectServers          interface{}
@@ -941,6 +961,8 @@ type ListenPorts struct {
 	Health   int
 	Default  int
 	SSLProxy int
+	// QUIC is the UDP port used for HTTP/3. Zero means HTTP/3 is disabled
+	QUIC int
 }
 
 // GlobalExternalAuth describe external authentication configuration for the
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
+
+const (
+	// conditionValid reports if the configuration contains errors
+	conditionValid = "Valid"
+	// conditionApplied reports the result of the last sync
+	conditionApplied = "Applied"
+)
+
+// syncConfigurationStatus updates the status of the configuration resource
+// with the errors found in the configuration and the result of the last sync.
+// Only the leader updates the status.
+func (n *NGINXController) syncConfigurationStatus(syncErr error) {
+	if n.cfg.DynamicClient == nil || atomic.LoadInt32(&n.isLeader) == 0 {
+		return
+	}
+
+	obj := n.store.GetConfigurationResource()
+	if obj == nil {
+		return
+	}
+
+	checksum := ""
+	if n.runningConfig != nil {
+		checksum = n.runningConfig.BackendConfigChecksum
+	}
+
+	status := configurationStatus(obj, n.store.GetBackendConfigurationErrors(), checksum, syncErr, time.Now())
+
+	current, _, _ := unstructured.NestedMap(obj.Object, "status")
+	if apiequality.Semantic.DeepEqual(current, status) {
+		return
+	}
+
+	updated := obj.DeepCopy()
+	updated.Object["status"] = status
+
+	_, err := n.cfg.DynamicClient.Resource(store.ConfigurationResource).Namespace(updated.GetNamespace()).
+		UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Warningf("Error updating the status of the configuration resource %v/%v: %v", updated.GetNamespace(), updated.GetName(), err)
+	}
+}
+
+// configurationStatus returns the status of the configuration resource.
+// The values use the types of the unstructured objects to allow the
+// comparison with the current status.
+func configurationStatus(obj *unstructured.Unstructured, configErrors []string, checksum string, syncErr error, now time.Time) map[string]interface{} {
+	status := map[string]interface{}{
+		"observedGeneration": obj.GetGeneration(),
+	}
+
+	if checksum != "" {
+		status["lastAppliedChecksum"] = checksum
+	} else if previous, found, _ := unstructured.NestedString(obj.Object, "status", "lastAppliedChecksum"); found {
+		status["lastAppliedChecksum"] = previous
+	}
+
+	valid := condition(obj, conditionValid, "True", "Valid", "The configuration does not contain errors", now)
+	if len(configErrors) > 0 {
+		errs := make([]interface{}, 0, len(configErrors))
+		for _, e := range configErrors {
+			errs = append(errs, e)
+		}
+
+		status["validationErrors"] = errs
+		valid = condition(obj, conditionValid, "False", "InvalidSettings",
+			fmt.Sprintf("The configuration contains %v errors: %v", len(configErrors), strings.Join(configErrors, "; ")), now)
+	}
+
+	applied := condition(obj, conditionApplied, "True", "ConfigurationApplied", "The configuration was applied", now)
+	if syncErr != nil {
+		applied = condition(obj, conditionApplied, "False", "ReloadFailed", syncErr.Error(), now)
+	}
+
+	status["conditions"] = []interface{}{valid, applied}
+
+	return status
+}
+
+// condition returns a condition of the status. The last transition time is
+// preserved if the current condition of the same type has the same status.
+func condition(obj *unstructured.Unstructured, conditionType, status, reason, message string, now time.Time) map[string]interface{} {
+	transition := now.UTC().Format(time.RFC3339)
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		current, ok := c.(map[string]interface{})
+		if !ok || current["type"] != conditionType {
+			continue
+		}
+
+		if current["status"] == status {
+			if t, ok := current["lastTransitionTime"].(string); ok {
+				transition = t
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"type":               conditionType,
+		"status":             status,
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": transition,
+	}
+}
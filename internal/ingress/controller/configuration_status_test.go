@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConfigurationStatus(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetGeneration(2)
+
+	t1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := configurationStatus(obj, nil, "123", nil, t1)
+
+	if status["observedGeneration"] != int64(2) {
+		t.Errorf("expected observedGeneration 2 but returned %v", status["observedGeneration"])
+	}
+
+	if status["lastAppliedChecksum"] != "123" {
+		t.Errorf("expected lastAppliedChecksum 123 but returned %v", status["lastAppliedChecksum"])
+	}
+
+	if _, ok := status["validationErrors"]; ok {
+		t.Errorf("unexpected validation errors: %v", status["validationErrors"])
+	}
+
+	obj.Object["status"] = status
+
+	// the status of the Applied condition changes, the Valid condition keeps the transition time
+	t2 := t1.Add(time.Hour)
+	status = configurationStatus(obj, []string{"gzip-level: invalid"}, "", fmt.Errorf("reload failed"), t2)
+
+	if status["lastAppliedChecksum"] != "123" {
+		t.Errorf("expected the previous lastAppliedChecksum but returned %v", status["lastAppliedChecksum"])
+	}
+
+	errs, ok := status["validationErrors"].([]interface{})
+	if !ok || len(errs) != 1 {
+		t.Errorf("expected 1 validation error but returned %v", status["validationErrors"])
+	}
+
+	conditions := status["conditions"].([]interface{})
+	valid := conditions[0].(map[string]interface{})
+	applied := conditions[1].(map[string]interface{})
+
+	if valid["status"] != "False" || valid["lastTransitionTime"] != t2.Format(time.RFC3339) {
+		t.Errorf("unexpected Valid condition: %v", valid)
+	}
+
+	if applied["status"] != "False" || applied["reason"] != "ReloadFailed" {
+		t.Errorf("unexpected Applied condition: %v", applied)
+	}
+
+	obj.Object["status"] = status
+
+	t3 := t2.Add(time.Hour)
+	status = configurationStatus(obj, []string{"gzip-level: invalid"}, "", fmt.Errorf("reload failed"), t3)
+
+	conditions = status["conditions"].([]interface{})
+	valid = conditions[0].(map[string]interface{})
+	if valid["lastTransitionTime"] != t2.Format(time.RFC3339) {
+		t.Errorf("expected the transition time to be preserved but returned %v", valid["lastTransitionTime"])
+	}
+}
@@ -21,27 +21,34 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mitchellh/hashstructure"
 	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+	"k8s.io/ingress-nginx/internal/ingress/status"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/internal/task"
 	"k8s.io/klog/v2"
 )
 
@@ -60,11 +67,18 @@ type Configuration struct {
 
 	Client clientset.Interface
 
+	// DynamicClient is only defined if ConfigurationResource is used
+	DynamicClient dynamic.Interface
+
 	ResyncPeriod time.Duration
 
 	ConfigMapName  string
 	DefaultService string
 
+	// ConfigurationResource contains the namespace/name of the NGINXConfiguration
+	// resource. The settings of the resource take precedence over the ConfigMap.
+	ConfigurationResource string
+
 	Namespace string
 
 	// +optional
@@ -78,6 +92,15 @@ type Configuration struct {
 	PublishService       string
 	PublishStatusAddress string
 
+	// PublishAddressSets contains named address sets selected by IngressClasses and Ingresses
+	PublishAddressSets map[string]status.AddressSet
+	// PublishClassAddressSets contains the address set of each IngressClass
+	PublishClassAddressSets map[string]string
+	// PublishIPFamily limits the IP addresses published to a family
+	PublishIPFamily apiv1.IPFamily
+	// PublishPorts adds the ports to the addresses published in the status
+	PublishPorts bool
+
 	UpdateStatus           bool
 	UseNodeInternalIP      bool
 	ElectionID             string
@@ -96,23 +119,48 @@ type Configuration struct {
 
 	SyncRateLimit float32
 
+	MinReloadInterval time.Duration
+	MaxReloadDelay    time.Duration
+
+	MaxOldWorkerGenerations int
+
+	// Shard defines the hosts rendered by the ingress controller
+	// when the hosts are split across groups of ingress controllers
+	Shard shard.Shard
+	// IngressSelector is a label selector to filter the Ingresses
+	IngressSelector string
+
 	DisableCatchAll bool
 
 	ValidationWebhook         string
 	ValidationWebhookCertPath string
 	ValidationWebhookKeyPath  string
 
-	GlobalExternalAuth  *ngx_config.GlobalExternalAuth
-	MaxmindEditionFiles []string
+	StrictAnnotationValidation bool
+	StrictConfigMapValidation  bool
+
+	GlobalExternalAuth    *ngx_config.GlobalExternalAuth
+	MaxmindEditionFiles   []string
+	MaxmindUpdateInterval time.Duration
 
 	MonitorMaxBatchSize int
 
-	ShutdownGracePeriod int
+	ShutdownGracePeriod      int
+	ShutdownEndpointsTimeout time.Duration
+	ShutdownDrainTimeout     time.Duration
+	ShutdownDrainConnections int
+
+	EnableIncrementalSync bool
+
+	EnableExternalNameResolution bool
+	ExternalNamePreferIPv6       bool
 }
 
 // GetPublishService returns the Service used to set the load-balancer status of Ingresses.
+// The first Service is used when several Services are published.
 func (n NGINXController) GetPublishService() *apiv1.Service {
-	s, err := n.store.GetService(n.cfg.PublishService)
+	service := strings.TrimSpace(strings.Split(n.cfg.PublishService, ",")[0])
+	s, err := n.store.GetService(service)
 	if err != nil {
 		return nil
 	}
@@ -130,13 +178,34 @@ func (n *NGINXController) syncIngress(interface{}) error {
 		return nil
 	}
 
+	n.syncLock.Lock()
+	defer n.syncLock.Unlock()
+
 	ings := n.store.ListIngresses()
-	hosts, servers, pcfg := n.getConfiguration(ings)
+	hosts, servers, pcfg := n.computeConfiguration(ings)
+
+	if !n.runningConfig.Equal(pcfg) && !n.IsDynamicConfigurationEnough(pcfg) && n.tooManyOldWorkers() {
+		// only the reloads wait for the old workers. The sync lock is released
+		// while waiting so the dynamic changes are applied in the meantime, and
+		// the configuration is computed again once the workers are gone
+		n.syncLock.Unlock()
+		n.waitForOldWorkers()
+		n.syncLock.Lock()
+
+		ings = n.store.ListIngresses()
+		hosts, servers, pcfg = n.computeConfiguration(ings)
+	}
+
+	n.pruneBackendConflicts(ings)
 
 	n.metricCollector.SetSSLExpireTime(servers)
+	n.metricCollector.SetAnnotationErrors(ings)
+	n.metricCollector.SetConfigMapErrors(len(n.store.GetBackendConfigurationErrors()))
 
 	if n.runningConfig.Equal(pcfg) {
 		klog.V(3).Infof("No configuration change detected, skipping backend reload")
+		n.syncConfigurationStatus(nil)
+		n.syncIngressConditions(ings, pcfg, nil)
 		return nil
 	}
 
@@ -157,6 +226,8 @@ func (n *NGINXController) syncIngress(interface{}) error {
 			n.metricCollector.ConfigSuccess(hash, false)
 			klog.Errorf("Unexpected failure reloading the backend:\n%v", err)
 			n.recorder.Eventf(k8s.IngressPodDetails, apiv1.EventTypeWarning, "RELOAD", fmt.Sprintf("Error reloading NGINX: %v", err))
+			n.syncConfigurationStatus(err)
+			n.syncIngressConditions(ings, pcfg, err)
 			return err
 		}
 
@@ -192,20 +263,88 @@ func (n *NGINXController) syncIngress(interface{}) error {
 		klog.Warningf("Dynamic reconfiguration failed: %v", err)
 		return false, err
 	})
+	n.health.setDynamicConfiguration(err)
 	if err != nil {
 		klog.Errorf("Unexpected failure reconfiguring NGINX:\n%v", err)
+		n.syncIngressConditions(ings, pcfg, err)
+		return err
+	}
+
+	ri := getRemovedIngresses(n.runningConfig, pcfg)
+	re := getRemovedHosts(n.runningConfig, pcfg)
+	n.metricCollector.RemoveMetrics(ri, re)
+
+	n.runningConfig = pcfg
+	n.health.setInitialSync()
+	n.syncConfigurationStatus(nil)
+	n.syncIngressConditions(ings, pcfg, nil)
+
+	return nil
+}
+
+// syncDynamic applies the changes that do not require a reload of NGINX,
+// like endpoints and certificates, without waiting for the reload queue.
+// Changes that require a reload are delegated to the reload queue.
+func (n *NGINXController) syncDynamic(interface{}) error {
+	if n.dynamicQueue.IsShuttingDown() {
+		return nil
+	}
+
+	n.syncLock.Lock()
+	defer n.syncLock.Unlock()
+
+	ings := n.store.ListIngresses()
+	_, servers, pcfg := n.computeConfiguration(ings)
+	if n.runningConfig.Equal(pcfg) {
+		klog.V(3).Infof("No configuration change detected, skipping dynamic reconfiguration")
+		return nil
+	}
+
+	if !n.IsDynamicConfigurationEnough(pcfg) {
+		klog.V(3).Infof("Configuration changes require a backend reload, delegating to the reload queue")
+		n.syncQueue.EnqueueSkippableTask(task.GetDummyObject("dynamic-change"))
+		return nil
+	}
+
+	err := n.configureDynamically(pcfg)
+	n.health.setDynamicConfiguration(err)
+	if err != nil {
+		klog.Warningf("Dynamic reconfiguration failed: %v", err)
+		n.syncIngressConditions(ings, pcfg, err)
 		return err
 	}
 
+	klog.V(2).Infof("Dynamic reconfiguration succeeded.")
+	n.metricCollector.SetSSLExpireTime(servers)
+
 	ri := getRemovedIngresses(n.runningConfig, pcfg)
 	re := getRemovedHosts(n.runningConfig, pcfg)
 	n.metricCollector.RemoveMetrics(ri, re)
 
 	n.runningConfig = pcfg
+	n.syncIngressConditions(ings, pcfg, nil)
 
 	return nil
 }
 
+// computeConfiguration returns the configuration of the backend for the
+// given Ingresses, using the incremental cache when it is enabled.
+func (n *NGINXController) computeConfiguration(ings []*ingress.Ingress) (sets.String, []*ingress.Server, *ingress.Configuration) {
+	var hosts sets.String
+	var servers []*ingress.Server
+	var pcfg *ingress.Configuration
+	if n.configCache != nil {
+		upstreams, backendServers := n.configCache.getBackendServers(n, ings)
+		hosts, servers, pcfg = n.newConfiguration(upstreams, backendServers)
+	} else {
+		hosts, servers, pcfg = n.getConfiguration(ings)
+	}
+
+	n.resolveExternalNames(pcfg)
+	n.loadErrorPages(pcfg)
+	return hosts, servers, pcfg
+}
+
 // CheckIngress returns an error in case the provided ingress, when added
 // to the current configuration, generates an invalid configuration
 func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
@@ -236,6 +375,17 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		}
 	}
 
+	unknown, errs := annotations.ValidateAnnotations(ing.GetAnnotations())
+	if len(unknown) > 0 {
+		klog.Warningf("Ingress %v/%v contains annotations not supported by the ingress controller: %v", ing.Namespace, ing.Name, strings.Join(unknown, ", "))
+	}
+
+	// without strict validation the invalid values are logged with the errors of the parsers
+	if len(errs) > 0 && n.cfg.StrictAnnotationValidation {
+		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
+		return utilerrors.NewAggregate(errs)
+	}
+
 	k8s.SetDefaultNGINXPathType(ing)
 
 	cfg := n.store.GetBackendConfiguration()
@@ -255,10 +405,20 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		return toCheck.ObjectMeta.Namespace == ing.ObjectMeta.Namespace &&
 			toCheck.ObjectMeta.Name == ing.ObjectMeta.Name
 	}
+	parsed, parseErr := annotations.NewAnnotationExtractor(n.store).Extract(ing)
+	if parseErr != nil {
+		if n.cfg.StrictAnnotationValidation {
+			n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
+			return parseErr
+		}
+
+		klog.Warningf("Ingress %v/%v contains invalid annotations: %v", ing.Namespace, ing.Name, parseErr)
+	}
+
 	ings := store.FilterIngresses(allIngresses, filter)
 	ings = append(ings, &ingress.Ingress{
 		Ingress:           *ing,
-		ParsedAnnotations: annotations.NewAnnotationExtractor(n.store).Extract(ing),
+		ParsedAnnotations: parsed,
 	})
 
 	_, servers, pcfg := n.getConfiguration(ings)
@@ -315,6 +475,10 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 		nginx.StreamPort,
 	}
 
+	if proto == apiv1.ProtocolUDP && n.cfg.ListenPorts.QUIC > 0 {
+		rp = append(rp, n.cfg.ListenPorts.QUIC)
+	}
+
 	reservedPorts := sets.NewInt(rp...)
 	// svcRef format: <(str)namespace>/<(str)service>:<(intstr)port>[:<("PROXY")decode>:<("PROXY")encode>]
 	for port, svcRef := range configmap.Data {
@@ -442,10 +606,14 @@ func (n *NGINXController) getDefaultUpstream() *ingress.Backend {
 // getConfiguration returns the configuration matching the standard kubernetes ingress
 func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.String, []*ingress.Server, *ingress.Configuration) {
 	upstreams, servers := n.getBackendServers(ingresses)
-	var passUpstreams []*ingress.SSLPassthroughBackend
+	updateServersLocations(servers)
 
-	hosts := sets.NewString()
+	return n.newConfiguration(upstreams, servers)
+}
 
+// updateServersLocations updates the locations of the servers
+// using the rules described in updateServerLocations
+func updateServersLocations(servers []*ingress.Server) {
 	for _, server := range servers {
 		// If a location is defined by a prefix string that ends with the slash character, and requests are processed by one of
 		// proxy_pass, fastcgi_pass, uwsgi_pass, scgi_pass, memcached_pass, or grpc_pass, then the special processing is performed.
@@ -460,7 +628,19 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 		//     proxy_pass http://login.example.com;
 		// }
 		server.Locations = updateServerLocations(server.Locations)
+	}
+}
+
+// newConfiguration returns the configuration containing the
+// upstreams and servers obtained from the Ingress rules
+func (n *NGINXController) newConfiguration(upstreams []*ingress.Backend, servers []*ingress.Server) (sets.String, []*ingress.Server, *ingress.Configuration) {
+	upstreams, servers = filterShard(n.cfg.Shard, upstreams, servers)
+
+	var passUpstreams []*ingress.SSLPassthroughBackend
+
+	hosts := sets.NewString()
 
+	for _, server := range servers {
 		if !hosts.Has(server.Hostname) {
 			hosts.Insert(server.Hostname)
 		}
@@ -497,6 +677,8 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 		UDPEndpoints:          n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
 		PassthroughBackends:   passUpstreams,
 		BackendConfigChecksum: n.store.GetBackendConfiguration().Checksum,
+		DynamicLocations:      n.store.GetBackendConfiguration().EnableDynamicLocations,
+		GeoIPChecksum:         n.geoIPChecksum,
 		DefaultSSLCertificate: n.getDefaultSSLCertificate(),
 	}
 }
@@ -506,8 +688,9 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 // service name and port are the same.
 func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*ingress.Backend, []*ingress.Server) {
 	du := n.getDefaultUpstream()
-	upstreams := n.createUpstreams(ingresses, du)
-	servers := n.createServers(ingresses, upstreams, du)
+	backendAnns := backendAnnotationsCache{}
+	upstreams := n.createUpstreams(ingresses, du, backendAnns)
+	servers := n.createServers(ingresses, upstreams, du, backendAnns)
 
 	var canaryIngresses []*ingress.Ingress
 
@@ -567,6 +750,7 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 
 			for _, path := range rule.HTTP.Paths {
 				upsName := upstreamName(ing.Namespace, path.Backend.ServiceName, path.Backend.ServicePort)
+				locAnns := n.backendAnnotations(backendAnns, ing, path.Backend.ServiceName)
 
 				ups := upstreams[upsName]
 
@@ -609,7 +793,7 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 					loc.Service = ups.Service
 					loc.Ingress = ing
 
-					locationApplyAnnotations(loc, anns)
+					locationApplyAnnotations(loc, locAnns)
 
 					if loc.Redirect.FromToWWW {
 						server.RedirectFromToWWW = true
@@ -631,7 +815,7 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 						Port:         ups.Port,
 						Ingress:      ing,
 					}
-					locationApplyAnnotations(loc, anns)
+					locationApplyAnnotations(loc, locAnns)
 
 					if loc.Redirect.FromToWWW {
 						server.RedirectFromToWWW = true
@@ -776,17 +960,30 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 
 // createUpstreams creates the NGINX upstreams (Endpoints) for each Service
 // referenced in Ingress rules.
-func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.Backend) map[string]*ingress.Backend {
+func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.Backend, backendAnns backendAnnotationsCache) map[string]*ingress.Backend {
 	upstreams := make(map[string]*ingress.Backend)
 	upstreams[defUpstreamName] = du
 
-	for _, ing := range data {
-		anns := ing.ParsedAnnotations
+	// Ingress (and annotations) used to configure each upstream. Ingresses are
+	// sorted by creation timestamp. The upstream of a path is configured by the
+	// oldest Ingress referencing the Service, while the upstream of a default
+	// backend is configured by the newest one.
+	upstreamOwners := make(map[string]*ingress.Ingress)
+	upstreamAnnotations := make(map[string]*annotations.Ingress)
 
+	for _, ing := range data {
 		var defBackend string
 		if ing.Spec.Backend != nil {
 			defBackend = upstreamName(ing.Namespace, ing.Spec.Backend.ServiceName, ing.Spec.Backend.ServicePort)
 
+			anns := n.backendAnnotations(backendAnns, ing, ing.Spec.Backend.ServiceName)
+			if owner, ok := upstreamOwners[defBackend]; ok && owner != ing {
+				n.reportBackendConflict(owner, ing, defBackend, annotations.BackendConflicts(upstreamAnnotations[defBackend], anns))
+			}
+
+			upstreamOwners[defBackend] = ing
+			upstreamAnnotations[defBackend] = anns
+
 			klog.V(3).Infof("Creating upstream %q", defBackend)
 			upstreams[defBackend] = newUpstream(defBackend)
 
@@ -845,11 +1042,19 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 
 			for _, path := range rule.HTTP.Paths {
 				name := upstreamName(ing.Namespace, path.Backend.ServiceName, path.Backend.ServicePort)
+				anns := n.backendAnnotations(backendAnns, ing, path.Backend.ServiceName)
 
 				if _, ok := upstreams[name]; ok {
+					if owner, ok := upstreamOwners[name]; ok && owner != ing {
+						n.reportBackendConflict(ing, owner, name, annotations.BackendConflicts(upstreamAnnotations[name], anns))
+					}
+
 					continue
 				}
 
+				upstreamOwners[name] = ing
+				upstreamAnnotations[name] = anns
+
 				klog.V(3).Infof("Creating upstream %q", name)
 				upstreams[name] = newUpstream(name)
 				upstreams[name].Port = path.Backend.ServicePort
@@ -907,9 +1112,127 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 		}
 	}
 
+	// Services used as mirror targets are processed after the Ingress rules
+	// to avoid overriding the configuration of the upstreams defined there
+	for _, ing := range data {
+		for _, target := range ing.ParsedAnnotations.Mirror.Targets {
+			if target.Service == "" {
+				continue
+			}
+
+			name := upstreamName(ing.Namespace, target.Service, target.ServicePort)
+			if _, ok := upstreams[name]; ok {
+				continue
+			}
+
+			klog.V(3).Infof("Creating upstream %q for mirror target", name)
+			upstreams[name] = newUpstream(name)
+			upstreams[name].Port = target.ServicePort
+			upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
+
+			svcKey := fmt.Sprintf("%v/%v", ing.Namespace, target.Service)
+
+			endp, err := n.serviceEndpoints(svcKey, target.ServicePort.String())
+			if err != nil {
+				klog.Warningf("Error obtaining Endpoints for Service %q: %v", svcKey, err)
+				continue
+			}
+			upstreams[name].Endpoints = endp
+
+			s, err := n.store.GetService(svcKey)
+			if err != nil {
+				klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
+				continue
+			}
+
+			upstreams[name].Service = s
+		}
+	}
+
 	return upstreams
 }
 
+// backendAnnotationsCache contains the annotations returned by
+// backendAnnotations, by Ingress and Service, during the computation
+// of the configuration
+type backendAnnotationsCache map[string]*annotations.Ingress
+
+// backendAnnotations returns the parsed annotations of an Ingress with the
+// backend annotations defined in the Service serviceName applied.
+func (n *NGINXController) backendAnnotations(cache backendAnnotationsCache, ing *ingress.Ingress, serviceName string) *annotations.Ingress {
+	svcKey := fmt.Sprintf("%v/%v", ing.Namespace, serviceName)
+	key := fmt.Sprintf("%v/%v", k8s.MetaNamespaceKey(ing), svcKey)
+	if anns, ok := cache[key]; ok {
+		return anns
+	}
+
+	anns := ing.ParsedAnnotations
+	svc, err := n.store.GetService(svcKey)
+	if err == nil && svc != nil {
+		anns = annotations.NewBackendExtractor(n.store).Extract(&ing.Ingress, ing.ParsedAnnotations, svc)
+	}
+
+	cache[key] = anns
+	return anns
+}
+
+// backendConflicts contains the last conflict reported for each Ingress and
+// upstream, so an Event is only emitted when a conflict appears or changes
+type backendConflicts struct {
+	mu        sync.Mutex
+	conflicts map[string]string
+}
+
+// reportBackendConflict emits an Event in an Ingress that defines backend
+// annotations different from the ones used to configure the upstream.
+func (n *NGINXController) reportBackendConflict(ing, owner *ingress.Ingress, upstream string, conflicts []string) {
+	key := fmt.Sprintf("%v/%v", k8s.MetaNamespaceKey(ing), upstream)
+
+	n.backendConflicts.mu.Lock()
+	defer n.backendConflicts.mu.Unlock()
+
+	if len(conflicts) == 0 {
+		delete(n.backendConflicts.conflicts, key)
+		return
+	}
+
+	msg := fmt.Sprintf("Upstream %q is configured by Ingress %q. Ignoring conflicting annotations: %v",
+		upstream, k8s.MetaNamespaceKey(owner), strings.Join(conflicts, ", "))
+	if n.backendConflicts.conflicts[key] == msg {
+		return
+	}
+
+	if n.backendConflicts.conflicts == nil {
+		n.backendConflicts.conflicts = make(map[string]string)
+	}
+	n.backendConflicts.conflicts[key] = msg
+
+	klog.Warningf("Ingress %q: %v", k8s.MetaNamespaceKey(ing), msg)
+
+	if n.recorder != nil {
+		n.recorder.Eventf(ing, apiv1.EventTypeWarning, "BackendConflict", msg)
+	}
+}
+
+// pruneBackendConflicts removes the conflicts reported for Ingresses that no
+// longer exist, so the conflicts of a recreated Ingress are reported again
+func (n *NGINXController) pruneBackendConflicts(ings []*ingress.Ingress) {
+	keys := sets.NewString()
+	for _, ing := range ings {
+		keys.Insert(k8s.MetaNamespaceKey(ing))
+	}
+
+	n.backendConflicts.mu.Lock()
+	defer n.backendConflicts.mu.Unlock()
+
+	for key := range n.backendConflicts.conflicts {
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) < 2 || !keys.Has(parts[0]+"/"+parts[1]) {
+			delete(n.backendConflicts.conflicts, key)
+		}
+	}
+}
+
 // getServiceClusterEndpoint returns an Endpoint corresponding to the ClusterIP
 // field of a Service.
 func (n *NGINXController) getServiceClusterEndpoint(svcKey string, backend *networking.IngressBackend) (endpoint ingress.Endpoint, err error) {
@@ -1008,7 +1331,8 @@ func (n *NGINXController) getDefaultSSLCertificate() *ingress.SSLCert {
 // one root location, which uses a default backend if left unspecified.
 func (n *NGINXController) createServers(data []*ingress.Ingress,
 	upstreams map[string]*ingress.Backend,
-	du *ingress.Backend) map[string]*ingress.Server {
+	du *ingress.Backend,
+	backendAnns backendAnnotationsCache) map[string]*ingress.Server {
 
 	servers := make(map[string]*ingress.Server, len(data))
 	allAliases := make(map[string][]string, len(data))
@@ -1087,7 +1411,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 					// TODO: Redirect and rewrite can affect the catch all behavior, skip for now
 					originalRedirect := defLoc.Redirect
 					originalRewrite := defLoc.Rewrite
-					locationApplyAnnotations(defLoc, anns)
+					locationApplyAnnotations(defLoc, n.backendAnnotations(backendAnns, ing, ing.Spec.Backend.ServiceName))
 					defLoc.Redirect = originalRedirect
 					defLoc.Rewrite = originalRewrite
 				} else {
@@ -1260,7 +1584,7 @@ func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress)
 	loc.BasicDigestAuth = anns.BasicDigestAuth
 	loc.ClientBodyBufferSize = anns.ClientBodyBufferSize
 	loc.ConfigurationSnippet = anns.ConfigurationSnippet
-	loc.CorsConfig = anns.CorsConfig
+	loc.CorsConfig = anns.CorsConfig.ForPath(loc.Path)
 	loc.ExternalAuth = anns.ExternalAuth
 	loc.EnableGlobalAuth = anns.EnableGlobalAuth
 	loc.HTTP2PushPreload = anns.HTTP2PushPreload
@@ -1283,13 +1607,34 @@ func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress)
 	loc.BackendProtocol = anns.BackendProtocol
 	loc.FastCGI = anns.FastCGI
 	loc.CustomHTTPErrors = anns.CustomHTTPErrors
+	loc.CustomErrorPages = anns.CustomErrorPages
 	loc.ModSecurity = anns.ModSecurity
 	loc.Satisfy = anns.Satisfy
 	loc.Mirror = anns.Mirror
+	loc.Mirror.Targets = mirrorTargets(anns)
 
 	loc.DefaultBackendUpstreamName = defUpstreamName
 }
 
+// mirrorTargets returns a copy of the mirror targets of an Ingress with
+// the name of the upstream of the targets that reference a Service
+func mirrorTargets(anns *annotations.Ingress) []mirror.Target {
+	if len(anns.Mirror.Targets) == 0 {
+		return nil
+	}
+
+	targets := make([]mirror.Target, 0, len(anns.Mirror.Targets))
+	for _, target := range anns.Mirror.Targets {
+		if target.Service != "" {
+			target.UpstreamName = upstreamName(anns.Namespace, target.Service, target.ServicePort)
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
 // OK to merge canary ingresses iff there exists one or more ingresses to potentially merge into
 func nonCanaryIngressExists(ingresses []*ingress.Ingress, canaryIngresses []*ingress.Ingress) bool {
 	return len(ingresses)-len(canaryIngresses) > 0
@@ -35,8 +35,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
@@ -62,6 +64,14 @@ func (fakeIngressStore) GetBackendConfiguration() ngx_config.Configuration {
 	return ngx_config.Configuration{}
 }
 
+func (fakeIngressStore) GetBackendConfigurationErrors() []string {
+	return nil
+}
+
+func (fakeIngressStore) GetConfigurationResource() *unstructured.Unstructured {
+	return nil
+}
+
 func (fakeIngressStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
 	return nil, fmt.Errorf("test error")
 }
@@ -74,6 +84,10 @@ func (fakeIngressStore) GetService(key string) (*corev1.Service, error) {
 	return nil, fmt.Errorf("test error")
 }
 
+func (fakeIngressStore) GetNamespace(name string) (*corev1.Namespace, error) {
+	return nil, fmt.Errorf("test error")
+}
+
 func (fakeIngressStore) GetServiceEndpoints(key string) (*corev1.Endpoints, error) {
 	return nil, fmt.Errorf("test error")
 }
@@ -244,6 +258,25 @@ func TestCheckIngress(t *testing.T) {
 			}
 		})
 
+		t.Run("When an annotation contains a value invalid for its type", func(t *testing.T) {
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				err:      nil,
+				expected: "_,test.example.com",
+			}
+			ing.ObjectMeta.Annotations[parser.GetAnnotationWithPrefix("proxy-read-timeout")] = "30s"
+			if err := nginx.CheckIngress(ing); err != nil {
+				t.Errorf("with an invalid annotation value, no error should be returned: %v", err)
+			}
+
+			nginx.cfg.StrictAnnotationValidation = true
+			if nginx.CheckIngress(ing) == nil {
+				t.Errorf("with an invalid annotation value and strict validation, an error should be returned")
+			}
+			nginx.cfg.StrictAnnotationValidation = false
+			delete(ing.ObjectMeta.Annotations, parser.GetAnnotationWithPrefix("proxy-read-timeout"))
+		})
+
 		t.Run("When the default annotation prefix is used despite an override", func(t *testing.T) {
 			parser.AnnotationsPrefix = "ingress.kubernetes.io"
 			ing.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "GRPC"
@@ -1671,6 +1704,57 @@ func TestGetBackendServers(t *testing.T) {
 	}
 }
 
+func TestDefaultBackendUpstreamOwner(t *testing.T) {
+	newIngress := func(name, loadBalancing string) *ingress.Ingress {
+		return &ingress.Ingress{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "example",
+				},
+				Spec: networking.IngressSpec{
+					Backend: &networking.IngressBackend{
+						ServiceName: "http-svc",
+						ServicePort: intstr.FromInt(80),
+					},
+				},
+			},
+			ParsedAnnotations: &annotations.Ingress{
+				LoadBalancing: loadBalancing,
+			},
+		}
+	}
+
+	nginxController := newDynamicNginxController(t, testConfigMap)
+	recorder := record.NewFakeRecorder(10)
+	nginxController.recorder = recorder
+
+	ingresses := []*ingress.Ingress{newIngress("first", "round_robin"), newIngress("second", "ewma")}
+	upstreams := nginxController.createUpstreams(ingresses, nginxController.getDefaultUpstream(), backendAnnotationsCache{})
+
+	// the newest Ingress defining the default backend configures the upstream
+	if lb := upstreams["example-http-svc-80"].LoadBalancing; lb != "ewma" {
+		t.Errorf("expected the load balancing of the last Ingress but %v returned", lb)
+	}
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one conflict event but %v returned", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	// the conflict is only reported again when it changes
+	nginxController.createUpstreams(ingresses, nginxController.getDefaultUpstream(), backendAnnotationsCache{})
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no event for a conflict already reported but %v returned", len(recorder.Events))
+	}
+
+	ingresses[1].ParsedAnnotations.ServiceUpstream = true
+	nginxController.createUpstreams(ingresses, nginxController.getDefaultUpstream(), backendAnnotationsCache{})
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected one event for a conflict that changed but %v returned", len(recorder.Events))
+	}
+}
+
 func testConfigMap(ns string) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1680,7 +1764,7 @@ func testConfigMap(ns string) *v1.ConfigMap {
 	}
 }
 
-func newNGINXController(t *testing.T) *NGINXController {
+func newNGINXController(t testing.TB) *NGINXController {
 	ns := v1.NamespaceDefault
 
 	clientSet := fake.NewSimpleClientset()
@@ -1716,7 +1800,11 @@ func newNGINXController(t *testing.T) *NGINXController {
 		10*time.Minute,
 		clientSet,
 		channels.NewRingChannel(10),
-		false)
+		false,
+		false,
+		"",
+		nil,
+		"")
 
 	sslCert := ssl.GetFakeSSLCert()
 	config := &Configuration{
@@ -1727,9 +1815,10 @@ func newNGINXController(t *testing.T) *NGINXController {
 	}
 
 	return &NGINXController{
-		store:   storer,
-		cfg:     config,
-		command: NewNginxCommand(),
+		store:            storer,
+		cfg:              config,
+		command:          NewNginxCommand(),
+		backendConflicts: &backendConflicts{},
 	}
 }
 
@@ -1774,7 +1863,11 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *v1.Confi
 		10*time.Minute,
 		clientSet,
 		channels.NewRingChannel(10),
-		false)
+		false,
+		false,
+		"",
+		nil,
+		"")
 
 	sslCert := ssl.GetFakeSSLCert()
 	config := &Configuration{
@@ -1785,8 +1878,9 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *v1.Confi
 	}
 
 	return &NGINXController{
-		store:   storer,
-		cfg:     config,
-		command: NewNginxCommand(),
+		store:            storer,
+		cfg:              config,
+		command:          NewNginxCommand(),
+		backendConflicts: &backendConflicts{},
 	}
 }
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// errorPageDefault is the name of the pages served for the status codes without pages
+const errorPageDefault = "default"
+
+// errorPageContentTypes contains the content type of the pages by extension
+var errorPageContentTypes = map[string]string{
+	"html": "text/html",
+	"json": "application/json",
+	"txt":  "text/plain",
+	"xml":  "application/xml",
+}
+
+// errorPageVariable delimits the names of the variables in the output of
+// the templates of the error pages, replaced by Lua when a page is served
+const errorPageVariable = "\x00"
+
+// errorPageData contains the fields available in the templates of the error pages
+type errorPageData struct {
+	StatusCode string
+	RequestID  string
+}
+
+var errorPageVariables = errorPageData{
+	StatusCode: errorPageVariable + "status" + errorPageVariable,
+	RequestID:  errorPageVariable + "request_id" + errorPageVariable,
+}
+
+// parseErrorPages parses the error pages of a ConfigMap. Each key is the
+// status code, or "default", followed by the extension of the content type
+// of the page, like 404.html or default.json. The pages are templates with
+// the fields StatusCode and RequestID. Invalid pages are skipped and
+// returned as an error.
+func parseErrorPages(data map[string]string) (ingress.ErrorPages, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	pages := ingress.ErrorPages{}
+	for _, key := range keys {
+		code, page, err := parseErrorPage(key, data[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", key, err))
+			continue
+		}
+
+		pages[code] = append(pages[code], *page)
+	}
+
+	// pages are sorted by extension in the keys, with the HTML page first
+	// because it is served when the client accepts any content type
+	for _, codePages := range pages {
+		sort.SliceStable(codePages, func(i, j int) bool {
+			return codePages[i].ContentType == "text/html" && codePages[j].ContentType != "text/html"
+		})
+	}
+
+	return pages, utilerrors.NewAggregate(errs)
+}
+
+func parseErrorPage(key, value string) (string, *ingress.ErrorPage, error) {
+	dot := strings.LastIndex(key, ".")
+	if dot == -1 {
+		return "", nil, fmt.Errorf("expected a key like <status code>.<extension>")
+	}
+
+	code, extension := key[:dot], key[dot+1:]
+	if code != errorPageDefault {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 400 || status > 599 {
+			return "", nil, fmt.Errorf("%v is not a valid error status code", code)
+		}
+	}
+
+	contentType, ok := errorPageContentTypes[extension]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported extension %v", extension)
+	}
+
+	if strings.Contains(value, errorPageVariable) {
+		return "", nil, fmt.Errorf("the page contains a NUL character")
+	}
+
+	tmpl, err := template.New(key).Parse(value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, errorPageVariables); err != nil {
+		return "", nil, err
+	}
+
+	return code, &ingress.ErrorPage{
+		ContentType: contentType,
+		Body:        strings.Split(body.String(), errorPageVariable),
+	}, nil
+}
+
+// loadErrorPages adds to the configuration the custom error pages of the
+// global ConfigMap and the ConfigMaps referenced by the locations
+func (n *NGINXController) loadErrorPages(pcfg *ingress.Configuration) {
+	names := sets.NewString()
+	if name := n.store.GetBackendConfiguration().CustomErrorPages; name != "" {
+		names.Insert(name)
+	}
+
+	for _, server := range pcfg.Servers {
+		for _, location := range server.Locations {
+			if location.CustomErrorPages != "" {
+				names.Insert(location.CustomErrorPages)
+			}
+		}
+	}
+
+	if names.Len() == 0 {
+		return
+	}
+
+	pcfg.ErrorPages = make(map[string]ingress.ErrorPages, names.Len())
+	for _, name := range names.List() {
+		cm, err := n.store.GetConfigMap(name)
+		if err != nil {
+			klog.Warningf("Error reading ConfigMap %v with custom error pages: %v", name, err)
+			continue
+		}
+
+		pages, err := parseErrorPages(cm.Data)
+		if err != nil {
+			klog.Warningf("Invalid custom error pages in ConfigMap %v: %v", name, err)
+		}
+
+		pcfg.ErrorPages[name] = pages
+	}
+}
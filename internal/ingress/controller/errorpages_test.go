@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func TestParseErrorPages(t *testing.T) {
+	pages, err := parseErrorPages(map[string]string{
+		"404.json":     `{"code": {{ .StatusCode }}, "requestID": "{{ .RequestID }}"}`,
+		"404.html":     `<h1>Not found</h1>`,
+		"default.txt":  `error {{ .StatusCode }}`,
+		"200.html":     `OK`,
+		"503.pdf":      `unsupported`,
+		"500.html":     `{{ .Unknown }}`,
+		"maintenance":  `no extension`,
+		"502.txt":      `{{ if .StatusCode }}`,
+		"default.html": `{{ .StatusCode }}{{ .RequestID }}`,
+	})
+
+	expected := ingress.ErrorPages{
+		"404": {
+			{ContentType: "text/html", Body: []string{"<h1>Not found</h1>"}},
+			{ContentType: "application/json", Body: []string{`{"code": `, "status", `, "requestID": "`, "request_id", `"}`}},
+		},
+		"default": {
+			{ContentType: "text/html", Body: []string{"", "status", "", "request_id", ""}},
+			{ContentType: "text/plain", Body: []string{"error ", "status", ""}},
+		},
+	}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected the pages %v but returned %v", expected, pages)
+	}
+
+	if err == nil {
+		t.Fatalf("expected an error parsing invalid pages")
+	}
+
+	for _, key := range []string{"200.html", "503.pdf", "500.html", "maintenance", "502.txt"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected an error for the page %v but returned %v", key, err)
+		}
+	}
+}
+
+type fakeErrorPagesStore struct {
+	fakeIngressStore
+	configMaps map[string]*corev1.ConfigMap
+}
+
+func (s fakeErrorPagesStore) GetBackendConfiguration() ngx_config.Configuration {
+	cfg := ngx_config.NewDefault()
+	cfg.CustomErrorPages = "ingress-nginx/error-pages"
+	return cfg
+}
+
+func (s fakeErrorPagesStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
+	cm, ok := s.configMaps[key]
+	if !ok {
+		return nil, fmt.Errorf("configmap %v not found", key)
+	}
+
+	return cm, nil
+}
+
+func TestLoadErrorPages(t *testing.T) {
+	n := &NGINXController{
+		store: fakeErrorPagesStore{
+			configMaps: map[string]*corev1.ConfigMap{
+				"ingress-nginx/error-pages": {Data: map[string]string{"404.html": "global"}},
+				"default/error-pages":       {Data: map[string]string{"404.html": "default"}},
+			},
+		},
+	}
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{{
+			Hostname: "example.com",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{Path: "/app", CustomErrorPages: "default/error-pages"},
+				{Path: "/missing", CustomErrorPages: "default/missing"},
+			},
+		}},
+	}
+
+	n.loadErrorPages(pcfg)
+
+	expected := map[string]ingress.ErrorPages{
+		"ingress-nginx/error-pages": {"404": {{ContentType: "text/html", Body: []string{"global"}}}},
+		"default/error-pages":       {"404": {{ContentType: "text/html", Body: []string{"default"}}}},
+	}
+	if !reflect.DeepEqual(pcfg.ErrorPages, expected) {
+		t.Errorf("expected the error pages %v but returned %v", expected, pcfg.ErrorPages)
+	}
+}
@@ -0,0 +1,387 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/net/dns"
+)
+
+const (
+	// externalNameMinTTL and externalNameMaxTTL limit the time
+	// the addresses of an ExternalName Service are cached
+	externalNameMinTTL = 5 * time.Second
+	externalNameMaxTTL = 1 * time.Hour
+
+	// externalNameRetryInterval is the time waiting after a failed resolution
+	externalNameRetryInterval = 10 * time.Second
+
+	// externalNameRefreshInterval is the interval between checks of the expired names
+	externalNameRefreshInterval = 1 * time.Second
+
+	// externalNameLookupTimeout limits the time resolving a name
+	externalNameLookupTimeout = 30 * time.Second
+
+	// externalNameUnusedTimeout is the time after which a name not used
+	// by any backend in the configuration is removed from the cache
+	externalNameUnusedTimeout = 10 * time.Minute
+)
+
+// externalNameLookuper looks up the addresses of a name
+type externalNameLookuper interface {
+	LookupHost(ctx context.Context, host string) ([]dns.Record, error)
+	LookupSRV(ctx context.Context, service, proto, host string) ([]dns.Record, error)
+}
+
+// externalNameKey identifies a name to resolve. The SRV record
+// _<port>._<proto>.<host> is used when the port is not empty.
+type externalNameKey struct {
+	host  string
+	port  string
+	proto string
+}
+
+type externalNameEntry struct {
+	records []dns.Record
+	// failed is true when the last resolution of the name failed
+	failed   bool
+	expires  time.Time
+	lastUsed time.Time
+	// services contains the Services with the name, as namespace/name
+	services sets.String
+}
+
+// externalNameResolver resolves the names of the ExternalName Services
+// used as backends and caches the addresses during the TTL of the records.
+// The endpoints of a name are returned only after its first resolution,
+// until then the name is resolved by NGINX.
+type externalNameResolver struct {
+	lookuper externalNameLookuper
+
+	lock    sync.Mutex
+	entries map[externalNameKey]*externalNameEntry
+
+	// wakeCh triggers the resolution of the names added to the cache
+	wakeCh chan struct{}
+
+	// onChange is called when the addresses of any name change
+	onChange func()
+	// onError is called for each Service with a name that failed to resolve.
+	// failing is false when the previous resolution of the name succeeded.
+	onError func(service, host string, err error, failing bool)
+
+	now func() time.Time
+}
+
+func newExternalNameResolver(lookuper externalNameLookuper, onChange func(), onError func(string, string, error, bool)) *externalNameResolver {
+	return &externalNameResolver{
+		lookuper: lookuper,
+		entries:  make(map[externalNameKey]*externalNameEntry),
+		wakeCh:   make(chan struct{}, 1),
+		onChange: onChange,
+		onError:  onError,
+		now:      time.Now,
+	}
+}
+
+// endpoints returns the resolved endpoints of a port of an ExternalName
+// Service. It returns false if the name was not resolved yet.
+func (r *externalNameResolver) endpoints(svc *apiv1.Service, port *apiv1.ServicePort) ([]ingress.Endpoint, bool) {
+	key := externalNameKey{host: svc.Spec.ExternalName}
+	if port.Name != "" {
+		key.port = port.Name
+		key.proto = strings.ToLower(string(port.Protocol))
+		if key.proto == "" {
+			key.proto = "tcp"
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &externalNameEntry{services: sets.NewString()}
+		r.entries[key] = entry
+
+		select {
+		case r.wakeCh <- struct{}{}:
+		default:
+		}
+	}
+
+	entry.lastUsed = r.now()
+	entry.services.Insert(k8s.MetaNamespaceKey(svc))
+
+	if len(entry.records) == 0 {
+		return nil, false
+	}
+
+	targetPort := port.TargetPort.IntValue()
+	endpoints := make([]ingress.Endpoint, 0, len(entry.records))
+	for _, record := range entry.records {
+		p := targetPort
+		if record.Port > 0 {
+			p = record.Port
+		}
+
+		endpoints = append(endpoints, ingress.Endpoint{
+			Address: record.IP.String(),
+			Port:    fmt.Sprintf("%v", p),
+		})
+	}
+
+	return endpoints, true
+}
+
+// run resolves the expired names until the stop channel is closed
+func (r *externalNameResolver) run(stopCh chan struct{}) {
+	ticker := time.NewTicker(externalNameRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		case <-r.wakeCh:
+		}
+
+		r.refresh()
+	}
+}
+
+// refresh resolves the expired names and removes the unused names from
+// the cache. The addresses of a name are kept when the resolution fails.
+func (r *externalNameResolver) refresh() {
+	now := r.now()
+
+	var expired []externalNameKey
+	r.lock.Lock()
+	for key, entry := range r.entries {
+		if now.Sub(entry.lastUsed) > externalNameUnusedTimeout {
+			delete(r.entries, key)
+			continue
+		}
+
+		if !now.Before(entry.expires) {
+			expired = append(expired, key)
+		}
+	}
+	r.lock.Unlock()
+
+	changed := false
+	for _, key := range expired {
+		records, err := r.lookup(key)
+
+		r.lock.Lock()
+		entry, ok := r.entries[key]
+		if !ok {
+			r.lock.Unlock()
+			continue
+		}
+
+		failing := entry.failed
+		services := entry.services.List()
+		if err != nil {
+			entry.failed = true
+			entry.expires = now.Add(externalNameRetryInterval)
+		} else {
+			if !equalRecords(entry.records, records) {
+				changed = true
+			}
+
+			entry.records = records
+			entry.failed = false
+			entry.expires = now.Add(recordsTTL(records))
+		}
+		r.lock.Unlock()
+
+		if err != nil {
+			for _, service := range services {
+				r.onError(service, key.host, err, failing)
+			}
+			continue
+		}
+
+		klog.V(3).InfoS("Resolved ExternalName", "name", key.host, "port", key.port, "records", len(records), "services", services)
+	}
+
+	if changed {
+		r.onChange()
+	}
+}
+
+// lookup resolves a name, using the SRV record of the port if it exists
+func (r *externalNameResolver) lookup(key externalNameKey) ([]dns.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), externalNameLookupTimeout)
+	defer cancel()
+
+	if key.port != "" {
+		records, err := r.lookuper.LookupSRV(ctx, key.port, key.proto, key.host)
+		if err == nil || !errors.Is(err, dns.ErrNotFound) {
+			return records, err
+		}
+	}
+
+	return r.lookuper.LookupHost(ctx, key.host)
+}
+
+// recordsTTL returns the lowest TTL of the records, within the limits of the cache.
+// The resolver of the net package does not return the TTL of the records, so
+// the records of dns.Resolver always use dns.DefaultTTL.
+func recordsTTL(records []dns.Record) time.Duration {
+	ttl := externalNameMaxTTL
+	for _, record := range records {
+		if record.TTL < ttl {
+			ttl = record.TTL
+		}
+	}
+
+	if ttl < externalNameMinTTL {
+		ttl = externalNameMinTTL
+	}
+
+	return ttl
+}
+
+// equalRecords returns true if the records contain the same addresses and ports
+func equalRecords(r1, r2 []dns.Record) bool {
+	if len(r1) != len(r2) {
+		return false
+	}
+
+	addresses := func(records []dns.Record) []string {
+		list := make([]string, 0, len(records))
+		for _, record := range records {
+			list = append(list, net.JoinHostPort(record.IP.String(), fmt.Sprintf("%v", record.Port)))
+		}
+
+		sort.Strings(list)
+		return list
+	}
+
+	a1, a2 := addresses(r1), addresses(r2)
+	for i := range a1 {
+		if a1[i] != a2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveExternalNames replaces the endpoints of the backends of ExternalName
+// Services with the addresses resolved by the controller. The backends
+// are copied because they are shared with the configuration cache.
+func (n *NGINXController) resolveExternalNames(pcfg *ingress.Configuration) {
+	if n.externalNames == nil {
+		return
+	}
+
+	backends := make([]*ingress.Backend, len(pcfg.Backends))
+	for i, backend := range pcfg.Backends {
+		backends[i] = backend
+
+		endpoints, ok := n.externalNameEndpoints(backend.Service, backend.Port)
+		if !ok {
+			continue
+		}
+
+		resolved := *backend
+		resolved.Endpoints = endpoints
+		backends[i] = &resolved
+	}
+	pcfg.Backends = backends
+
+	pcfg.TCPEndpoints = n.resolveL4ExternalNames(pcfg.TCPEndpoints)
+	pcfg.UDPEndpoints = n.resolveL4ExternalNames(pcfg.UDPEndpoints)
+}
+
+func (n *NGINXController) resolveL4ExternalNames(services []ingress.L4Service) []ingress.L4Service {
+	if len(services) == 0 {
+		return services
+	}
+
+	resolved := make([]ingress.L4Service, len(services))
+	for i, service := range services {
+		resolved[i] = service
+
+		if endpoints, ok := n.externalNameEndpoints(service.Service, service.Backend.Port); ok {
+			resolved[i].Endpoints = endpoints
+		}
+	}
+
+	return resolved
+}
+
+// externalNameEndpoints returns the resolved endpoints of a port of a Service,
+// or false if the Service is not an ExternalName Service with a resolved name
+func (n *NGINXController) externalNameEndpoints(svc *apiv1.Service, port intstr.IntOrString) ([]ingress.Endpoint, bool) {
+	if svc == nil || svc.Spec.Type != apiv1.ServiceTypeExternalName {
+		return nil, false
+	}
+
+	// IP addresses and invalid names are handled by getEndpoints
+	if net.ParseIP(svc.Spec.ExternalName) != nil || svc.Spec.ExternalName == "localhost" {
+		return nil, false
+	}
+
+	for i, sp := range svc.Spec.Ports {
+		if (port.Type == intstr.String && sp.Name == port.StrVal) ||
+			(port.Type == intstr.Int && sp.Port == port.IntVal) {
+			return n.externalNames.endpoints(svc, &svc.Spec.Ports[i])
+		}
+	}
+
+	return nil, false
+}
+
+// onExternalNameError exports the failed resolution of the name of an ExternalName
+// Service as a metric, and as an event when the name starts failing
+func (n *NGINXController) onExternalNameError(service, host string, err error, failing bool) {
+	klog.Warningf("Error resolving the name %v of ExternalName Service %q: %v", host, service, err)
+
+	ns, name, _ := k8s.ParseNameNS(service)
+	n.metricCollector.IncExternalNameResolutionErrors(ns, name)
+
+	if failing || n.recorder == nil {
+		return
+	}
+
+	svc, serr := n.store.GetService(service)
+	if serr != nil {
+		return
+	}
+
+	n.recorder.Eventf(svc, apiv1.EventTypeWarning, "ExternalNameResolution", "Error resolving %v: %v", host, err)
+}
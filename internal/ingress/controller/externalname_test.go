@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+	"k8s.io/ingress-nginx/internal/net/dns"
+)
+
+type fakeLookuper struct {
+	hosts map[string][]dns.Record
+	srvs  map[string][]dns.Record
+	err   error
+}
+
+func (f *fakeLookuper) LookupHost(ctx context.Context, host string) ([]dns.Record, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	records, ok := f.hosts[host]
+	if !ok {
+		return nil, dns.ErrNotFound
+	}
+
+	return records, nil
+}
+
+func (f *fakeLookuper) LookupSRV(ctx context.Context, service, proto, host string) ([]dns.Record, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	records, ok := f.srvs[fmt.Sprintf("_%v._%v.%v", service, proto, host)]
+	if !ok {
+		return nil, fmt.Errorf("lookup SRV: %w", dns.ErrNotFound)
+	}
+
+	return records, nil
+}
+
+func externalNameService(name, externalName string, ports ...apiv1.ServicePort) *apiv1.Service {
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: apiv1.ServiceSpec{
+			Type:         apiv1.ServiceTypeExternalName,
+			ExternalName: externalName,
+			Ports:        ports,
+		},
+	}
+}
+
+func TestExternalNameResolver(t *testing.T) {
+	lookuper := &fakeLookuper{
+		hosts: map[string][]dns.Record{
+			"www.example.com": {
+				{IP: net.ParseIP("192.0.2.1"), TTL: 60 * time.Second},
+				{IP: net.ParseIP("192.0.2.2"), TTL: 30 * time.Second},
+			},
+		},
+		srvs: map[string][]dns.Record{
+			"_grpc._tcp.www.example.com": {
+				{IP: net.ParseIP("192.0.2.10"), Port: 9090, TTL: 300 * time.Second},
+			},
+		},
+	}
+
+	changes := 0
+	var errors []string
+	r := newExternalNameResolver(lookuper, func() { changes++ }, func(service, host string, err error, failing bool) {
+		errors = append(errors, fmt.Sprintf("%v %v %v", service, host, failing))
+	})
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	httpPort := apiv1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)}
+	grpcPort := apiv1.ServicePort{Name: "grpc", Port: 9000, TargetPort: intstr.FromInt(9000)}
+	svc := externalNameService("external", "www.example.com", httpPort, grpcPort)
+
+	if _, ok := r.endpoints(svc, &httpPort); ok {
+		t.Fatalf("expected no endpoints before the first resolution")
+	}
+	r.endpoints(svc, &grpcPort)
+
+	r.refresh()
+	if changes != 1 {
+		t.Errorf("expected a change after the first resolution but got %v", changes)
+	}
+
+	endpoints, ok := r.endpoints(svc, &httpPort)
+	expected := []ingress.Endpoint{{Address: "192.0.2.1", Port: "8080"}, {Address: "192.0.2.2", Port: "8080"}}
+	if !ok || !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected the endpoints %v but returned %v", expected, endpoints)
+	}
+
+	endpoints, _ = r.endpoints(svc, &grpcPort)
+	expected = []ingress.Endpoint{{Address: "192.0.2.10", Port: "9090"}}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected the endpoints of the SRV record %v but returned %v", expected, endpoints)
+	}
+
+	// the names are resolved again after the lowest TTL
+	lookuper.err = fmt.Errorf("server failure")
+	now = now.Add(30 * time.Second)
+	r.refresh()
+	r.refresh()
+	now = now.Add(externalNameRetryInterval)
+	r.refresh()
+
+	expectedErrors := []string{
+		"default/external www.example.com false",
+		"default/external www.example.com true",
+	}
+	if !reflect.DeepEqual(errors, expectedErrors) {
+		t.Errorf("expected the errors %v but returned %v", expectedErrors, errors)
+	}
+
+	endpoints, _ = r.endpoints(svc, &httpPort)
+	if len(endpoints) != 2 {
+		t.Errorf("expected the previous endpoints after a failed resolution but returned %v", endpoints)
+	}
+
+	lookuper.err = nil
+	lookuper.hosts["www.example.com"] = []dns.Record{{IP: net.ParseIP("192.0.2.3"), TTL: 60 * time.Second}}
+	now = now.Add(externalNameRetryInterval)
+	r.refresh()
+	if changes != 2 {
+		t.Errorf("expected a change after the addresses changed but got %v", changes)
+	}
+
+	// unused names are removed from the cache
+	now = now.Add(externalNameUnusedTimeout + time.Second)
+	r.refresh()
+	if len(r.entries) != 0 {
+		t.Errorf("expected the unused names to be removed but the cache contains %v", r.entries)
+	}
+}
+
+func TestResolveExternalNames(t *testing.T) {
+	httpPort := apiv1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)}
+	svc := externalNameService("external", "www.example.com", httpPort)
+
+	backend := &ingress.Backend{
+		Name:      "default-external-80",
+		Service:   svc,
+		Port:      intstr.FromInt(80),
+		Endpoints: []ingress.Endpoint{{Address: "www.example.com", Port: "8080"}},
+	}
+	pcfg := &ingress.Configuration{
+		Backends: []*ingress.Backend{backend},
+		TCPEndpoints: []ingress.L4Service{{
+			Port:      5000,
+			Backend:   ingress.L4Backend{Name: "external", Namespace: "default", Port: intstr.FromString("http")},
+			Endpoints: []ingress.Endpoint{{Address: "www.example.com", Port: "8080"}},
+			Service:   svc,
+		}},
+	}
+
+	lookuper := &fakeLookuper{
+		hosts: map[string][]dns.Record{
+			"www.example.com": {{IP: net.ParseIP("192.0.2.1"), TTL: 60 * time.Second}},
+		},
+	}
+
+	n := &NGINXController{metricCollector: metric.DummyCollector{}}
+	n.externalNames = newExternalNameResolver(lookuper, func() {}, n.onExternalNameError)
+
+	n.resolveExternalNames(pcfg)
+	if pcfg.Backends[0].Endpoints[0].Address != "www.example.com" {
+		t.Errorf("expected the hostname before the first resolution but returned %v", pcfg.Backends[0].Endpoints)
+	}
+
+	n.externalNames.refresh()
+	n.resolveExternalNames(pcfg)
+
+	expected := []ingress.Endpoint{{Address: "192.0.2.1", Port: "8080"}}
+	if !reflect.DeepEqual(pcfg.Backends[0].Endpoints, expected) {
+		t.Errorf("expected the resolved endpoints %v but returned %v", expected, pcfg.Backends[0].Endpoints)
+	}
+
+	if !reflect.DeepEqual(pcfg.TCPEndpoints[0].Endpoints, expected) {
+		t.Errorf("expected the resolved TCP endpoints %v but returned %v", expected, pcfg.TCPEndpoints[0].Endpoints)
+	}
+
+	if backend.Endpoints[0].Address != "www.example.com" {
+		t.Errorf("expected the original backend to be unchanged but returned %v", backend.Endpoints)
+	}
+}
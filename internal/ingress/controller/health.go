@@ -0,0 +1,256 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
+)
+
+const (
+	healthStatusOK      = "ok"
+	healthStatusFailure = "failure"
+	healthStatusWarning = "warning"
+
+	// healthDialTimeout limits the time checking if a socket is listening
+	healthDialTimeout = 1 * time.Second
+)
+
+// healthState contains the state of the components of the
+// ingress controller not available in NGINX or in the store
+type healthState struct {
+	informersSynced int32
+	initialSync     int32
+
+	lock                 sync.Mutex
+	dynamicConfigErr     error
+	certificateConfigErr error
+}
+
+func newHealthState() *healthState {
+	return &healthState{}
+}
+
+// setInformersSynced marks the informers of the store as synced
+func (h *healthState) setInformersSynced() {
+	atomic.StoreInt32(&h.informersSynced, 1)
+}
+
+// setInitialSync marks the first successful sync of the configuration
+func (h *healthState) setInitialSync() {
+	atomic.StoreInt32(&h.initialSync, 1)
+}
+
+// setDynamicConfiguration records the result of the last dynamic reconfiguration
+func (h *healthState) setDynamicConfiguration(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.dynamicConfigErr = err
+}
+
+// setCertificateConfiguration records the result of the last update of the certificates in NGINX
+func (h *healthState) setCertificateConfiguration(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.certificateConfigErr = err
+}
+
+// healthCheck is a named check of a component of the ingress controller
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+type healthCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResult struct {
+	Status string              `json:"status"`
+	Checks []healthCheckResult `json:"checks"`
+}
+
+// healthHandler runs the checks and returns the result of each
+// check in JSON, with the status code 503 if any check fails.
+// The failures of the details are reported as warnings and do
+// not change the status.
+func healthHandler(checks, details []healthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := healthResult{Status: healthStatusOK}
+		for _, c := range checks {
+			cr := healthCheckResult{Name: c.name, Status: healthStatusOK}
+			if err := c.check(); err != nil {
+				klog.V(2).InfoS("Health check failed", "path", r.URL.Path, "check", c.name, "err", err)
+				cr.Status = healthStatusFailure
+				cr.Error = err.Error()
+				result.Status = healthStatusFailure
+			}
+
+			result.Checks = append(result.Checks, cr)
+		}
+
+		for _, c := range details {
+			cr := healthCheckResult{Name: c.name, Status: healthStatusOK}
+			if err := c.check(); err != nil {
+				cr.Status = healthStatusWarning
+				cr.Error = err.Error()
+			}
+
+			result.Checks = append(result.Checks, cr)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if result.Status != healthStatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			klog.ErrorS(err, "Encoding the result of the health checks")
+		}
+	})
+}
+
+// LivezHandler returns the handler of the liveness endpoint.
+// It only fails when the NGINX master process is not running.
+func (n *NGINXController) LivezHandler() http.Handler {
+	return healthHandler([]healthCheck{
+		{"nginx", checkNGINXProcess},
+	}, nil)
+}
+
+// StartupzHandler returns the handler of the startup endpoint.
+// It fails until the first configuration is applied to NGINX.
+func (n *NGINXController) StartupzHandler() http.Handler {
+	return healthHandler([]healthCheck{
+		{"informers", n.checkInformersSynced},
+		{"nginx", checkNGINXProcess},
+		{"initial-sync", n.checkInitialSync},
+	}, nil)
+}
+
+// ReadyzHandler returns the handler of the readiness endpoint. It fails
+// until the first configuration is applied to NGINX, when a component
+// is not working and during the shutdown of the ingress controller.
+// The errors of the last changes of the configuration are only reported:
+// the same configuration is applied by every replica, and failing would
+// remove all of them from the Service while NGINX keeps serving the last
+// configuration applied.
+func (n *NGINXController) ReadyzHandler() http.Handler {
+	checks := []healthCheck{
+		{"shutdown", n.checkShutdown},
+		{"informers", n.checkInformersSynced},
+		{"nginx", checkNGINXProcess},
+		{"initial-sync", n.checkInitialSync},
+		{"dynamic-configuration", checkDynamicLoadBalancer},
+	}
+
+	if n.cfg.EnableMetrics {
+		checks = append(checks, healthCheck{"metrics-socket", checkMetricsSocket})
+	}
+
+	if n.cfg.ValidationWebhook != "" {
+		checks = append(checks, healthCheck{"admission-webhook", n.checkAdmissionWebhook})
+	}
+
+	return healthHandler(checks, []healthCheck{
+		{"last-dynamic-configuration", n.checkLastDynamicConfiguration},
+		{"certificates", n.checkCertificates},
+	})
+}
+
+func (n *NGINXController) checkShutdown() error {
+	if n.isShuttingDown {
+		return fmt.Errorf("the ingress controller is shutting down")
+	}
+
+	return nil
+}
+
+func (n *NGINXController) checkInformersSynced() error {
+	if atomic.LoadInt32(&n.health.informersSynced) == 0 {
+		return fmt.Errorf("waiting for the informers to sync")
+	}
+
+	return nil
+}
+
+func (n *NGINXController) checkInitialSync() error {
+	if atomic.LoadInt32(&n.health.initialSync) == 0 {
+		return fmt.Errorf("waiting for the first configuration of NGINX")
+	}
+
+	return nil
+}
+
+func (n *NGINXController) checkLastDynamicConfiguration() error {
+	n.health.lock.Lock()
+	defer n.health.lock.Unlock()
+
+	if n.health.dynamicConfigErr != nil {
+		return fmt.Errorf("last dynamic reconfiguration failed: %v", n.health.dynamicConfigErr)
+	}
+
+	return nil
+}
+
+func (n *NGINXController) checkCertificates() error {
+	n.health.lock.Lock()
+	defer n.health.lock.Unlock()
+
+	if n.health.certificateConfigErr != nil {
+		return fmt.Errorf("last update of the certificates failed: %v", n.health.certificateConfigErr)
+	}
+
+	return nil
+}
+
+func (n *NGINXController) checkAdmissionWebhook() error {
+	// the TLS handshake checks the certificate of the webhook is loaded
+	dialer := &net.Dialer{Timeout: healthDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", n.cfg.ValidationWebhook, &tls.Config{
+		InsecureSkipVerify: true, // #nosec
+	})
+	if err != nil {
+		return fmt.Errorf("admission webhook not listening: %v", err)
+	}
+
+	return conn.Close()
+}
+
+func checkMetricsSocket() error {
+	conn, err := net.DialTimeout("unix", collectors.SocketPath, healthDialTimeout)
+	if err != nil {
+		return fmt.Errorf("metrics socket not listening: %v", err)
+	}
+
+	return conn.Close()
+}
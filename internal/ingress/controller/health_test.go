@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	n := &NGINXController{
+		cfg:    &Configuration{},
+		health: newHealthState(),
+	}
+
+	handler := healthHandler([]healthCheck{
+		{"informers", n.checkInformersSynced},
+		{"initial-sync", n.checkInitialSync},
+	}, []healthCheck{
+		{"certificates", n.checkCertificates},
+	})
+
+	call := func() (int, healthResult) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var result healthResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("unexpected error decoding %v: %v", w.Body.String(), err)
+		}
+
+		return w.Code, result
+	}
+
+	code, result := call()
+	if code != http.StatusServiceUnavailable || result.Status != healthStatusFailure {
+		t.Errorf("expected a failure before the initial sync but returned %v %v", code, result)
+	}
+
+	n.health.setInformersSynced()
+	n.health.setCertificateConfiguration(fmt.Errorf("invalid certificate"))
+
+	code, result = call()
+	expected := []healthCheckResult{
+		{Name: "informers", Status: healthStatusOK},
+		{Name: "initial-sync", Status: healthStatusFailure, Error: "waiting for the first configuration of NGINX"},
+		{Name: "certificates", Status: healthStatusWarning, Error: "last update of the certificates failed: invalid certificate"},
+	}
+	if code != http.StatusServiceUnavailable || !reflect.DeepEqual(result.Checks, expected) {
+		t.Errorf("expected the checks %v but returned %v %v", expected, code, result.Checks)
+	}
+
+	// the errors of the details are reported without failing
+	n.health.setInitialSync()
+
+	code, result = call()
+	expected[1] = healthCheckResult{Name: "initial-sync", Status: healthStatusOK}
+	if code != http.StatusOK || result.Status != healthStatusOK || !reflect.DeepEqual(result.Checks, expected) {
+		t.Errorf("expected the checks %v but returned %v %v", expected, code, result)
+	}
+
+	n.health.setCertificateConfiguration(nil)
+
+	code, result = call()
+	if code != http.StatusOK || result.Status != healthStatusOK {
+		t.Errorf("expected a success after the initial sync but returned %v %v", code, result)
+	}
+}
@@ -0,0 +1,547 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// ingressReferences contains the objects referenced by an Ingress
+type ingressReferences struct {
+	// nodes contains the names of the servers and upstreams configured using
+	// the Ingress. Two Ingresses sharing a node must be computed together.
+	nodes []string
+	// services contains the keys of the referenced Services (and Endpoints)
+	services []string
+	// secrets contains the keys of the referenced TLS Secrets
+	secrets []string
+}
+
+// component contains the result of the computation of a group of Ingresses
+// that do not share servers nor upstreams with other Ingresses.
+type component struct {
+	ingresses []string
+	nodes     sets.String
+
+	objects []*ingress.Ingress
+
+	servers  []*ingress.Server
+	backends []*ingress.Backend
+}
+
+// configurationCache keeps the servers and upstreams obtained from the
+// Ingresses in the last sync, and the objects referenced by each Ingress.
+// This allows the computation of the servers and upstreams affected by the
+// changes received since the last sync instead of the complete configuration.
+type configurationCache struct {
+	mu sync.Mutex
+
+	// full forces the computation of the complete configuration in the next sync
+	full bool
+
+	// keys of the Services and Secrets changed since the last sync. Changes in
+	// Ingresses are detected comparing the objects returned by the store.
+	dirtyServices sets.String
+	dirtySecrets  sets.String
+
+	// state of the last sync. Only used by the sync goroutine.
+	ingresses  map[string]*ingress.Ingress
+	references map[string]ingressReferences
+	components map[string]*component
+	// component of each Ingress and node
+	ingressComponent map[string]*component
+	nodeComponent    map[string]*component
+	// Ingresses referencing each Service and Secret
+	serviceIngresses map[string]sets.String
+	secretIngresses  map[string]sets.String
+	// upstreams not associated with a node of a component
+	unassigned []*ingress.Backend
+
+	defaultSSLCertificate *ingress.SSLCert
+	backendChecksum       string
+}
+
+// newConfigurationCache creates an empty configurationCache. The first sync
+// always computes the complete configuration.
+func newConfigurationCache() *configurationCache {
+	return &configurationCache{
+		full:          true,
+		dirtyServices: sets.NewString(),
+		dirtySecrets:  sets.NewString(),
+	}
+}
+
+// Record registers a change received from the store
+func (c *configurationCache) Record(evt store.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if evt.Type == store.ConfigurationEvent {
+		c.full = true
+		return
+	}
+
+	obj := evt.Obj
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	switch o := obj.(type) {
+	case *apiv1.Service:
+		c.dirtyServices.Insert(k8s.MetaNamespaceKey(o))
+	case *apiv1.Endpoints:
+		c.dirtyServices.Insert(k8s.MetaNamespaceKey(o))
+	case *apiv1.Secret:
+		c.dirtySecrets.Insert(k8s.MetaNamespaceKey(o))
+	default:
+		// changes in Ingresses are detected in the next sync
+	}
+}
+
+// getIngressReferences returns the objects referenced by an Ingress
+func getIngressReferences(ing *ingress.Ingress) ingressReferences {
+	nodes := sets.NewString()
+	services := sets.NewString()
+	secrets := sets.NewString()
+
+	anns := ing.ParsedAnnotations
+
+	addBackend := func(serviceName, upstream string) {
+		services.Insert(fmt.Sprintf("%v/%v", ing.Namespace, serviceName))
+		nodes.Insert("upstream:" + upstream)
+	}
+
+	if ing.Spec.Backend != nil {
+		// the backend of the Ingress configures the catch-all server
+		nodes.Insert("server:" + defServerName)
+		addBackend(ing.Spec.Backend.ServiceName, upstreamName(ing.Namespace, ing.Spec.Backend.ServiceName, ing.Spec.Backend.ServicePort))
+	}
+
+	if anns != nil && anns.Canary.Enabled {
+		// rules of canary Ingresses without a server use the catch-all server
+		nodes.Insert("server:" + defServerName)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+		nodes.Insert("server:" + host)
+
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			addBackend(path.Backend.ServiceName, upstreamName(ing.Namespace, path.Backend.ServiceName, path.Backend.ServicePort))
+		}
+	}
+
+	if anns != nil {
+		// aliases cannot use the name of another server
+		for _, alias := range anns.Aliases {
+			nodes.Insert("server:" + alias)
+		}
+
+		for _, target := range anns.Mirror.Targets {
+			if target.Service == "" {
+				continue
+			}
+
+			addBackend(target.Service, upstreamName(ing.Namespace, target.Service, target.ServicePort))
+		}
+
+		if anns.DefaultBackend != nil {
+			services.Insert(fmt.Sprintf("%v/%v", anns.DefaultBackend.Namespace, anns.DefaultBackend.Name))
+			nodes.Insert(fmt.Sprintf("upstream:custom-default-backend-%v", anns.DefaultBackend.Name))
+		}
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName != "" {
+			secrets.Insert(fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName))
+		}
+	}
+
+	return ingressReferences{
+		nodes:    nodes.List(),
+		services: services.List(),
+		secrets:  secrets.List(),
+	}
+}
+
+// getBackendServers returns the same upstreams and servers than
+// NGINXController.getBackendServers followed by updateServersLocations,
+// computing only the Ingresses affected by the changes since the last sync.
+func (c *configurationCache) getBackendServers(n *NGINXController, ingresses []*ingress.Ingress) ([]*ingress.Backend, []*ingress.Server) {
+	c.mu.Lock()
+	full := c.full
+	dirtyServices := c.dirtyServices
+	dirtySecrets := c.dirtySecrets
+	c.full = false
+	c.dirtyServices = sets.NewString()
+	c.dirtySecrets = sets.NewString()
+	c.mu.Unlock()
+
+	defaultCert := n.getDefaultSSLCertificate()
+	checksum := n.store.GetBackendConfiguration().Checksum
+	if c.defaultSSLCertificate != defaultCert || c.backendChecksum != checksum {
+		full = true
+	}
+
+	if n.cfg.DefaultService != "" && dirtyServices.Has(n.cfg.DefaultService) {
+		// the default backend is used in the catch-all server
+		full = true
+	}
+
+	current := make(map[string]*ingress.Ingress, len(ingresses))
+	for _, ing := range ingresses {
+		current[k8s.MetaNamespaceKey(ing)] = ing
+	}
+
+	if full {
+		c.reset(n, ingresses)
+	} else {
+		c.update(n, ingresses, current, dirtyServices, dirtySecrets)
+	}
+
+	c.ingresses = current
+	c.defaultSSLCertificate = defaultCert
+	c.backendChecksum = checksum
+
+	return c.assemble(n)
+}
+
+// reset computes the configuration of all the Ingresses
+func (c *configurationCache) reset(n *NGINXController, ingresses []*ingress.Ingress) {
+	klog.V(2).InfoS("Computing the complete configuration", "ingresses", len(ingresses))
+
+	c.references = make(map[string]ingressReferences, len(ingresses))
+	c.components = make(map[string]*component)
+	c.ingressComponent = make(map[string]*component, len(ingresses))
+	c.nodeComponent = make(map[string]*component)
+	c.serviceIngresses = make(map[string]sets.String)
+	c.secretIngresses = make(map[string]sets.String)
+	c.unassigned = nil
+
+	for _, ing := range ingresses {
+		c.addReferences(k8s.MetaNamespaceKey(ing), getIngressReferences(ing))
+	}
+
+	for _, comp := range c.group(ingresses) {
+		c.addComponent(comp)
+	}
+
+	// a single computation avoids the overhead of one call per component
+	upstreams, servers := n.getBackendServers(ingresses)
+	updateServersLocations(servers)
+
+	for _, server := range servers {
+		comp, ok := c.nodeComponent["server:"+server.Hostname]
+		if !ok {
+			// catch-all server not used by any Ingress
+			continue
+		}
+
+		comp.servers = append(comp.servers, server)
+	}
+
+	for _, upstream := range upstreams {
+		if upstream.Name == defUpstreamName {
+			continue
+		}
+
+		comp, ok := c.nodeComponent["upstream:"+upstream.Name]
+		if !ok {
+			c.unassigned = append(c.unassigned, upstream)
+			continue
+		}
+
+		comp.backends = append(comp.backends, upstream)
+	}
+}
+
+// update computes the components containing Ingresses that changed, or
+// that reference Services or Secrets that changed, since the last sync.
+func (c *configurationCache) update(n *NGINXController, ingresses []*ingress.Ingress, current map[string]*ingress.Ingress,
+	dirtyServices, dirtySecrets sets.String) {
+	dirty := sets.NewString()
+
+	for key, ing := range current {
+		if prev, ok := c.ingresses[key]; !ok || prev != ing {
+			dirty.Insert(key)
+		}
+	}
+
+	for key := range c.ingresses {
+		if _, ok := current[key]; !ok {
+			dirty.Insert(key)
+		}
+	}
+
+	for _, svc := range dirtyServices.UnsortedList() {
+		dirty.Insert(c.serviceIngresses[svc].UnsortedList()...)
+	}
+
+	for _, secret := range dirtySecrets.UnsortedList() {
+		dirty.Insert(c.secretIngresses[secret].UnsortedList()...)
+	}
+
+	if dirty.Len() == 0 {
+		klog.V(3).InfoS("No Ingress affected by the changes, using the cached configuration")
+		return
+	}
+
+	// components that must be computed again
+	affected := make(map[*component]bool)
+	for _, key := range dirty.UnsortedList() {
+		if comp, ok := c.ingressComponent[key]; ok {
+			affected[comp] = true
+		}
+
+		c.removeReferences(key)
+
+		ing, ok := current[key]
+		if !ok {
+			continue
+		}
+
+		refs := getIngressReferences(ing)
+		for _, node := range refs.nodes {
+			if comp, ok := c.nodeComponent[node]; ok {
+				affected[comp] = true
+			}
+		}
+
+		c.addReferences(key, refs)
+	}
+
+	toCompute := sets.NewString(dirty.UnsortedList()...)
+	for comp := range affected {
+		toCompute.Insert(comp.ingresses...)
+		c.removeComponent(comp)
+	}
+
+	// keep the order of the Ingresses returned by the store
+	var changed []*ingress.Ingress
+	for _, ing := range ingresses {
+		if toCompute.Has(k8s.MetaNamespaceKey(ing)) {
+			changed = append(changed, ing)
+		}
+	}
+
+	klog.V(2).InfoS("Computing configuration changes", "ingresses", len(changed), "total", len(ingresses))
+	for _, comp := range c.group(changed) {
+		c.compute(n, comp)
+		c.addComponent(comp)
+	}
+}
+
+func (c *configurationCache) addReferences(key string, refs ingressReferences) {
+	c.references[key] = refs
+
+	for _, svc := range refs.services {
+		if _, ok := c.serviceIngresses[svc]; !ok {
+			c.serviceIngresses[svc] = sets.NewString()
+		}
+		c.serviceIngresses[svc].Insert(key)
+	}
+
+	for _, secret := range refs.secrets {
+		if _, ok := c.secretIngresses[secret]; !ok {
+			c.secretIngresses[secret] = sets.NewString()
+		}
+		c.secretIngresses[secret].Insert(key)
+	}
+}
+
+func (c *configurationCache) removeReferences(key string) {
+	refs, ok := c.references[key]
+	if !ok {
+		return
+	}
+
+	for _, svc := range refs.services {
+		c.serviceIngresses[svc].Delete(key)
+		if c.serviceIngresses[svc].Len() == 0 {
+			delete(c.serviceIngresses, svc)
+		}
+	}
+
+	for _, secret := range refs.secrets {
+		c.secretIngresses[secret].Delete(key)
+		if c.secretIngresses[secret].Len() == 0 {
+			delete(c.secretIngresses, secret)
+		}
+	}
+
+	delete(c.references, key)
+}
+
+func (c *configurationCache) removeComponent(comp *component) {
+	for _, key := range comp.ingresses {
+		if c.ingressComponent[key] == comp {
+			delete(c.ingressComponent, key)
+		}
+	}
+
+	for _, node := range comp.nodes.UnsortedList() {
+		if c.nodeComponent[node] == comp {
+			delete(c.nodeComponent, node)
+		}
+	}
+
+	delete(c.components, comp.ingresses[0])
+}
+
+// group returns the Ingresses grouped in components. Ingresses sharing a
+// server or an upstream belong to the same component.
+func (c *configurationCache) group(ingresses []*ingress.Ingress) []*component {
+	// union-find over the Ingresses using the nodes as links
+	parent := make([]int, len(ingresses))
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int)
+	for i, ing := range ingresses {
+		parent[i] = i
+
+		for _, node := range c.references[k8s.MetaNamespaceKey(ing)].nodes {
+			j, ok := owner[node]
+			if !ok {
+				owner[node] = i
+				continue
+			}
+
+			ri, rj := find(i), find(j)
+			if ri == rj {
+				continue
+			}
+
+			// the oldest Ingress is the root of the component
+			if ri < rj {
+				parent[rj] = ri
+			} else {
+				parent[ri] = rj
+			}
+		}
+	}
+
+	var comps []*component
+	byRoot := make(map[int]*component)
+	for i, ing := range ingresses {
+		root := find(i)
+
+		comp, ok := byRoot[root]
+		if !ok {
+			comp = &component{
+				nodes: sets.NewString(),
+			}
+			byRoot[root] = comp
+			comps = append(comps, comp)
+		}
+
+		key := k8s.MetaNamespaceKey(ing)
+		comp.ingresses = append(comp.ingresses, key)
+		comp.objects = append(comp.objects, ing)
+		comp.nodes.Insert(c.references[key].nodes...)
+	}
+
+	return comps
+}
+
+// compute obtains the servers and upstreams of a component
+func (c *configurationCache) compute(n *NGINXController, comp *component) {
+	upstreams, servers := n.getBackendServers(comp.objects)
+	updateServersLocations(servers)
+
+	for _, server := range servers {
+		// the catch-all server is always returned
+		if server.Hostname == defServerName && !comp.nodes.Has("server:"+defServerName) {
+			continue
+		}
+
+		comp.servers = append(comp.servers, server)
+	}
+
+	for _, upstream := range upstreams {
+		if upstream.Name == defUpstreamName {
+			continue
+		}
+
+		comp.backends = append(comp.backends, upstream)
+	}
+}
+
+func (c *configurationCache) addComponent(comp *component) {
+	c.components[comp.ingresses[0]] = comp
+
+	for _, key := range comp.ingresses {
+		c.ingressComponent[key] = comp
+	}
+
+	for _, node := range comp.nodes.UnsortedList() {
+		c.nodeComponent[node] = comp
+	}
+}
+
+// assemble returns the upstreams and servers of all the components
+func (c *configurationCache) assemble(n *NGINXController) ([]*ingress.Backend, []*ingress.Server) {
+	upstreams := []*ingress.Backend{n.getDefaultUpstream()}
+	upstreams = append(upstreams, c.unassigned...)
+
+	var servers []*ingress.Server
+
+	for _, comp := range c.components {
+		upstreams = append(upstreams, comp.backends...)
+		servers = append(servers, comp.servers...)
+	}
+
+	if _, ok := c.nodeComponent["server:"+defServerName]; !ok {
+		// no Ingress uses the catch-all server
+		_, defServers := n.getBackendServers(nil)
+		updateServersLocations(defServers)
+		servers = append(servers, defServers...)
+	}
+
+	sort.SliceStable(upstreams, func(a, b int) bool {
+		return upstreams[a].Name < upstreams[b].Name
+	})
+
+	sort.SliceStable(servers, func(i, j int) bool {
+		return servers[i].Hostname < servers[j].Hostname
+	})
+
+	return upstreams, servers
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+func buildIncrementalIngress(name, host, path, service string) *ingress.Ingress {
+	return &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     path,
+										PathType: &pathTypePrefix,
+										Backend: networking.IngressBackend{
+											ServiceName: service,
+											ServicePort: intstr.FromInt(80),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{},
+	}
+}
+
+// summarizeConfiguration returns the upstreams and the locations of each
+// server in a comparable format
+func summarizeConfiguration(upstreams []*ingress.Backend, servers []*ingress.Server) ([]string, []string) {
+	var u []string
+	for _, upstream := range upstreams {
+		u = append(u, upstream.Name)
+	}
+
+	var s []string
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			s = append(s, fmt.Sprintf("%v%v -> %v", server.Hostname, location.Path, location.Backend))
+		}
+	}
+
+	return u, s
+}
+
+func checkIncrementalConfiguration(t *testing.T, n *NGINXController, ingresses []*ingress.Ingress) {
+	expUpstreams, expServers := n.getBackendServers(ingresses)
+	updateServersLocations(expServers)
+
+	upstreams, servers := n.configCache.getBackendServers(n, ingresses)
+
+	eu, es := summarizeConfiguration(expUpstreams, expServers)
+	u, s := summarizeConfiguration(upstreams, servers)
+
+	if !reflect.DeepEqual(eu, u) {
+		t.Errorf("expected upstreams %v but returned %v", eu, u)
+	}
+
+	if !reflect.DeepEqual(es, s) {
+		t.Errorf("expected servers %v but returned %v", es, s)
+	}
+}
+
+func TestGetIngressReferences(t *testing.T) {
+	ing := buildIncrementalIngress("foo", "foo.bar", "/", "foo-svc")
+	ing.Spec.TLS = []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "foo-tls"}}
+	ing.ParsedAnnotations.Aliases = []string{"foo.baz"}
+
+	refs := getIngressReferences(ing)
+
+	expNodes := []string{"server:foo.bar", "server:foo.baz", "upstream:default-foo-svc-80"}
+	if !reflect.DeepEqual(refs.nodes, expNodes) {
+		t.Errorf("expected nodes %v but returned %v", expNodes, refs.nodes)
+	}
+
+	if expServices := []string{"default/foo-svc"}; !reflect.DeepEqual(refs.services, expServices) {
+		t.Errorf("expected services %v but returned %v", expServices, refs.services)
+	}
+
+	if expSecrets := []string{"default/foo-tls"}; !reflect.DeepEqual(refs.secrets, expSecrets) {
+		t.Errorf("expected secrets %v but returned %v", expSecrets, refs.secrets)
+	}
+
+	canaryIng := buildIncrementalIngress("foo-canary", "foo.bar", "/", "foo-svc-canary")
+	canaryIng.ParsedAnnotations.Canary = canary.Config{Enabled: true}
+
+	refs = getIngressReferences(canaryIng)
+
+	expNodes = []string{"server:_", "server:foo.bar", "upstream:default-foo-svc-canary-80"}
+	if !reflect.DeepEqual(refs.nodes, expNodes) {
+		t.Errorf("expected nodes %v but returned %v", expNodes, refs.nodes)
+	}
+}
+
+func TestConfigurationCacheGroup(t *testing.T) {
+	ingresses := []*ingress.Ingress{
+		buildIncrementalIngress("a", "a.com", "/", "svc-a"),
+		buildIncrementalIngress("b", "b.com", "/", "svc-b"),
+		buildIncrementalIngress("c", "a.com", "/c", "svc-c"),
+		buildIncrementalIngress("d", "d.com", "/", "svc-b"),
+	}
+
+	c := newConfigurationCache()
+	c.references = make(map[string]ingressReferences)
+	c.serviceIngresses = make(map[string]sets.String)
+	c.secretIngresses = make(map[string]sets.String)
+	for _, ing := range ingresses {
+		c.addReferences(k8s.MetaNamespaceKey(ing), getIngressReferences(ing))
+	}
+
+	var groups [][]string
+	for _, comp := range c.group(ingresses) {
+		groups = append(groups, comp.ingresses)
+	}
+
+	expected := [][]string{{"default/a", "default/c"}, {"default/b", "default/d"}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected components %v but returned %v", expected, groups)
+	}
+}
+
+func TestConfigurationCacheGetBackendServers(t *testing.T) {
+	n := newNGINXController(t)
+	n.configCache = newConfigurationCache()
+
+	ingresses := []*ingress.Ingress{
+		buildIncrementalIngress("a", "a.com", "/", "svc-a"),
+		buildIncrementalIngress("b", "b.com", "/", "svc-b"),
+		buildIncrementalIngress("c", "a.com", "/c", "svc-c"),
+		buildIncrementalIngress("d", "", "/d", "svc-d"),
+	}
+
+	// first sync
+	checkIncrementalConfiguration(t, n, ingresses)
+
+	// no changes
+	checkIncrementalConfiguration(t, n, ingresses)
+
+	// modified Ingress
+	ingresses[1] = buildIncrementalIngress("b", "b.com", "/b", "svc-e")
+	checkIncrementalConfiguration(t, n, ingresses)
+
+	// new Ingress sharing a server
+	ingresses = append(ingresses, buildIncrementalIngress("e", "b.com", "/e", "svc-a"))
+	checkIncrementalConfiguration(t, n, ingresses)
+
+	// deleted Ingress
+	ingresses = append(ingresses[:2], ingresses[3:]...)
+	checkIncrementalConfiguration(t, n, ingresses)
+
+	// deleted Ingress using the catch-all server
+	ingresses = ingresses[:2]
+	checkIncrementalConfiguration(t, n, ingresses)
+}
+
+func buildSyntheticIngresses(count int) []*ingress.Ingress {
+	ingresses := make([]*ingress.Ingress, 0, count)
+	for i := 0; i < count; i++ {
+		ingresses = append(ingresses, buildIncrementalIngress(
+			fmt.Sprintf("ing-%v", i), fmt.Sprintf("host-%v.example.com", i), "/", fmt.Sprintf("svc-%v", i)))
+	}
+
+	return ingresses
+}
+
+func BenchmarkSyncFull(b *testing.B) {
+	n := newNGINXController(b)
+	ingresses := buildSyntheticIngresses(2000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := i % len(ingresses)
+		ingresses[idx] = buildIncrementalIngress(fmt.Sprintf("ing-%v", idx), fmt.Sprintf("host-%v.example.com", idx), fmt.Sprintf("/%v", i), fmt.Sprintf("svc-%v", idx))
+		_, servers := n.getBackendServers(ingresses)
+		updateServersLocations(servers)
+	}
+}
+
+func BenchmarkSyncIncremental(b *testing.B) {
+	n := newNGINXController(b)
+	n.configCache = newConfigurationCache()
+	ingresses := buildSyntheticIngresses(2000)
+
+	n.configCache.getBackendServers(n, ingresses)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := i % len(ingresses)
+		ingresses[idx] = buildIncrementalIngress(fmt.Sprintf("ing-%v", idx), fmt.Sprintf("host-%v.example.com", idx), fmt.Sprintf("/%v", i), fmt.Sprintf("svc-%v", idx))
+		n.configCache.getBackendServers(n, ingresses)
+	}
+}
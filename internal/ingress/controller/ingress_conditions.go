@@ -0,0 +1,404 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/status"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// maxConditionMessageLength is the maximum length of the message of a
+// condition. The errors of a reload contain the whole output of NGINX.
+const maxConditionMessageLength = 256
+
+// IngressConditions returns the conditions of the Ingresses computed
+// in the last sync, using the namespace/name of the Ingress as key
+func (n *NGINXController) IngressConditions() map[string][]status.Condition {
+	conditions, ok := n.ingressConditions.Load().(map[string][]status.Condition)
+	if !ok {
+		return nil
+	}
+
+	return conditions
+}
+
+// syncIngressConditions computes the conditions of the Ingresses using the
+// configuration of the last sync. The leader emits an Event in the Ingresses
+// with conditions that changed and publishes them through the status syncer.
+// A failed sync only affects the Ingresses changed since the running
+// configuration, the other Ingresses are still served by NGINX.
+func (n *NGINXController) syncIngressConditions(ings []*ingress.Ingress, pcfg *ingress.Configuration, syncErr error) {
+	previous := n.IngressConditions()
+	now := metav1.NewTime(time.Now())
+
+	var involved sets.String
+	if syncErr != nil {
+		involved = changedIngresses(n.runningConfig, pcfg, ings)
+	}
+
+	conditions := make(map[string][]status.Condition, len(ings))
+	for _, ing := range ings {
+		// the Ingresses of other shards are reported by their ingress controllers
+		if !n.cfg.Shard.OwnsIngress(&ing.Ingress) {
+			continue
+		}
+
+		key := k8s.MetaNamespaceKey(ing)
+
+		ingErr := syncErr
+		if !involved.Has(key) {
+			ingErr = nil
+		}
+
+		conditions[key] = n.ingressConditionsFor(ing, pcfg, ingErr, now)
+	}
+
+	n.ingressConditions.Store(conditions)
+
+	if atomic.LoadInt32(&n.isLeader) == 0 {
+		return
+	}
+
+	changed := false
+	for _, ing := range ings {
+		key := k8s.MetaNamespaceKey(ing)
+		if _, ok := conditions[key]; !ok {
+			continue
+		}
+
+		for _, c := range changedConditions(previous[key], conditions[key]) {
+			changed = true
+
+			// Ingresses without previous conditions only report failures
+			if n.recorder == nil || (previous[key] == nil && c.Status == apiv1.ConditionTrue) {
+				continue
+			}
+
+			eventType := apiv1.EventTypeNormal
+			if c.Status != apiv1.ConditionTrue {
+				eventType = apiv1.EventTypeWarning
+			}
+
+			n.recorder.Eventf(ing, eventType, c.Reason, "%v is %v: %v", c.Type, c.Status, c.Message)
+		}
+	}
+
+	if changed && n.syncStatus != nil {
+		n.syncStatus.Update()
+	}
+}
+
+// ingressConditionsFor returns the Accepted, ResolvedRefs and Programmed conditions of an Ingress
+func (n *NGINXController) ingressConditionsFor(ing *ingress.Ingress, pcfg *ingress.Configuration, syncErr error, now metav1.Time) []status.Condition {
+	newCondition := func(conditionType string, ok bool, reason, message string) status.Condition {
+		s := apiv1.ConditionTrue
+		if !ok {
+			s = apiv1.ConditionFalse
+		}
+
+		return status.Condition{
+			Type:               conditionType,
+			Status:             s,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: now,
+		}
+	}
+
+	accepted := newCondition(status.ConditionAccepted, true, "Accepted", "The Ingress rules are valid")
+	if len(ing.AnnotationErrors) > 0 {
+		accepted = newCondition(status.ConditionAccepted, false, "InvalidAnnotations",
+			strings.Join(ing.AnnotationErrors, "; "))
+	} else if owner, host, path := hostConflict(ing, pcfg.Servers); owner != nil {
+		accepted = newCondition(status.ConditionAccepted, false, "HostConflict",
+			fmt.Sprintf("The path %v of host %v is defined by Ingress %v", path, host, k8s.MetaNamespaceKey(owner)))
+	}
+
+	resolvedRefs := newCondition(status.ConditionResolvedRefs, true, "ResolvedRefs", "All the references were resolved")
+	if reason, message := n.unresolvedRef(ing, pcfg.Backends); reason != "" {
+		resolvedRefs = newCondition(status.ConditionResolvedRefs, false, reason, message)
+	}
+
+	programmed := newCondition(status.ConditionProgrammed, true, "Programmed", "The configuration was applied to NGINX")
+	if syncErr != nil {
+		programmed = newCondition(status.ConditionProgrammed, false, "SyncFailed", conditionMessage(syncErr))
+	} else if !rendered(ing, pcfg.Servers) {
+		programmed = newCondition(status.ConditionProgrammed, false, "NotRendered", "The Ingress does not define any location")
+	}
+
+	return []status.Condition{accepted, resolvedRefs, programmed}
+}
+
+// conditionMessage returns the first line of an error reported by NGINX,
+// or the first line of the error, truncated to maxConditionMessageLength
+func conditionMessage(err error) string {
+	var message string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if message == "" {
+			message = line
+		}
+
+		if strings.Contains(line, "[emerg]") || strings.Contains(line, "[error]") {
+			message = line
+			break
+		}
+	}
+
+	if len(message) > maxConditionMessageLength {
+		message = message[:maxConditionMessageLength-3] + "..."
+	}
+
+	return message
+}
+
+// changedIngresses returns the keys of the Ingresses with locations, or
+// backends used by their locations, that differ between the running and the
+// new configuration. Canary Ingresses do not own locations and are included.
+func changedIngresses(running, pcfg *ingress.Configuration, ings []*ingress.Ingress) sets.String {
+	changed := sets.NewString()
+	for _, ing := range ings {
+		if ing.ParsedAnnotations != nil && ing.ParsedAnnotations.Canary.Enabled {
+			changed.Insert(k8s.MetaNamespaceKey(ing))
+		}
+	}
+
+	if running == nil {
+		running = &ingress.Configuration{}
+	}
+
+	runningLocations := ingressLocations(running.Servers)
+	locations := ingressLocations(pcfg.Servers)
+	runningBackends := backendsByName(running.Backends)
+	backends := backendsByName(pcfg.Backends)
+
+	for key, locs := range locations {
+		runningLocs := runningLocations[key]
+		if len(locs) != len(runningLocs) {
+			changed.Insert(key)
+			continue
+		}
+
+		for id, loc := range locs {
+			if !loc.Equal(runningLocs[id]) || !backends[loc.Backend].Equal(runningBackends[loc.Backend]) {
+				changed.Insert(key)
+				break
+			}
+		}
+	}
+
+	for key := range runningLocations {
+		if _, ok := locations[key]; !ok {
+			changed.Insert(key)
+		}
+	}
+
+	return changed
+}
+
+// ingressLocations returns the locations of the servers, using the
+// namespace/name of the Ingress and the host and path of the location as keys
+func ingressLocations(servers []*ingress.Server) map[string]map[string]*ingress.Location {
+	locations := make(map[string]map[string]*ingress.Location)
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.Ingress == nil {
+				continue
+			}
+
+			key := k8s.MetaNamespaceKey(loc.Ingress)
+			if _, ok := locations[key]; !ok {
+				locations[key] = make(map[string]*ingress.Location)
+			}
+
+			pathType := ""
+			if loc.PathType != nil {
+				pathType = string(*loc.PathType)
+			}
+
+			locations[key][fmt.Sprintf("%v %v %v", server.Hostname, loc.Path, pathType)] = loc
+		}
+	}
+
+	return locations
+}
+
+func backendsByName(backends []*ingress.Backend) map[string]*ingress.Backend {
+	byName := make(map[string]*ingress.Backend, len(backends))
+	for _, backend := range backends {
+		byName[backend.Name] = backend
+	}
+
+	return byName
+}
+
+// hostConflict returns the Ingress and the host and path of the first rule
+// of an Ingress rendered using the location of a different Ingress
+func hostConflict(ing *ingress.Ingress, servers []*ingress.Server) (*ingress.Ingress, string, string) {
+	// canary Ingresses share the locations of the main Ingress
+	if ing.ParsedAnnotations != nil && ing.ParsedAnnotations.Canary.Enabled {
+		return nil, "", ""
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			p := path.Path
+			if p == "" {
+				p = rootLocation
+			}
+
+			loc := findLocation(servers, host, p)
+			if loc == nil || loc.Ingress == nil {
+				continue
+			}
+
+			if k8s.MetaNamespaceKey(loc.Ingress) != k8s.MetaNamespaceKey(ing) {
+				return loc.Ingress, host, p
+			}
+		}
+	}
+
+	return nil, "", ""
+}
+
+// findLocation returns the location of a server created for a path of an Ingress rule
+func findLocation(servers []*ingress.Server, host, path string) *ingress.Location {
+	for _, server := range servers {
+		if server.Hostname != host {
+			continue
+		}
+
+		for _, loc := range server.Locations {
+			if loc.IngressPath == path && !loc.IsDefBackend {
+				return loc
+			}
+		}
+	}
+
+	return nil
+}
+
+// rendered returns true if a location of the configuration belongs to the Ingress
+func rendered(ing *ingress.Ingress, servers []*ingress.Server) bool {
+	key := k8s.MetaNamespaceKey(ing)
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.Ingress != nil && k8s.MetaNamespaceKey(loc.Ingress) == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// unresolvedRef returns the reason and message of the first Service
+// or Secret referenced by the Ingress that cannot be used
+func (n *NGINXController) unresolvedRef(ing *ingress.Ingress, upstreams []*ingress.Backend) (string, string) {
+	backends := []*networking.IngressBackend{}
+	if ing.Spec.Backend != nil {
+		backends = append(backends, ing.Spec.Backend)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for i := range rule.HTTP.Paths {
+			backends = append(backends, &rule.HTTP.Paths[i].Backend)
+		}
+	}
+
+	for _, backend := range backends {
+		if backend.ServiceName == "" {
+			continue
+		}
+
+		svcKey := fmt.Sprintf("%v/%v", ing.Namespace, backend.ServiceName)
+		if _, err := n.store.GetService(svcKey); err != nil {
+			return "ServiceNotFound", fmt.Sprintf("Service %v not found", svcKey)
+		}
+
+		name := upstreamName(ing.Namespace, backend.ServiceName, backend.ServicePort)
+		for _, upstream := range upstreams {
+			if upstream.Name == name && len(upstream.Endpoints) == 0 {
+				return "NoEndpoints", fmt.Sprintf("Service %v does not have active endpoints for port %v", svcKey, backend.ServicePort.String())
+			}
+		}
+	}
+
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		secrKey := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
+		if _, err := n.store.GetLocalSSLCert(secrKey); err != nil {
+			return "SecretNotFound", fmt.Sprintf("Secret %v not found or does not contain a valid certificate", secrKey)
+		}
+	}
+
+	return "", ""
+}
+
+// changedConditions returns the conditions with a status, reason
+// or message different from the previous conditions of the same type
+func changedConditions(previous, conditions []status.Condition) []status.Condition {
+	changed := []status.Condition{}
+
+	for _, c := range conditions {
+		found := false
+		for _, p := range previous {
+			if p.Type == c.Type && p.Status == c.Status && p.Reason == c.Reason && p.Message == c.Message {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			changed = append(changed, c)
+		}
+	}
+
+	return changed
+}
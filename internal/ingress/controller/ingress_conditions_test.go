@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/status"
+)
+
+func conditionsTestIngress(name, host string) *ingress.Ingress {
+	return &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path: "/",
+										Backend: networking.IngressBackend{
+											ServiceName: "app",
+											ServicePort: intstr.FromInt(80),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestIngressConditionsFor(t *testing.T) {
+	owner := conditionsTestIngress("owner", "example.com")
+	other := conditionsTestIngress("other", "example.com")
+	missing := conditionsTestIngress("missing", "missing.example.com")
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{
+				Hostname: "example.com",
+				Locations: []*ingress.Location{
+					{Path: "/", IngressPath: "/", Ingress: owner, Backend: "default-app-80"},
+				},
+			},
+		},
+	}
+
+	n := &NGINXController{store: fakeIngressStore{}}
+	now := metav1.Now()
+
+	testCases := []struct {
+		ing      *ingress.Ingress
+		syncErr  error
+		expected map[string]string
+	}{
+		{
+			ing: owner,
+			expected: map[string]string{
+				status.ConditionAccepted:     "Accepted",
+				status.ConditionResolvedRefs: "ServiceNotFound",
+				status.ConditionProgrammed:   "Programmed",
+			},
+		},
+		{
+			ing: other,
+			expected: map[string]string{
+				status.ConditionAccepted:   "HostConflict",
+				status.ConditionProgrammed: "NotRendered",
+			},
+		},
+		{
+			ing:     missing,
+			syncErr: fmt.Errorf("reload failed"),
+			expected: map[string]string{
+				status.ConditionAccepted:   "Accepted",
+				status.ConditionProgrammed: "SyncFailed",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		conditions := n.ingressConditionsFor(tc.ing, pcfg, tc.syncErr, now)
+		if len(conditions) != 3 {
+			t.Fatalf("expected 3 conditions but %v returned", len(conditions))
+		}
+
+		for _, c := range conditions {
+			reason, ok := tc.expected[c.Type]
+			if !ok {
+				continue
+			}
+
+			if c.Reason != reason {
+				t.Errorf("expected reason %v for condition %v of Ingress %v but %v returned", reason, c.Type, tc.ing.Name, c.Reason)
+			}
+
+			if (c.Status == apiv1.ConditionTrue) != (c.Reason == "Accepted" || c.Reason == "Programmed") {
+				t.Errorf("unexpected status %v for condition %v of Ingress %v", c.Status, c.Type, tc.ing.Name)
+			}
+		}
+	}
+}
+
+func TestChangedConditions(t *testing.T) {
+	previous := []status.Condition{
+		{Type: status.ConditionAccepted, Status: apiv1.ConditionTrue, Reason: "Accepted"},
+		{Type: status.ConditionProgrammed, Status: apiv1.ConditionTrue, Reason: "Programmed"},
+	}
+
+	conditions := []status.Condition{
+		{Type: status.ConditionAccepted, Status: apiv1.ConditionTrue, Reason: "Accepted"},
+		{Type: status.ConditionProgrammed, Status: apiv1.ConditionFalse, Reason: "SyncFailed"},
+	}
+
+	changed := changedConditions(previous, conditions)
+	if len(changed) != 1 || changed[0].Type != status.ConditionProgrammed {
+		t.Errorf("expected the Programmed condition to change but %v returned", changed)
+	}
+
+	if changed := changedConditions(conditions, conditions); len(changed) != 0 {
+		t.Errorf("expected no changes but %v returned", changed)
+	}
+}
+
+func TestConditionMessage(t *testing.T) {
+	err := fmt.Errorf(`exit status 1
+2021/01/01 00:00:00 [warn] 1#1: the "http2_max_field_size" directive is obsolete
+2021/01/01 00:00:00 [emerg] 1#1: unknown directive "foo" in /tmp/nginx-cfg123:45
+nginx: configuration file /tmp/nginx-cfg123 test failed`)
+
+	expected := `2021/01/01 00:00:00 [emerg] 1#1: unknown directive "foo" in /tmp/nginx-cfg123:45`
+	if message := conditionMessage(err); message != expected {
+		t.Errorf("expected %v but %v returned", expected, message)
+	}
+
+	if message := conditionMessage(fmt.Errorf("%v", strings.Repeat("a", 1000))); len(message) != maxConditionMessageLength {
+		t.Errorf("expected a message of %v characters but %v returned", maxConditionMessageLength, len(message))
+	}
+}
+
+func TestChangedIngresses(t *testing.T) {
+	unchanged := conditionsTestIngress("unchanged", "example.com")
+	changed := conditionsTestIngress("changed", "changed.example.com")
+	removed := conditionsTestIngress("removed", "removed.example.com")
+
+	running := &ingress.Configuration{
+		Backends: []*ingress.Backend{
+			{Name: "default-app-80"},
+		},
+		Servers: []*ingress.Server{
+			{
+				Hostname:  "example.com",
+				Locations: []*ingress.Location{{Path: "/", Ingress: unchanged, Backend: "default-app-80"}},
+			},
+			{
+				Hostname:  "changed.example.com",
+				Locations: []*ingress.Location{{Path: "/", Ingress: changed, Backend: "default-app-80"}},
+			},
+			{
+				Hostname:  "removed.example.com",
+				Locations: []*ingress.Location{{Path: "/", Ingress: removed, Backend: "default-app-80"}},
+			},
+		},
+	}
+
+	pcfg := &ingress.Configuration{
+		Backends: []*ingress.Backend{
+			{Name: "default-app-80"},
+			{Name: "default-other-80"},
+		},
+		Servers: []*ingress.Server{
+			{
+				Hostname:  "example.com",
+				Locations: []*ingress.Location{{Path: "/", Ingress: unchanged, Backend: "default-app-80"}},
+			},
+			{
+				Hostname:  "changed.example.com",
+				Locations: []*ingress.Location{{Path: "/", Ingress: changed, Backend: "default-other-80"}},
+			},
+		},
+	}
+
+	involved := changedIngresses(running, pcfg, []*ingress.Ingress{unchanged, changed, removed})
+	expected := []string{"default/changed", "default/removed"}
+	if !reflect.DeepEqual(involved.List(), expected) {
+		t.Errorf("expected %v but %v returned", expected, involved.List())
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"text/template"
 	"time"
@@ -41,6 +42,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -55,12 +57,14 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/controller/process"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+	"k8s.io/ingress-nginx/internal/ingress/leader"
 	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/ingress/status"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/net/dns"
 	"k8s.io/ingress-nginx/internal/net/ssl"
 	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/internal/runtime"
 	"k8s.io/ingress-nginx/internal/task"
 	"k8s.io/ingress-nginx/internal/watch"
 )
@@ -68,6 +72,12 @@ import (
 const (
 	tempNginxPattern = "nginx-cfg"
 	emptyUID         = "-1"
+
+	// leaderTaskStopTimeout is the maximum time waiting for a leader
+	// task to finish its work in progress after losing the leadership
+	leaderTaskStopTimeout = 10 * time.Second
+	// leaderTasksCheckInterval is the interval between health checks of the leader tasks
+	leaderTasksCheckInterval = 30 * time.Second
 )
 
 // NewNGINXController creates a new NGINX Ingress controller.
@@ -100,6 +110,9 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		ngxErrCh: make(chan error),
 
 		stopLock: &sync.Mutex{},
+		syncLock: &sync.Mutex{},
+
+		backendConflicts: &backendConflicts{},
 
 		runningConfig: new(ingress.Configuration),
 
@@ -108,6 +121,38 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		metricCollector: mc,
 
 		command: NewNginxCommand(),
+
+		workerTracker: process.NewWorkerTracker(nginx.PID),
+
+		leaderTasks: leader.NewTasks(mc, leaderTaskStopTimeout),
+
+		health: newHealthState(),
+
+		resources: runtime.DetectResources(),
+	}
+
+	klog.InfoS("Detected resources", "cgroupVersion", n.resources.CgroupVersion, "cpus", n.resources.CPUs,
+		"cpuSet", n.resources.CPUSet, "memoryLimit", n.resources.MemoryLimit)
+	mc.SetDetectedResources(n.resources.CPUs, n.resources.MemoryLimit)
+
+	if config.EnableIncrementalSync {
+		n.configCache = newConfigurationCache()
+	}
+
+	if config.EnableExternalNameResolution {
+		resolvConf, err := dns.GetResolvConf()
+		if err != nil {
+			klog.Fatalf("Error reading the resolver configuration: %v", err)
+		}
+
+		n.externalNames = newExternalNameResolver(dns.NewResolver(resolvConf, config.ExternalNamePreferIPv6), func() {
+			n.dynamicQueue.EnqueueSkippableTask(task.GetDummyObject("external-name"))
+		}, n.onExternalNameError)
+	}
+
+	if config.MaxmindUpdateInterval > 0 {
+		n.geoIPChecksum = nginx.GeoIPDatabasesChecksum(config.MaxmindEditionFiles)
+		n.geoIPUpdater = nginx.NewGeoIPUpdater(config.MaxmindUpdateInterval, n.onGeoIPUpdate, mc.SetGeoIPDatabase)
 	}
 
 	if n.cfg.ValidationWebhook != "" {
@@ -131,23 +176,49 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		config.ResyncPeriod,
 		config.Client,
 		n.updateCh,
-		config.DisableCatchAll)
+		config.DisableCatchAll,
+		config.StrictConfigMapValidation,
+		config.ConfigurationResource,
+		config.DynamicClient,
+		config.IngressSelector)
 
-	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+	n.syncQueue = task.NewCoalescingTaskQueue("reload", n.syncIngress, config.MinReloadInterval, config.MaxReloadDelay)
+	n.dynamicQueue = task.NewNamedTaskQueue("dynamic", n.syncDynamic)
 
 	if config.UpdateStatus {
 		n.syncStatus = status.NewStatusSyncer(status.Config{
-			Client:                 config.Client,
-			PublishService:         config.PublishService,
-			PublishStatusAddress:   config.PublishStatusAddress,
-			IngressLister:          n.store,
-			UpdateStatusOnShutdown: config.UpdateStatusOnShutdown,
-			UseNodeInternalIP:      config.UseNodeInternalIP,
+			Client:                  config.Client,
+			PublishService:          config.PublishService,
+			PublishStatusAddress:    config.PublishStatusAddress,
+			PublishAddressSets:      config.PublishAddressSets,
+			PublishClassAddressSets: config.PublishClassAddressSets,
+			PublishIPFamily:         config.PublishIPFamily,
+			PublishPorts:            config.PublishPorts,
+			ListenPorts:             []int32{int32(config.ListenPorts.HTTP), int32(config.ListenPorts.HTTPS)},
+			IngressLister:           shardIngressLister{ingressLister: n.store, shard: config.Shard},
+			ConditionLister:         n,
+			UpdateStatusOnShutdown:  config.UpdateStatusOnShutdown,
+			UseNodeInternalIP:       config.UseNodeInternalIP,
+		})
+		n.leaderTasks.Register(leader.Task{
+			Name:    "status",
+			Start:   n.syncStatus.Run,
+			Stop:    n.syncStatus.Stop,
+			Healthy: n.syncStatus.Healthy,
 		})
 	} else {
 		klog.Warning("Update of Ingress status is disabled (flag --update-status)")
 	}
 
+	n.leaderTasks.Register(leader.Task{
+		Name: "ssl-expire-metrics",
+		Start: func(stopCh chan struct{}) {
+			// manually update SSL expiration metrics
+			// (to not wait for a reload)
+			n.metricCollector.SetSSLExpireTime(n.runningConfig.Servers)
+		},
+	})
+
 	onTemplateChange := func() {
 		template, err := ngx_template.NewTemplate(nginx.TemplatePath)
 		if err != nil {
@@ -210,8 +281,21 @@ type NGINXController struct {
 
 	recorder record.EventRecorder
 
+	// syncQueue applies all the changes, reloading NGINX if required.
+	// Changes received during the minimum reload interval are coalesced.
 	syncQueue *task.Queue
 
+	// dynamicQueue applies the changes that do not require a reload,
+	// without waiting for the changes pending in syncQueue
+	dynamicQueue *task.Queue
+
+	// syncLock serializes the syncs of both queues
+	syncLock *sync.Mutex
+
+	// configCache is used to compute only the parts of the
+	// configuration affected by the changes in the cluster
+	configCache *configurationCache
+
 	syncStatus status.Syncer
 
 	syncRateLimiter flowcontrol.RateLimiter
@@ -238,6 +322,15 @@ type NGINXController struct {
 
 	isShuttingDown bool
 
+	// isLeader is 1 while the controller holds the leader election lock
+	isLeader int32
+
+	// backendConflicts contains the conflicting backend annotations reported
+	backendConflicts *backendConflicts
+
+	// ingressConditions contains the conditions of the Ingresses computed in the last sync
+	ingressConditions atomic.Value
+
 	Proxy *TCPProxy
 
 	store store.Storer
@@ -247,6 +340,32 @@ type NGINXController struct {
 	validationWebhookServer *http.Server
 
 	command NginxExecTester
+
+	// workerTracker tracks the NGINX workers shutting down after a reload
+	workerTracker *process.WorkerTracker
+
+	// leaderTasks contains the tasks running only in the leader
+	leaderTasks *leader.Tasks
+
+	// health contains the state of the components checked by the health endpoints
+	health *healthState
+
+	// externalNames resolves the names of ExternalName Services,
+	// nil when --enable-external-name-resolution is not set
+	externalNames *externalNameResolver
+
+	// geoIPUpdater downloads new versions of the GeoIP2 databases,
+	// nil when the databases are not downloaded or updated
+	geoIPUpdater *nginx.GeoIPUpdater
+	// geoIPChecksum contains the checksum of the GeoIP2 databases on disk
+	geoIPChecksum string
+
+	// modSecurityAuditStopCh stops the reader of the ModSecurity audit events,
+	// nil when enable-modsecurity-audit-events is not set in the configuration ConfigMap
+	modSecurityAuditStopCh chan struct{}
+
+	// resources contains the CPUs and memory available to the controller
+	resources runtime.Resources
 }
 
 // Start starts a new NGINX master process running in the foreground.
@@ -254,6 +373,7 @@ func (n *NGINXController) Start() {
 	klog.InfoS("Starting NGINX Ingress controller")
 
 	n.store.Run(n.stopCh)
+	n.health.setInformersSynced()
 
 	// we need to use the defined ingress class to allow multiple leaders
 	// in order to update information about ingress status
@@ -262,24 +382,31 @@ func (n *NGINXController) Start() {
 		electionID = fmt.Sprintf("%v-%v", n.cfg.ElectionID, class.IngressClass)
 	}
 
+	// each shard elects a leader to update the status of its Ingresses
+	if n.cfg.Shard.Enabled() {
+		electionID = fmt.Sprintf("%v-shard-%v", electionID, n.cfg.Shard.ID)
+	}
+
 	setupLeaderElection(&leaderElectionConfig{
 		Client:     n.cfg.Client,
 		ElectionID: electionID,
-		OnStartedLeading: func(stopCh chan struct{}) {
-			if n.syncStatus != nil {
-				go n.syncStatus.Run(stopCh)
-			}
-
+		OnStartedLeading: func() {
+			atomic.StoreInt32(&n.isLeader, 1)
 			n.metricCollector.OnStartedLeading(electionID)
-			// manually update SSL expiration metrics
-			// (to not wait for a reload)
-			n.metricCollector.SetSSLExpireTime(n.runningConfig.Servers)
+			n.leaderTasks.Start()
 		},
 		OnStoppedLeading: func() {
+			// finish the work in progress before the next leader starts
+			n.leaderTasks.Stop()
+			atomic.StoreInt32(&n.isLeader, 0)
 			n.metricCollector.OnStoppedLeading(electionID)
 		},
 	})
 
+	go wait.Until(func() {
+		n.leaderTasks.Check()
+	}, leaderTasksCheckInterval, n.stopCh)
+
 	cmd := n.command.ExecCommand()
 
 	// put NGINX in another process group to prevent it
@@ -297,6 +424,14 @@ func (n *NGINXController) Start() {
 	n.start(cmd)
 
 	go n.syncQueue.Run(time.Second, n.stopCh)
+	go n.dynamicQueue.Run(time.Second, n.stopCh)
+	if n.externalNames != nil {
+		go n.externalNames.run(n.stopCh)
+	}
+	if n.geoIPUpdater != nil {
+		go n.geoIPUpdater.Run(n.stopCh)
+	}
+	go wait.Until(func() { n.updateOldWorkers() }, oldWorkersMetricsInterval, n.stopCh)
 	// force initial sync
 	n.syncQueue.EnqueueTask(task.GetDummyObject("initial-sync"))
 
@@ -340,12 +475,21 @@ func (n *NGINXController) Start() {
 
 			if evt, ok := event.(store.Event); ok {
 				klog.V(3).InfoS("Event received", "type", evt.Type, "object", evt.Obj)
+				if n.configCache != nil {
+					n.configCache.Record(evt)
+				}
+
 				if evt.Type == store.ConfigurationEvent {
 					// TODO: is this necessary? Consider removing this special case
 					n.syncQueue.EnqueueTask(task.GetDummyObject("configmap-change"))
 					continue
 				}
 
+				if isDynamicEvent(evt) {
+					n.dynamicQueue.EnqueueSkippableTask(evt.Obj)
+					continue
+				}
+
 				n.syncQueue.EnqueueSkippableTask(evt.Obj)
 			} else {
 				klog.Warningf("Unexpected event type received %T", event)
@@ -356,6 +500,53 @@ func (n *NGINXController) Start() {
 	}
 }
 
+// onGeoIPUpdate renders the GeoIP2 databases present on disk
+// in the configuration and reloads NGINX after a database changed.
+// The checksum of the databases is part of the configuration, so the
+// next sync detects the change and reloads NGINX.
+func (n *NGINXController) onGeoIPUpdate(files []string) {
+	checksum := nginx.GeoIPDatabasesChecksum(files)
+
+	n.syncLock.Lock()
+	n.cfg.MaxmindEditionFiles = files
+	n.geoIPChecksum = checksum
+	n.syncLock.Unlock()
+
+	n.syncQueue.EnqueueTask(task.GetDummyObject("geoip-update"))
+}
+
+// updateModSecurityAudit starts the reader of the ModSecurity audit events
+// when they are enabled in the configuration ConfigMap, and stops it when
+// they are disabled
+func (n *NGINXController) updateModSecurityAudit(enabled bool) {
+	if enabled == (n.modSecurityAuditStopCh != nil) {
+		return
+	}
+
+	if !enabled {
+		close(n.modSecurityAuditStopCh)
+		n.modSecurityAuditStopCh = nil
+		return
+	}
+
+	stopCh := make(chan struct{})
+	n.modSecurityAuditStopCh = stopCh
+
+	reader := nginx.NewModSecurityAuditReader(n.onModSecurityAuditEvent)
+	go reader.Run(stopCh)
+}
+
+// onModSecurityAuditEvent exports the metrics of a ModSecurity audit event and logs it
+func (n *NGINXController) onModSecurityAuditEvent(event *nginx.ModSecurityAuditEvent) {
+	if event.Namespace != "" && event.Ingress != "" {
+		n.metricCollector.IncModSecurityAuditEvent(event.Namespace, event.Ingress)
+	}
+
+	klog.InfoS("ModSecurity audit event", "namespace", event.Namespace, "ingress", event.Ingress,
+		"requestID", event.RequestID, "clientIP", event.ClientIP, "host", event.Host, "method", event.Method,
+		"uri", event.URI, "status", event.Status, "rules", event.Rules)
+}
+
 // Stop gracefully stops the NGINX master process.
 func (n *NGINXController) Stop() error {
 	n.isShuttingDown = true
@@ -369,9 +560,13 @@ func (n *NGINXController) Stop() error {
 
 	time.Sleep(time.Duration(n.cfg.ShutdownGracePeriod) * time.Second)
 
+	n.drain()
+
 	klog.InfoS("Shutting down controller queues")
 	close(n.stopCh)
 	go n.syncQueue.Shutdown()
+	go n.dynamicQueue.Shutdown()
+	n.leaderTasks.Stop()
 	if n.syncStatus != nil {
 		n.syncStatus.Shutdown()
 	}
@@ -509,14 +704,21 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 		cfg.ServerNameHashMaxSize = serverNameHashMaxSize
 	}
 
+	wp, err := strconv.Atoi(cfg.WorkerProcesses)
+	klog.V(3).InfoS("Worker processes", "count", wp)
+	if err != nil {
+		wp = 1
+	}
+
+	if cfg.WorkerCPUAffinity == "auto" && len(n.resources.CPUSet) > 0 {
+		// bind the workers only to the CPUs the controller is allowed to run on
+		cfg.WorkerCPUAffinity = fmt.Sprintf("auto %v", runtime.CPUAffinityMask(n.resources.CPUSet))
+		klog.V(3).InfoS("Adjusting WorkerCPUAffinity variable", "value", cfg.WorkerCPUAffinity)
+	}
+
 	if cfg.MaxWorkerOpenFiles == 0 {
 		// the limit of open files is per worker process
 		// and we leave some room to avoid consuming all the FDs available
-		wp, err := strconv.Atoi(cfg.WorkerProcesses)
-		klog.V(3).InfoS("Worker processes", "count", wp)
-		if err != nil {
-			wp = 1
-		}
 		maxOpenFiles := (rlimitMaxNumFiles() / wp) - 1024
 		klog.V(3).InfoS("Maximum number of open file descriptors", "value", maxOpenFiles)
 		if maxOpenFiles < 1024 {
@@ -529,10 +731,16 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 
 	if cfg.MaxWorkerConnections == 0 {
 		maxWorkerConnections := int(float64(cfg.MaxWorkerOpenFiles * 3.0 / 4))
+		if memoryConnections := memoryMaxWorkerConnections(n.resources.MemoryLimit, wp); memoryConnections > 0 && memoryConnections < maxWorkerConnections {
+			klog.V(3).InfoS("Limiting MaxWorkerConnections to the memory limit", "memoryLimit", n.resources.MemoryLimit, "value", memoryConnections)
+			maxWorkerConnections = memoryConnections
+		}
 		klog.V(3).InfoS("Adjusting MaxWorkerConnections variable", "value", maxWorkerConnections)
 		cfg.MaxWorkerConnections = maxWorkerConnections
 	}
 
+	n.metricCollector.SetWorkerSettings(wp, cfg.MaxWorkerConnections, cfg.MaxWorkerOpenFiles)
+
 	setHeaders := map[string]string{}
 	if cfg.ProxySetHeaders != "" {
 		cmap, err := n.store.GetConfigMap(cfg.ProxySetHeaders)
@@ -593,6 +801,7 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 		NginxStatusIpv6Whitelist: cfg.NginxStatusIpv6Whitelist,
 		RedirectServers:          buildRedirects(ingressCfg.Servers),
 		IsSSLPassthroughEnabled:  n.cfg.EnableSSLPassthrough,
+		IsHTTP3Enabled:           n.cfg.ListenPorts.QUIC > 0,
 		ListenPorts:              n.cfg.ListenPorts,
 		PublishService:           n.GetPublishService(),
 		EnableMetrics:            n.cfg.EnableMetrics,
@@ -701,6 +910,13 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		return err
 	}
 
+	n.updateModSecurityAudit(cfg.EnableModsecurityAuditEvents)
+
+	err = n.workerTracker.Reloading()
+	if err != nil {
+		klog.Warningf("Error reading the NGINX worker processes: %v", err)
+	}
+
 	o, err := n.command.ExecCommand("-s", "reload").CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%v\n%v", err, string(o))
@@ -835,9 +1051,63 @@ func (n *NGINXController) IsDynamicConfigurationEnough(pcfg *ingress.Configurati
 	clearCertificates(&copyOfRunningConfig)
 	clearCertificates(&copyOfPcfg)
 
+	copyOfRunningConfig.ErrorPages = nil
+	copyOfPcfg.ErrorPages = nil
+
+	if pcfg.DynamicLocations {
+		cfg := n.store.GetBackendConfiguration()
+		clearRoutedLocations(&copyOfRunningConfig, cfg)
+		clearRoutedLocations(&copyOfPcfg, cfg)
+	}
+
 	return copyOfRunningConfig.Equal(&copyOfPcfg)
 }
 
+// Helper function to replace the locations routed by Lua with the settings of the
+// location rendered in nginx.conf, since changes in the routed locations can be
+// applied dynamically.
+func clearRoutedLocations(config *ingress.Configuration, cfg ngx_config.Configuration) {
+	var clearedServers []*ingress.Server
+	for _, server := range config.Servers {
+		routed := ngx_template.RoutedLocations(server, cfg)
+		if len(routed) == 0 {
+			clearedServers = append(clearedServers, server)
+			continue
+		}
+
+		isRouted := make(map[*ingress.Location]bool, len(routed))
+		for _, location := range routed {
+			isRouted[location] = true
+		}
+
+		copyOfServer := *server
+		copyOfServer.Locations = []*ingress.Location{ngx_template.WithoutRoutedSettings(routed[0])}
+		for _, location := range server.Locations {
+			if !isRouted[location] {
+				copyOfServer.Locations = append(copyOfServer.Locations, location)
+			}
+		}
+
+		clearedServers = append(clearedServers, &copyOfServer)
+	}
+	config.Servers = clearedServers
+}
+
+// isDynamicEvent returns true if the event usually changes only parts
+// of the configuration that can be applied without a reload.
+func isDynamicEvent(evt store.Event) bool {
+	if evt.Type == store.ErrorPagesEvent {
+		return true
+	}
+
+	switch evt.Obj.(type) {
+	case *apiv1.Endpoints, *apiv1.Secret:
+		return true
+	default:
+		return false
+	}
+}
+
 // configureDynamically encodes new Backends in JSON format and POSTs the
 // payload to an internal HTTP endpoint handled by Lua.
 func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) error {
@@ -860,6 +1130,24 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 	serversChanged := !reflect.DeepEqual(n.runningConfig.Servers, pcfg.Servers)
 	if serversChanged {
 		err := configureCertificates(pcfg.Servers)
+		n.health.setCertificateConfiguration(err)
+		if err != nil {
+			return err
+		}
+	}
+
+	// changes in the configuration can change the routed locations
+	configChanged := n.runningConfig.BackendConfigChecksum != pcfg.BackendConfigChecksum
+	if pcfg.DynamicLocations && (serversChanged || configChanged) {
+		routes := ngx_template.LocationRoutes(pcfg.Servers, n.store.GetBackendConfiguration())
+		err := configureLocations(routes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !reflect.DeepEqual(n.runningConfig.ErrorPages, pcfg.ErrorPages) {
+		err := configureErrorPages(pcfg.ErrorPages)
 		if err != nil {
 			return err
 		}
@@ -868,6 +1156,40 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 	return nil
 }
 
+// configureErrorPages JSON encodes the custom error pages and POSTs
+// them to an internal HTTP endpoint that is handled by Lua
+func configureErrorPages(errorPages map[string]ingress.ErrorPages) error {
+	if errorPages == nil {
+		errorPages = map[string]ingress.ErrorPages{}
+	}
+
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/error-pages", "application/json", errorPages)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// configureLocations JSON encodes the routes of the servers using dynamic
+// locations and POSTs them to an internal HTTP endpoint that is handled by Lua
+func configureLocations(routes map[string][]ngx_template.LocationRoute) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/locations", "application/json", routes)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
 func updateStreamConfiguration(TCPEndpoints []ingress.L4Service, UDPEndpoints []ingress.L4Service) error {
 	streams := make([]ingress.Backend, 0)
 	for _, ep := range TCPEndpoints {
@@ -31,8 +31,10 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
 
@@ -148,6 +150,26 @@ func TestIsDynamicConfigurationEnough(t *testing.T) {
 	}
 }
 
+func TestIsDynamicEvent(t *testing.T) {
+	testCases := []struct {
+		event    store.Event
+		expected bool
+	}{
+		{store.Event{Type: store.UpdateEvent, Obj: &apiv1.Endpoints{}}, true},
+		{store.Event{Type: store.UpdateEvent, Obj: &apiv1.Secret{}}, true},
+		{store.Event{Type: store.UpdateEvent, Obj: &apiv1.Service{}}, false},
+		{store.Event{Type: store.UpdateEvent, Obj: &networking.Ingress{}}, false},
+		{store.Event{Type: store.ConfigurationEvent, Obj: &apiv1.ConfigMap{}}, false},
+		{store.Event{Type: store.ErrorPagesEvent, Obj: &apiv1.ConfigMap{}}, true},
+	}
+
+	for _, tc := range testCases {
+		if isDynamicEvent(tc.event) != tc.expected {
+			t.Errorf("expected isDynamicEvent to return %v for %T", tc.expected, tc.event.Obj)
+		}
+	}
+}
+
 func TestConfigureDynamically(t *testing.T) {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%v", nginx.StatusPort))
 	if err != nil {
@@ -253,6 +275,7 @@ func TestConfigureDynamically(t *testing.T) {
 	n := &NGINXController{
 		runningConfig: &ingress.Configuration{},
 		cfg:           &Configuration{},
+		health:        newHealthState(),
 	}
 
 	err = n.configureDynamically(commonConfig)
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	workerTitle             = "nginx: worker process"
+	shuttingDownWorkerTitle = "nginx: worker process is shutting down"
+)
+
+// Worker contains the information of an NGINX worker process
+type Worker struct {
+	PID int
+	// Generation is the number of reloads before the worker was started
+	Generation int
+	// ShuttingDown is true if the worker belongs to a previous
+	// configuration and is waiting for the connections to finish
+	ShuttingDown bool
+	// ResidentMemory is the resident memory of the worker in bytes
+	ResidentMemory uint64
+}
+
+// OldWorkers contains the worker processes still running after a reload
+type OldWorkers struct {
+	// Generations is the number of configurations of the old workers
+	Generations int
+	Processes   int
+	// ResidentMemory is the resident memory of the old workers in bytes
+	ResidentMemory uint64
+}
+
+// WorkerTracker tracks the worker processes of the NGINX master
+// process across reloads
+type WorkerTracker struct {
+	procRoot string
+	pidFile  string
+
+	lock        sync.Mutex
+	generation  int
+	generations map[int]int
+}
+
+// NewWorkerTracker returns a tracker of the workers of the
+// NGINX master process with the PID in pidFile
+func NewWorkerTracker(pidFile string) *WorkerTracker {
+	return newWorkerTracker("/proc", pidFile)
+}
+
+func newWorkerTracker(procRoot, pidFile string) *WorkerTracker {
+	return &WorkerTracker{
+		procRoot:    procRoot,
+		pidFile:     pidFile,
+		generations: make(map[int]int),
+	}
+}
+
+// Reloading starts a new generation of workers. It must be called before a
+// reload is triggered, so the running workers are assigned to the previous
+// generation before NGINX starts the workers of the new configuration.
+func (t *WorkerTracker) Reloading() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_, err := t.scan()
+	t.generation++
+
+	return err
+}
+
+// Workers returns the worker processes of the NGINX master process
+func (t *WorkerTracker) Workers() ([]Worker, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.scan()
+}
+
+// OldWorkers returns the worker processes shutting down after a reload
+func (t *WorkerTracker) OldWorkers() (OldWorkers, error) {
+	workers, err := t.Workers()
+	if err != nil {
+		return OldWorkers{}, err
+	}
+
+	old := OldWorkers{}
+	generations := make(map[int]bool)
+	for _, worker := range workers {
+		if !worker.ShuttingDown {
+			continue
+		}
+
+		generations[worker.Generation] = true
+		old.Processes++
+		old.ResidentMemory += worker.ResidentMemory
+	}
+	old.Generations = len(generations)
+
+	return old, nil
+}
+
+// scan reads the worker processes of the master process. Workers not seen
+// before are assigned to the current generation.
+func (t *WorkerTracker) scan() ([]Worker, error) {
+	content, err := ioutil.ReadFile(t.pidFile)
+	if err != nil {
+		return nil, err
+	}
+
+	masterPID, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NGINX PID file %v: %v", t.pidFile, err)
+	}
+
+	entries, err := ioutil.ReadDir(t.procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var workers []Worker
+	running := make(map[int]bool)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		worker, ok := t.readWorker(pid, masterPID)
+		if !ok {
+			continue
+		}
+
+		generation, tracked := t.generations[pid]
+		if !tracked {
+			generation = t.generation
+			t.generations[pid] = generation
+		}
+
+		worker.Generation = generation
+		running[pid] = true
+		workers = append(workers, worker)
+	}
+
+	for pid := range t.generations {
+		if !running[pid] {
+			delete(t.generations, pid)
+		}
+	}
+
+	return workers, nil
+}
+
+// readWorker returns the information of the process if it
+// is a worker of the master process
+func (t *WorkerTracker) readWorker(pid, masterPID int) (Worker, bool) {
+	dir := filepath.Join(t.procRoot, strconv.Itoa(pid))
+
+	// the process may exit while reading it
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return Worker{}, false
+	}
+
+	// the name of the command is enclosed in parentheses and may
+	// contain spaces, the parent PID is the second field after it
+	end := bytes.LastIndexByte(stat, ')')
+	if end == -1 {
+		return Worker{}, false
+	}
+
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 2 {
+		return Worker{}, false
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil || ppid != masterPID {
+		return Worker{}, false
+	}
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return Worker{}, false
+	}
+
+	title := strings.TrimSpace(string(bytes.Trim(cmdline, "\x00")))
+	if !strings.HasPrefix(title, workerTitle) {
+		// cache loader and cache manager processes
+		return Worker{}, false
+	}
+
+	worker := Worker{
+		PID:          pid,
+		ShuttingDown: strings.HasPrefix(title, shuttingDownWorkerTitle),
+	}
+
+	statm, err := ioutil.ReadFile(filepath.Join(dir, "statm"))
+	if err == nil {
+		fields := strings.Fields(string(statm))
+		if len(fields) > 1 {
+			pages, err := strconv.ParseUint(fields[1], 10, 64)
+			if err == nil {
+				worker.ResidentMemory = pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+
+	return worker, true
+}
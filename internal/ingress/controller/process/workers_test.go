@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProcess(t *testing.T, procRoot string, pid, ppid int, title string, residentPages int) {
+	dir := filepath.Join(procRoot, fmt.Sprintf("%v", pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := map[string]string{
+		"stat":    fmt.Sprintf("%v (nginx) S %v %v 0 0", pid, ppid, ppid),
+		"cmdline": title + "\x00\x00\x00",
+		"statm":   fmt.Sprintf("1000 %v 10 1 0 100 0", residentPages),
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestWorkerTracker(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(procRoot)
+
+	pidFile := filepath.Join(procRoot, "nginx.pid")
+	if err := ioutil.WriteFile(pidFile, []byte("10\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeProcess(t, procRoot, 1, 0, "/usr/bin/dumb-init", 1)
+	writeProcess(t, procRoot, 10, 1, "nginx: master process /usr/bin/nginx -c /etc/nginx/nginx.conf", 1)
+	writeProcess(t, procRoot, 11, 10, "nginx: worker process", 2)
+	writeProcess(t, procRoot, 12, 10, "nginx: cache manager process", 2)
+	writeProcess(t, procRoot, 13, 99, "nginx: worker process", 2)
+
+	tracker := newWorkerTracker(procRoot, pidFile)
+
+	workers, err := tracker.Workers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workers) != 1 || workers[0].PID != 11 || workers[0].Generation != 0 {
+		t.Fatalf("expected worker 11 of generation 0 but %+v returned", workers)
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	if workers[0].ResidentMemory != 2*pageSize {
+		t.Errorf("expected a resident memory of %v but %v returned", 2*pageSize, workers[0].ResidentMemory)
+	}
+
+	// first reload
+	if err := tracker.Reloading(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writeProcess(t, procRoot, 11, 10, "nginx: worker process is shutting down", 3)
+	writeProcess(t, procRoot, 21, 10, "nginx: worker process", 2)
+
+	// second reload
+	if err := tracker.Reloading(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writeProcess(t, procRoot, 21, 10, "nginx: worker process is shutting down", 4)
+	writeProcess(t, procRoot, 31, 10, "nginx: worker process", 2)
+
+	old, err := tracker.OldWorkers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := OldWorkers{Generations: 2, Processes: 2, ResidentMemory: 7 * pageSize}
+	if old != expected {
+		t.Errorf("expected %+v but %+v returned", expected, old)
+	}
+
+	// the workers of the first generation finished
+	if err := os.RemoveAll(filepath.Join(procRoot, "11")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	old, err = tracker.OldWorkers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = OldWorkers{Generations: 1, Processes: 1, ResidentMemory: 4 * pageSize}
+	if old != expected {
+		t.Errorf("expected %+v but %+v returned", expected, old)
+	}
+
+	if _, tracked := tracker.generations[11]; tracked {
+		t.Errorf("expected worker 11 to not be tracked after it finished")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+// filterShard returns the servers of the hosts belonging to the shard and
+// the upstreams used by these servers. The default server is always rendered.
+func filterShard(s shard.Shard, upstreams []*ingress.Backend, servers []*ingress.Server) ([]*ingress.Backend, []*ingress.Server) {
+	if !s.Enabled() {
+		return upstreams, servers
+	}
+
+	used := sets.NewString(defUpstreamName)
+
+	var shardServers []*ingress.Server
+	for _, server := range servers {
+		if server.Hostname != defServerName && !s.Owns(server.Hostname) {
+			continue
+		}
+
+		for _, location := range server.Locations {
+			used.Insert(location.Backend)
+			if location.DefaultBackendUpstreamName != "" {
+				used.Insert(location.DefaultBackendUpstreamName)
+			}
+			for _, target := range location.Mirror.Targets {
+				if target.UpstreamName != "" {
+					used.Insert(target.UpstreamName)
+				}
+			}
+		}
+
+		shardServers = append(shardServers, server)
+	}
+
+	// canary upstreams are only referenced by the primary upstream
+	for _, upstream := range upstreams {
+		if used.Has(upstream.Name) {
+			used.Insert(upstream.AlternativeBackends...)
+		}
+	}
+
+	var shardUpstreams []*ingress.Backend
+	for _, upstream := range upstreams {
+		if used.Has(upstream.Name) {
+			shardUpstreams = append(shardUpstreams, upstream)
+		}
+	}
+
+	return shardUpstreams, shardServers
+}
+
+// shardIngressLister lists the Ingresses with the status
+// published by the shard of the ingress controller
+type shardIngressLister struct {
+	ingressLister interface {
+		ListIngresses() []*ingress.Ingress
+	}
+
+	shard shard.Shard
+}
+
+// ListIngresses returns the Ingresses owned by the shard
+func (l shardIngressLister) ListIngresses() []*ingress.Ingress {
+	var ingresses []*ingress.Ingress
+	for _, ing := range l.ingressLister.ListIngresses() {
+		if l.shard.OwnsIngress(&ing.Ingress) {
+			ingresses = append(ingresses, ing)
+		}
+	}
+
+	return ingresses
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
+	"k8s.io/ingress-nginx/internal/ingress/shard"
+)
+
+func TestFilterShard(t *testing.T) {
+	s := shard.Shard{ID: 1, Count: 2}
+
+	// find a host of each shard
+	hosts := map[int]string{}
+	for i := 0; len(hosts) < 2; i++ {
+		host := fmt.Sprintf("host-%v.example.com", i)
+		hosts[shard.ForHost(host, 2)] = host
+	}
+
+	upstreams := []*ingress.Backend{
+		{Name: defUpstreamName},
+		{Name: "default-owned-80", AlternativeBackends: []string{"default-owned-canary-80"}},
+		{Name: "default-owned-canary-80", NoServer: true},
+		{Name: "default-mirror-80", NoServer: true},
+		{Name: "default-other-80"},
+		{Name: "default-other-mirror-80", NoServer: true},
+	}
+
+	// mirror targets using a URL or a Service
+	ownedMirror := mirror.Config{Targets: []mirror.Target{
+		{Source: "/_mirror-a", URL: "https://mirror.example.com"},
+		{Source: "/_mirror-a-1", Service: "mirror", UpstreamName: "default-mirror-80"},
+	}}
+	otherMirror := mirror.Config{Targets: []mirror.Target{
+		{Source: "/_mirror-b", Service: "other-mirror", UpstreamName: "default-other-mirror-80"},
+	}}
+
+	servers := []*ingress.Server{
+		{Hostname: defServerName, Locations: []*ingress.Location{{Path: "/", Backend: defUpstreamName}}},
+		{Hostname: hosts[1], Locations: []*ingress.Location{{Path: "/", Backend: "default-owned-80", Mirror: ownedMirror}}},
+		{Hostname: hosts[0], Locations: []*ingress.Location{{Path: "/", Backend: "default-other-80", Mirror: otherMirror}}},
+	}
+
+	shardUpstreams, shardServers := filterShard(s, upstreams, servers)
+
+	if len(shardServers) != 2 || shardServers[0].Hostname != defServerName || shardServers[1].Hostname != hosts[1] {
+		t.Errorf("expected the default server and %v", hosts[1])
+	}
+
+	names := []string{}
+	for _, upstream := range shardUpstreams {
+		names = append(names, upstream.Name)
+	}
+
+	expected := fmt.Sprintf("%v", []string{defUpstreamName, "default-owned-80", "default-owned-canary-80", "default-mirror-80"})
+	if fmt.Sprintf("%v", names) != expected {
+		t.Errorf("expected upstreams %v but %v returned", expected, names)
+	}
+
+	allUpstreams, allServers := filterShard(shard.Shard{}, upstreams, servers)
+	if len(allUpstreams) != len(upstreams) || len(allServers) != len(servers) {
+		t.Errorf("expected all the upstreams and servers when sharding is disabled")
+	}
+}
+
+func TestShardIngressLister(t *testing.T) {
+	ingresses := []*ingress.Ingress{}
+	for i := 0; i < 10; i++ {
+		ingresses = append(ingresses, &ingress.Ingress{
+			Ingress: networking.Ingress{
+				Spec: networking.IngressSpec{
+					Rules: []networking.IngressRule{{Host: fmt.Sprintf("host-%v.example.com", i)}},
+				},
+			},
+		})
+	}
+
+	total := 0
+	for id := 0; id < 3; id++ {
+		lister := shardIngressLister{
+			ingressLister: fakeIngressStore{ingresses: ingresses},
+			shard:         shard.Shard{ID: id, Count: 3},
+		}
+		total += len(lister.ListIngresses())
+	}
+
+	if total != len(ingresses) {
+		t.Errorf("expected each Ingress to be listed by one shard but %v were listed", total)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+// drainInterval is the interval between checks of the drain sequence
+var drainInterval = 1 * time.Second
+
+// drain removes the ingress controller from the Services fronting it and
+// waits for the active connections to finish before NGINX is stopped.
+// The readiness probe is already failing when the drain starts.
+func (n *NGINXController) drain() {
+	n.waitForEndpointsRemoval()
+	n.drainConnections()
+}
+
+// waitForEndpointsRemoval waits until the pod is removed from the
+// endpoints of the published Services, up to --shutdown-endpoints-timeout
+func (n *NGINXController) waitForEndpointsRemoval() {
+	services := n.publishServices()
+	if len(services) == 0 || n.cfg.ShutdownEndpointsTimeout <= 0 {
+		return
+	}
+
+	klog.InfoS("Waiting for the removal of the pod from the endpoints of the published Services", "services", services)
+	err := wait.PollImmediate(drainInterval, n.cfg.ShutdownEndpointsTimeout, func() (bool, error) {
+		for _, service := range services {
+			ns, name, err := k8s.ParseNameNS(service)
+			if err != nil {
+				klog.Warningf("%v", err)
+				continue
+			}
+
+			eps, err := n.cfg.Client.CoreV1().Endpoints(ns).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				klog.Warningf("Error obtaining the endpoints of Service %v: %v", service, err)
+				return false, nil
+			}
+
+			if endpointsContainPod(eps, k8s.IngressPodDetails.Namespace, k8s.IngressPodDetails.Name) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		klog.Warningf("The pod was not removed from the endpoints of the published Services after %v", n.cfg.ShutdownEndpointsTimeout)
+	}
+}
+
+// drainConnections stops accepting new connections in NGINX, closes the
+// keepalive connections after the next response and waits until the active
+// connections drop to --shutdown-drain-connections, up to --shutdown-drain-timeout
+func (n *NGINXController) drainConnections() {
+	if n.cfg.ShutdownDrainTimeout <= 0 {
+		return
+	}
+
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/drain", "application/json", true)
+	if err != nil {
+		klog.Warningf("Error starting the drain of NGINX connections: %v", err)
+		return
+	}
+
+	if statusCode != http.StatusCreated {
+		klog.Warningf("Error starting the drain of NGINX connections: unexpected error code: %d", statusCode)
+		return
+	}
+
+	klog.InfoS("Draining NGINX connections", "threshold", n.cfg.ShutdownDrainConnections, "timeout", n.cfg.ShutdownDrainTimeout)
+	active := 0
+	err = wait.PollImmediate(drainInterval, n.cfg.ShutdownDrainTimeout, func() (bool, error) {
+		active, err = collectors.NGINXActiveConnections()
+		if err != nil {
+			klog.Warningf("Error obtaining the active connections: %v", err)
+			return false, nil
+		}
+
+		return active <= n.cfg.ShutdownDrainConnections, nil
+	})
+	if err != nil {
+		klog.Warningf("NGINX has %v active connections after %v, stopping anyway", active, n.cfg.ShutdownDrainTimeout)
+		return
+	}
+
+	klog.InfoS("NGINX connections drained", "active", active)
+}
+
+// publishServices returns the Services publishing the addresses of the ingress
+// controller, from the flags --publish-service and --publish-address-set
+func (n *NGINXController) publishServices() []string {
+	var services []string
+	for _, service := range strings.Split(n.cfg.PublishService, ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+
+	for _, set := range n.cfg.PublishAddressSets {
+		services = append(services, set.Services...)
+	}
+
+	return services
+}
+
+// endpointsContainPod returns true if the pod is a ready address of the endpoints
+func endpointsContainPod(eps *apiv1.Endpoints, namespace, name string) bool {
+	for _, subset := range eps.Subsets {
+		for _, address := range subset.Addresses {
+			ref := address.TargetRef
+			if ref != nil && ref.Kind == "Pod" && ref.Namespace == namespace && ref.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/ingress-nginx/internal/ingress/status"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+func TestPublishServices(t *testing.T) {
+	n := &NGINXController{
+		cfg: &Configuration{
+			PublishService: "ingress-nginx/external, ingress-nginx/external-v6",
+			PublishAddressSets: map[string]status.AddressSet{
+				"internal": {Services: []string{"ingress-nginx/internal"}, Addresses: []string{"10.0.0.1"}},
+			},
+		},
+	}
+
+	expected := []string{"ingress-nginx/external", "ingress-nginx/external-v6", "ingress-nginx/internal"}
+	if services := n.publishServices(); !reflect.DeepEqual(services, expected) {
+		t.Errorf("expected %v but returned %v", expected, services)
+	}
+}
+
+func TestWaitForEndpointsRemoval(t *testing.T) {
+	k8s.IngressPodDetails = &k8s.PodInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress-nginx-controller-1",
+			Namespace: "ingress-nginx",
+		},
+	}
+
+	address := func(name string) apiv1.EndpointAddress {
+		return apiv1.EndpointAddress{
+			IP:        "10.0.0.1",
+			TargetRef: &apiv1.ObjectReference{Kind: "Pod", Namespace: "ingress-nginx", Name: name},
+		}
+	}
+
+	eps := &apiv1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "ingress-nginx"},
+		Subsets: []apiv1.EndpointSubset{{
+			Addresses: []apiv1.EndpointAddress{address("ingress-nginx-controller-1"), address("ingress-nginx-controller-2")},
+		}},
+	}
+
+	if !endpointsContainPod(eps, "ingress-nginx", "ingress-nginx-controller-1") {
+		t.Fatalf("expected the endpoints to contain the pod")
+	}
+
+	client := fake.NewSimpleClientset(eps)
+	n := &NGINXController{
+		cfg: &Configuration{
+			Client:                   client,
+			PublishService:           "ingress-nginx/external",
+			ShutdownEndpointsTimeout: 5 * time.Second,
+		},
+	}
+
+	drainInterval = 10 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		n.waitForEndpointsRemoval()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("expected to wait for the removal of the pod from the endpoints")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the pod is not ready anymore
+	eps.Subsets[0].NotReadyAddresses = []apiv1.EndpointAddress{eps.Subsets[0].Addresses[0]}
+	eps.Subsets[0].Addresses = eps.Subsets[0].Addresses[1:]
+	_, err := client.CoreV1().Endpoints("ingress-nginx").Update(context.TODO(), eps, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error updating the endpoints: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the wait to finish after the removal of the pod from the endpoints")
+	}
+}
@@ -25,7 +25,6 @@ import (
 	"k8s.io/klog/v2"
 
 	apiv1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/leaderelection"
@@ -38,7 +37,7 @@ type leaderElectionConfig struct {
 
 	ElectionID string
 
-	OnStartedLeading func(chan struct{})
+	OnStartedLeading func()
 	OnStoppedLeading func()
 }
 
@@ -57,19 +56,16 @@ func setupLeaderElection(config *leaderElectionConfig) {
 		return cancel
 	}
 
-	var stopCh chan struct{}
 	callbacks := leaderelection.LeaderCallbacks{
 		OnStartedLeading: func(ctx context.Context) {
 			klog.V(2).InfoS("I am the new leader")
-			stopCh = make(chan struct{})
 
 			if config.OnStartedLeading != nil {
-				config.OnStartedLeading(stopCh)
+				config.OnStartedLeading()
 			}
 		},
 		OnStoppedLeading: func() {
 			klog.V(2).InfoS("I am not leader anymore")
-			close(stopCh)
 
 			// cancel the context
 			cancelContext()
@@ -93,19 +89,26 @@ func setupLeaderElection(config *leaderElectionConfig) {
 		Host:      hostname,
 	})
 
-	lock := resourcelock.ConfigMapLock{
-		ConfigMapMeta: metav1.ObjectMeta{Namespace: k8s.IngressPodDetails.Namespace, Name: config.ElectionID},
-		Client:        config.Client.CoreV1(),
-		LockConfig: resourcelock.ResourceLockConfig{
+	// The lock is held in a Lease and in the ConfigMap used by previous
+	// versions, so both old and new replicas respect the same leader
+	// during an upgrade of the ingress controller.
+	lock, err := resourcelock.New(resourcelock.ConfigMapsLeasesResourceLock,
+		k8s.IngressPodDetails.Namespace,
+		config.ElectionID,
+		config.Client.CoreV1(),
+		config.Client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
 			Identity:      k8s.IngressPodDetails.Name,
 			EventRecorder: recorder,
-		},
+		})
+	if err != nil {
+		klog.Fatalf("unexpected error creating the leader election lock: %v", err)
 	}
 
 	ttl := 30 * time.Second
 
-	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
-		Lock:          &lock,
+	elector, err = leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
 		LeaseDuration: ttl,
 		RenewDeadline: ttl / 2,
 		RetryPeriod:   ttl / 4,
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// ConfigurationResource defines the custom resource containing the
+// configuration of the ingress controller
+var ConfigurationResource = schema.GroupVersionResource{
+	Group:    "ingress-nginx.k8s.io",
+	Version:  "v1alpha1",
+	Resource: "nginxconfigurations",
+}
+
+// newConfigurationInformer creates an informer watching only the
+// configuration resource with the key namespace/name
+func newConfigurationInformer(client dynamic.Interface, key string, resyncPeriod time.Duration) (cache.SharedIndexInformer, error) {
+	ns, name, err := k8s.ParseNameNS(key)
+	if err != nil {
+		return nil, err
+	}
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, resyncPeriod, ns,
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		},
+	)
+
+	return factory.ForResource(ConfigurationResource).Informer(), nil
+}
+
+// resourceSettings returns the settings defined in the spec of the
+// configuration resource using the format of the configuration ConfigMap.
+// Lists are converted to comma separated values.
+func resourceSettings(obj *unstructured.Unstructured) (map[string]string, error) {
+	data := make(map[string]string)
+	if obj == nil {
+		return data, nil
+	}
+
+	settings, found, err := unstructured.NestedMap(obj.Object, "spec", "settings")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return data, nil
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var invalid []string
+	for _, key := range keys {
+		switch value := settings[key].(type) {
+		case nil:
+			continue
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprintf("%v", item))
+			}
+
+			data[key] = strings.Join(items, ",")
+		case map[string]interface{}:
+			invalid = append(invalid, key)
+		default:
+			data[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return data, fmt.Errorf("objects are not valid settings: %v", strings.Join(invalid, ", "))
+	}
+
+	return data, nil
+}
+
+// mergeSettings returns the data of the configuration ConfigMap with the
+// settings of the configuration resource applied. The resource takes precedence.
+func mergeSettings(configMapData, resourceData map[string]string) map[string]string {
+	merged := make(map[string]string, len(configMapData)+len(resourceData))
+	for key, value := range configMapData {
+		merged[key] = value
+	}
+
+	for key, value := range resourceData {
+		merged[key] = value
+	}
+
+	return merged
+}
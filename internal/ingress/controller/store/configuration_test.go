@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResourceSettings(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "ingress-nginx.k8s.io/v1alpha1",
+			"kind":       "NGINXConfiguration",
+			"spec": map[string]interface{}{
+				"settings": map[string]interface{}{
+					"use-gzip":               true,
+					"gzip-level":             int64(5),
+					"proxy-body-size":        "8m",
+					"whitelist-source-range": []interface{}{"10.0.0.0/8", "192.168.0.0/16"},
+				},
+			},
+		},
+	}
+
+	data, err := resourceSettings(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"use-gzip":               "true",
+		"gzip-level":             "5",
+		"proxy-body-size":        "8m",
+		"whitelist-source-range": "10.0.0.0/8,192.168.0.0/16",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v but returned %v", expected, data)
+	}
+
+	merged := mergeSettings(map[string]string{"use-gzip": "false", "keep-alive": "10"}, data)
+	if merged["use-gzip"] != "true" || merged["keep-alive"] != "10" {
+		t.Errorf("expected the settings of the resource to take precedence but returned %v", merged)
+	}
+
+	obj.Object["spec"] = map[string]interface{}{
+		"settings": map[string]interface{}{
+			"proxy-body-size": map[string]interface{}{"size": "8m"},
+		},
+	}
+
+	if _, err := resourceSettings(obj); err == nil {
+		t.Errorf("expected an error for a setting containing an object")
+	}
+}
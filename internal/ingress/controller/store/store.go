@@ -30,10 +30,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -53,6 +56,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/ingress/status"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
@@ -66,6 +70,13 @@ type Storer interface {
 	// GetBackendConfiguration returns the nginx configuration stored in a configmap
 	GetBackendConfiguration() ngx_config.Configuration
 
+	// GetBackendConfigurationErrors returns the errors found reading the configmap
+	GetBackendConfigurationErrors() []string
+
+	// GetConfigurationResource returns the configuration resource or nil if
+	// the resource is not used or does not exist
+	GetConfigurationResource() *unstructured.Unstructured
+
 	// GetConfigMap returns the ConfigMap matching key.
 	GetConfigMap(key string) (*corev1.ConfigMap, error)
 
@@ -78,6 +89,9 @@ type Storer interface {
 	// GetServiceEndpoints returns the Endpoints of a Service matching key.
 	GetServiceEndpoints(key string) (*corev1.Endpoints, error)
 
+	// GetNamespace returns the Namespace matching name.
+	GetNamespace(name string) (*corev1.Namespace, error)
+
 	// ListIngresses returns a list of all Ingresses in the store.
 	ListIngresses() []*ingress.Ingress
 
@@ -111,6 +125,8 @@ const (
 	DeleteEvent EventType = "DELETE"
 	// ConfigurationEvent event associated when a controller configuration object is created or updated
 	ConfigurationEvent EventType = "CONFIGURATION"
+	// ErrorPagesEvent event associated when a ConfigMap with custom error pages is created or updated
+	ErrorPagesEvent EventType = "ERROR_PAGES"
 )
 
 // Event holds the context of an event.
@@ -126,6 +142,13 @@ type Informer struct {
 	Service   cache.SharedIndexInformer
 	Secret    cache.SharedIndexInformer
 	ConfigMap cache.SharedIndexInformer
+
+	// Configuration is only defined if the configuration resource is used
+	Configuration cache.SharedIndexInformer
+
+	// Namespace is only defined if the default annotations of the
+	// Namespaces are enabled
+	Namespace cache.SharedIndexInformer
 }
 
 // Lister contains object listers (stores).
@@ -135,6 +158,7 @@ type Lister struct {
 	Endpoint              EndpointLister
 	Secret                SecretLister
 	ConfigMap             ConfigMapLister
+	Namespace             NamespaceLister
 	IngressWithAnnotation IngressWithAnnotationsLister
 }
 
@@ -153,14 +177,26 @@ func (i *Informer) Run(stopCh chan struct{}) {
 	go i.Service.Run(stopCh)
 	go i.ConfigMap.Run(stopCh)
 
-	// wait for all involved caches to be synced before processing items
-	// from the queue
-	if !cache.WaitForCacheSync(stopCh,
+	synced := []cache.InformerSynced{
 		i.Endpoint.HasSynced,
 		i.Service.HasSynced,
 		i.Secret.HasSynced,
 		i.ConfigMap.HasSynced,
-	) {
+	}
+
+	if i.Configuration != nil {
+		go i.Configuration.Run(stopCh)
+		synced = append(synced, i.Configuration.HasSynced)
+	}
+
+	if i.Namespace != nil {
+		go i.Namespace.Run(stopCh)
+		synced = append(synced, i.Namespace.HasSynced)
+	}
+
+	// wait for all involved caches to be synced before processing items
+	// from the queue
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		runtime.HandleError(fmt.Errorf("timed out waiting for caches to sync"))
 	}
 
@@ -186,6 +222,18 @@ type k8sStore struct {
 	// operation to execute in each OnUpdate invocation
 	backendConfig ngx_config.Configuration
 
+	// backendConfigErrors contains the errors found reading the configmap
+	backendConfigErrors []string
+
+	// configMap and configResource contain the sources of backendConfig.
+	// The settings of the configuration resource take precedence.
+	configMap      *corev1.ConfigMap
+	configResource *unstructured.Unstructured
+
+	// strictConfigValidation refuses the configmaps containing errors,
+	// keeping the previous configuration
+	strictConfigValidation bool
+
 	// informer contains the cache Informers
 	informers *Informer
 
@@ -213,6 +261,8 @@ type k8sStore struct {
 	backendConfigMu *sync.RWMutex
 
 	defaultSSLCertificate string
+
+	recorder record.EventRecorder
 }
 
 // New creates a new object store to be used in the ingress controller
@@ -221,7 +271,11 @@ func New(
 	resyncPeriod time.Duration,
 	client clientset.Interface,
 	updateCh *channels.RingChannel,
-	disableCatchAll bool) Storer {
+	disableCatchAll bool,
+	strictConfigValidation bool,
+	configurationResource string,
+	dynamicClient dynamic.Interface,
+	ingressSelector string) Storer {
 
 	store := &k8sStore{
 		informers:             &Informer{},
@@ -233,6 +287,8 @@ func New(
 		backendConfigMu:       &sync.RWMutex{},
 		secretIngressMap:      NewObjectRefMap(),
 		defaultSSLCertificate: defaultSSLCertificate,
+
+		strictConfigValidation: strictConfigValidation,
 	}
 
 	eventBroadcaster := record.NewBroadcaster()
@@ -243,6 +299,7 @@ func New(
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
 		Component: "nginx-ingress-controller",
 	})
+	store.recorder = recorder
 
 	// k8sStore fulfills resolver.Resolver interface
 	store.annotations = annotations.NewAnnotationExtractor(store)
@@ -293,7 +350,19 @@ func New(
 		informers.WithTweakListOptions(secretsTweakListOptionsFunc),
 	)
 
-	store.informers.Ingress = infFactory.Networking().V1beta1().Ingresses().Informer()
+	// the ingresses can be filtered with a label selector to split
+	// the ingresses across groups of ingress controllers
+	infFactoryIngresses := infFactory
+	if ingressSelector != "" {
+		infFactoryIngresses = informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = ingressSelector
+			}),
+		)
+	}
+
+	store.informers.Ingress = infFactoryIngresses.Networking().V1beta1().Ingresses().Informer()
 	store.listers.Ingress.Store = store.informers.Ingress.GetStore()
 
 	store.informers.Endpoint = infFactory.Core().V1().Endpoints().Informer()
@@ -388,6 +457,9 @@ func New(
 				klog.InfoS("removing ingress", "ingress", klog.KObj(curIng), "class", class.IngressKey)
 				ingDeleteHandler(old)
 				return
+			} else if validCur && status.OnlyConditionsChanged(oldIng, curIng) {
+				klog.V(3).InfoS("Only the conditions of the ingress changed. Skipping update", "ingress", klog.KObj(curIng))
+				return
 			} else if validCur && !reflect.DeepEqual(old, cur) {
 				if hasCatchAllIngressRule(curIng.Spec) && disableCatchAll {
 					klog.InfoS("ignoring update for catch-all ingress and delete old one because of --disable-catch-all", "ingress", klog.KObj(curIng))
@@ -543,10 +615,20 @@ func New(
 			triggerUpdate = true
 			recorder.Eventf(cfgMap, corev1.EventTypeNormal, eventName, fmt.Sprintf("ConfigMap %v", key))
 			if key == configmap {
-				store.setConfig(cfgMap)
+				if eventName == "DELETE" {
+					// the default configuration is used without the ConfigMap
+					store.setConfig(&corev1.ConfigMap{ObjectMeta: cfgMap.ObjectMeta})
+				} else {
+					store.setConfig(cfgMap)
+				}
 			}
 		}
 
+		defaultsUpdated := false
+		rulesUpdated := false
+		// custom error pages are updated without a reload
+		errorPagesUpdated := key == store.GetBackendConfiguration().CustomErrorPages
+
 		ings := store.listers.IngressWithAnnotation.List()
 		for _, ingKey := range ings {
 			key := k8s.MetaNamespaceKey(ingKey)
@@ -556,6 +638,25 @@ func New(
 				continue
 			}
 
+			if name, err := parser.GetStringAnnotation("custom-error-pages", ing); err == nil &&
+				fmt.Sprintf("%v/%v", ing.Namespace, name) == k8s.MetaNamespaceKey(cfgMap) {
+				errorPagesUpdated = true
+			}
+
+			if annotations.IsDefaultsConfigMap(k8s.MetaNamespaceKey(cfgMap), ing) {
+				store.syncIngress(ing)
+				defaultsUpdated = true
+				continue
+			}
+
+			// the ModSecurity rule overrides are rendered in the configuration
+			if name, err := parser.GetStringAnnotation("modsecurity-rules-configmap", ing); err == nil &&
+				fmt.Sprintf("%v/%v", ing.Namespace, name) == k8s.MetaNamespaceKey(cfgMap) {
+				store.syncIngress(ing)
+				rulesUpdated = true
+				continue
+			}
+
 			if parser.AnnotationsReferencesConfigmap(ing) {
 				store.syncIngress(ing)
 				continue
@@ -571,6 +672,16 @@ func New(
 				Type: ConfigurationEvent,
 				Obj:  cfgMap,
 			}
+		} else if defaultsUpdated || rulesUpdated {
+			updateCh.In() <- Event{
+				Type: UpdateEvent,
+				Obj:  cfgMap,
+			}
+		} else if errorPagesUpdated {
+			updateCh.In() <- Event{
+				Type: ErrorPagesEvent,
+				Obj:  cfgMap,
+			}
 		}
 	}
 
@@ -589,9 +700,36 @@ func New(
 			key := k8s.MetaNamespaceKey(cfgMap)
 			handleCfgMapEvent(key, cfgMap, "UPDATE")
 		},
+		DeleteFunc: func(obj interface{}) {
+			cfgMap, ok := obj.(*corev1.ConfigMap)
+			if !ok {
+				// If we reached here it means the configmap was deleted but its final state is unrecorded.
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					klog.ErrorS(nil, "Error obtaining object from tombstone", "key", obj)
+					return
+				}
+				cfgMap, ok = tombstone.Obj.(*corev1.ConfigMap)
+				if !ok {
+					klog.Errorf("Tombstone contained object that is not a ConfigMap: %#v", obj)
+					return
+				}
+			}
+
+			// the Ingresses using the ConfigMap, like the default annotations,
+			// are synced again without its content
+			key := k8s.MetaNamespaceKey(cfgMap)
+			handleCfgMapEvent(key, cfgMap, "DELETE")
+		},
 	}
 
 	serviceHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			svc := obj.(*corev1.Service)
+			if annotations.ServiceProxySSLSecret(svc) != "" {
+				store.syncServiceSecrets(svc)
+			}
+		},
 		UpdateFunc: func(old, cur interface{}) {
 			oldSvc := old.(*corev1.Service)
 			curSvc := cur.(*corev1.Service)
@@ -600,6 +738,10 @@ func New(
 				return
 			}
 
+			if annotations.ServiceProxySSLSecret(oldSvc) != annotations.ServiceProxySSLSecret(curSvc) {
+				store.syncServiceSecrets(curSvc)
+			}
+
 			updateCh.In() <- Event{
 				Type: UpdateEvent,
 				Obj:  cur,
@@ -607,11 +749,122 @@ func New(
 		},
 	}
 
+	if annotations.NamespaceAnnotations {
+		// Namespaces are cluster scoped, only the watched namespace is required
+		infFactoryNamespaces := informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				if namespace != "" {
+					options.FieldSelector = fields.OneTermEqualSelector("metadata.name", namespace).String()
+				}
+			}),
+		)
+
+		store.informers.Namespace = infFactoryNamespaces.Core().V1().Namespaces().Informer()
+		store.listers.Namespace.Store = store.informers.Namespace.GetStore()
+	}
+
+	handleNamespace := func(ns *corev1.Namespace) {
+		synced := false
+		for _, ingKey := range store.listers.IngressWithAnnotation.List() {
+			key := k8s.MetaNamespaceKey(ingKey)
+			ing, err := store.getIngress(key)
+			if err != nil {
+				klog.Errorf("could not find Ingress %v in local store: %v", key, err)
+				continue
+			}
+
+			if ing.Namespace != ns.Name {
+				continue
+			}
+
+			store.syncIngress(ing)
+			synced = true
+		}
+
+		if synced {
+			updateCh.In() <- Event{
+				Type: UpdateEvent,
+				Obj:  ns,
+			}
+		}
+	}
+
+	namespaceEventHandler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldNs := old.(*corev1.Namespace)
+			curNs := cur.(*corev1.Namespace)
+
+			if reflect.DeepEqual(annotations.DefaultAnnotations(oldNs), annotations.DefaultAnnotations(curNs)) {
+				return
+			}
+
+			handleNamespace(curNs)
+		},
+	}
+
+	if configurationResource != "" && dynamicClient != nil {
+		informer, err := newConfigurationInformer(dynamicClient, configurationResource, resyncPeriod)
+		if err != nil {
+			klog.Warningf("Ignoring invalid configuration resource %v: %v", configurationResource, err)
+		} else {
+			store.informers.Configuration = informer
+		}
+	}
+
+	handleConfigurationResource := func(obj *unstructured.Unstructured) {
+		store.setConfigurationResource(obj)
+
+		// the configuration can change the default values of the annotations
+		for _, ingKey := range store.listers.IngressWithAnnotation.List() {
+			key := k8s.MetaNamespaceKey(ingKey)
+			ing, err := store.getIngress(key)
+			if err != nil {
+				klog.Errorf("could not find Ingress %v in local store: %v", key, err)
+				continue
+			}
+
+			store.syncIngress(ing)
+		}
+
+		updateCh.In() <- Event{
+			Type: ConfigurationEvent,
+			Obj:  obj,
+		}
+	}
+
+	configurationEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			handleConfigurationResource(obj.(*unstructured.Unstructured))
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldObj := old.(*unstructured.Unstructured)
+			curObj := cur.(*unstructured.Unstructured)
+
+			// changes in the status do not modify the generation and
+			// only update the cached resource, without a resync
+			if oldObj.GetGeneration() == curObj.GetGeneration() {
+				store.setConfigurationResource(curObj)
+				return
+			}
+
+			handleConfigurationResource(curObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			handleConfigurationResource(nil)
+		},
+	}
+
 	store.informers.Ingress.AddEventHandler(ingEventHandler)
 	store.informers.Endpoint.AddEventHandler(epEventHandler)
 	store.informers.Secret.AddEventHandler(secrEventHandler)
 	store.informers.ConfigMap.AddEventHandler(cmEventHandler)
 	store.informers.Service.AddEventHandler(serviceHandler)
+	if store.informers.Configuration != nil {
+		store.informers.Configuration.AddEventHandler(configurationEventHandler)
+	}
+	if store.informers.Namespace != nil {
+		store.informers.Namespace.AddEventHandler(namespaceEventHandler)
+	}
 
 	// do not wait for informers to read the configmap configuration
 	ns, name, _ := k8s.ParseNameNS(configmap)
@@ -655,9 +908,26 @@ func (s *k8sStore) syncIngress(ing *networkingv1beta1.Ingress) {
 
 	k8s.SetDefaultNGINXPathType(copyIng)
 
-	err := s.listers.IngressWithAnnotation.Update(&ingress.Ingress{
+	parsed, err := s.annotations.Extract(ing)
+
+	var annotationErrors []string
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		for _, e := range agg.Errors() {
+			annotationErrors = append(annotationErrors, e.Error())
+		}
+	}
+
+	for _, msg := range annotationErrors {
+		klog.Warningf("Ingress %v: %v", key, msg)
+		if s.recorder != nil {
+			s.recorder.Eventf(ing, corev1.EventTypeWarning, "AnnotationError", msg)
+		}
+	}
+
+	err = s.listers.IngressWithAnnotation.Update(&ingress.Ingress{
 		Ingress:           *copyIng,
-		ParsedAnnotations: s.annotations.Extract(ing),
+		ParsedAnnotations: parsed,
+		AnnotationErrors:  annotationErrors,
 	})
 	if err != nil {
 		klog.Error(err)
@@ -704,10 +974,65 @@ func (s *k8sStore) updateSecretIngressMap(ing *networkingv1beta1.Ingress) {
 		}
 	}
 
+	// the secrets referenced by the backend annotations of the Services
+	for _, svcName := range ingressServiceNames(ing) {
+		svc, err := s.listers.Service.ByKey(fmt.Sprintf("%v/%v", ing.Namespace, svcName))
+		if err != nil {
+			continue
+		}
+
+		if secrKey := annotations.ServiceProxySSLSecret(svc); secrKey != "" {
+			refSecrets = append(refSecrets, secrKey)
+		}
+	}
+
 	// populate map with all secret references
 	s.secretIngressMap.Insert(key, refSecrets...)
 }
 
+// syncServiceSecrets updates the secret references of the Ingresses using
+// a Service, which include the secrets referenced by the Service annotations,
+// and synchronizes these secrets with the local store and file system.
+func (s *k8sStore) syncServiceSecrets(svc *corev1.Service) {
+	for _, obj := range s.listers.IngressWithAnnotation.List() {
+		ing, err := s.getIngress(k8s.MetaNamespaceKey(obj))
+		if err != nil || ing.Namespace != svc.Namespace {
+			continue
+		}
+
+		for _, svcName := range ingressServiceNames(ing) {
+			if svcName == svc.Name {
+				s.updateSecretIngressMap(ing)
+				s.syncSecrets(ing)
+				break
+			}
+		}
+	}
+}
+
+// ingressServiceNames returns the names of the Services used as backends
+// by an Ingress
+func ingressServiceNames(ing *networkingv1beta1.Ingress) []string {
+	var names []string
+	if ing.Spec.Backend != nil && ing.Spec.Backend.ServiceName != "" {
+		names = append(names, ing.Spec.Backend.ServiceName)
+	}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.ServiceName != "" {
+				names = append(names, path.Backend.ServiceName)
+			}
+		}
+	}
+
+	return names
+}
+
 // objectRefAnnotationNsKey returns an object reference formatted as a
 // 'namespace/name' key from the given annotation name.
 func objectRefAnnotationNsKey(ann string, ing *networkingv1beta1.Ingress) (string, error) {
@@ -807,6 +1132,15 @@ func (s *k8sStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
 	return s.listers.ConfigMap.ByKey(key)
 }
 
+// GetNamespace returns the Namespace matching name.
+func (s *k8sStore) GetNamespace(name string) (*corev1.Namespace, error) {
+	if s.informers.Namespace == nil {
+		return nil, NotExistsError(name)
+	}
+
+	return s.listers.Namespace.ByKey(name)
+}
+
 // GetServiceEndpoints returns the Endpoints of a Service matching key.
 func (s *k8sStore) GetServiceEndpoints(key string) (*corev1.Endpoints, error) {
 	return s.listers.Endpoint.ByKey(key)
@@ -873,6 +1207,14 @@ func (s *k8sStore) GetBackendConfiguration() ngx_config.Configuration {
 	return s.backendConfig
 }
 
+// GetBackendConfigurationErrors returns the errors found reading the configmap
+func (s *k8sStore) GetBackendConfigurationErrors() []string {
+	s.backendConfigMu.RLock()
+	defer s.backendConfigMu.RUnlock()
+
+	return s.backendConfigErrors
+}
+
 func (s *k8sStore) setConfig(cmap *corev1.ConfigMap) {
 	s.backendConfigMu.Lock()
 	defer s.backendConfigMu.Unlock()
@@ -881,13 +1223,86 @@ func (s *k8sStore) setConfig(cmap *corev1.ConfigMap) {
 		return
 	}
 
-	s.backendConfig = ngx_template.ReadConfig(cmap.Data)
+	s.configMap = cmap
+	s.applyConfig()
+}
+
+// GetConfigurationResource returns the configuration resource
+func (s *k8sStore) GetConfigurationResource() *unstructured.Unstructured {
+	s.backendConfigMu.RLock()
+	defer s.backendConfigMu.RUnlock()
+
+	return s.configResource
+}
+
+// setConfigurationResource updates the configuration resource.
+// A nil value means the resource does not exist.
+func (s *k8sStore) setConfigurationResource(obj *unstructured.Unstructured) {
+	s.backendConfigMu.Lock()
+	defer s.backendConfigMu.Unlock()
+
+	s.configResource = obj
+	s.applyConfig()
+}
+
+// applyConfig updates the backend configuration using the configuration
+// ConfigMap and the configuration resource. Requires the backendConfigMu lock.
+func (s *k8sStore) applyConfig() {
+	cmap := s.configMap
+	if cmap == nil {
+		cmap = &corev1.ConfigMap{}
+	}
+
+	data := cmap.Data
+
+	var errs []error
+	if s.configResource != nil {
+		settings, err := resourceSettings(s.configResource)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		data = mergeSettings(cmap.Data, settings)
+	}
+
+	cfg, err := ngx_template.ParseConfig(data)
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = append(errs, agg.Errors()...)
+	}
+
+	// errors are reported in the source of the configuration
+	var source k8sruntime.Object
+	if s.configResource != nil {
+		source = s.configResource
+	} else if s.configMap != nil {
+		source = s.configMap
+	}
+
+	s.backendConfigErrors = nil
+	for _, e := range errs {
+		s.backendConfigErrors = append(s.backendConfigErrors, e.Error())
+		if s.recorder != nil && source != nil {
+			s.recorder.Eventf(source, corev1.EventTypeWarning, "ConfigurationError", e.Error())
+		}
+	}
+
+	if len(errs) > 0 && s.strictConfigValidation {
+		if s.backendConfig.Checksum != "" {
+			klog.Warningf("The configuration contains errors. Keeping the previous configuration")
+			return
+		}
+
+		klog.Warningf("The configuration contains errors. Using the default configuration")
+		cfg, _ = ngx_template.ParseConfig(map[string]string{})
+	}
+
+	s.backendConfig = cfg
 	if s.backendConfig.UseGeoIP2 && !nginx.GeoLite2DBExists() {
 		klog.Warning("The GeoIP2 feature is enabled but the databases are missing. Disabling")
 		s.backendConfig.UseGeoIP2 = false
 	}
 
-	s.writeSSLSessionTicketKey(cmap, "/etc/nginx/tickets.key")
+	s.writeSSLSessionTicketKey(&corev1.ConfigMap{ObjectMeta: cmap.ObjectMeta, Data: data}, "/etc/nginx/tickets.key")
 }
 
 // Run initiates the synchronization of the informers and the initial
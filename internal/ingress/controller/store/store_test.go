@@ -83,7 +83,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -160,7 +164,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -307,7 +315,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -410,7 +422,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -496,7 +512,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -604,7 +624,11 @@ func TestStore(t *testing.T) {
 			10*time.Minute,
 			clientSet,
 			updateCh,
-			false)
+			false,
+			false,
+			"",
+			nil,
+			"")
 
 		storer.Run(stopCh)
 
@@ -765,6 +789,8 @@ func newStore(t *testing.T) *k8sStore {
 			// add more listers if needed
 			Ingress:               IngressLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
 			IngressWithAnnotation: IngressWithAnnotationsLister{cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)},
+			Service:               ServiceLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
+			Secret:                SecretLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
 		},
 		sslStore:         NewSSLCertTracker(),
 		updateCh:         channels.NewRingChannel(10),
@@ -824,6 +850,44 @@ func TestUpdateSecretIngressMap(t *testing.T) {
 		}
 	})
 
+	t.Run("with annotation in the Service of a backend", func(t *testing.T) {
+		svc := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "testns",
+				Annotations: map[string]string{
+					parser.GetAnnotationWithPrefix("proxy-ssl-secret"): "testns/backend-ca",
+				},
+			},
+		}
+		s.listers.Service.Add(svc)
+		defer s.listers.Service.Delete(svc)
+
+		ing := ingTpl.DeepCopy()
+		ing.Spec = networking.IngressSpec{
+			Backend: &networking.IngressBackend{ServiceName: "backend", ServicePort: intstr.FromInt(443)},
+		}
+		s.listers.Ingress.Update(ing)
+		s.updateSecretIngressMap(ing)
+
+		if l := s.secretIngressMap.Len(); !(l == 1 && s.secretIngressMap.Has("testns/backend-ca")) {
+			t.Errorf("Expected \"testns/backend-ca\" to be the only referenced Secret (got %d)", l)
+		}
+
+		// a change of the Service annotation updates the references
+		s.listers.IngressWithAnnotation.Add(&ingress.Ingress{Ingress: *ing})
+		defer s.listers.IngressWithAnnotation.Delete(&ingress.Ingress{Ingress: *ing})
+
+		svc = svc.DeepCopy()
+		svc.Annotations[parser.GetAnnotationWithPrefix("proxy-ssl-secret")] = "testns/other-ca"
+		s.listers.Service.Update(svc)
+		s.syncServiceSecrets(svc)
+
+		if l := s.secretIngressMap.Len(); !(l == 1 && s.secretIngressMap.Has("testns/other-ca")) {
+			t.Errorf("Expected \"testns/other-ca\" to be the only referenced Secret (got %d)", l)
+		}
+	})
+
 	t.Run("with annotation in invalid format", func(t *testing.T) {
 		ing := ingTpl.DeepCopy()
 		ing.ObjectMeta.SetAnnotations(map[string]string{
@@ -18,7 +18,9 @@ package template
 
 import (
 	"fmt"
+	"math"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -28,6 +30,7 @@ import (
 	"github.com/mitchellh/hashstructure"
 	"github.com/mitchellh/mapstructure"
 
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -85,14 +88,77 @@ const (
 	maxNumberOfLuaDicts   = 100
 )
 
+type valueRange struct {
+	min int
+	max int
+}
+
+// validRanges contains the range of the numeric settings with a limited set of valid values
+var validRanges = map[string]valueRange{
+	"brotli-level":                          {0, 11},
+	"gzip-level":                            {1, 9},
+	"http2-max-concurrent-streams":          {1, math.MaxInt32},
+	"limit-conn-status-code":                {400, 599},
+	"limit-req-status-code":                 {400, 599},
+	"global-rate-limit-status-code":         {400, 599},
+	"keep-alive":                            {0, math.MaxInt32},
+	"keep-alive-requests":                   {0, math.MaxInt32},
+	"max-worker-connections":                {0, math.MaxInt32},
+	"max-worker-open-files":                 {0, math.MaxInt32},
+	"upstream-keepalive-connections":        {0, math.MaxInt32},
+	"upstream-keepalive-requests":           {0, math.MaxInt32},
+	"upstream-keepalive-timeout":            {0, math.MaxInt32},
+	"zipkin-collector-port":                 {1, 65535},
+	"jaeger-collector-port":                 {1, 65535},
+	"jaeger-sampler-port":                   {1, 65535},
+	"datadog-collector-port":                {1, 65535},
+	"syslog-port":                           {1, 65535},
+	"global-rate-limit-memcached-port":      {1, 65535},
+	"proxy-stream-next-upstream-tries":      {0, math.MaxInt32},
+	"global-rate-limit-memcached-pool-size": {0, math.MaxInt32},
+}
+
 // ReadConfig obtains the configuration defined by the user merged with the defaults.
 func ReadConfig(src map[string]string) config.Configuration {
+	to, _ := ParseConfig(src)
+	return to
+}
+
+// ParseConfig obtains the configuration defined by the user merged with the defaults.
+// The returned error contains every unknown key, invalid value and value out of the
+// allowed range. The invalid values are replaced with the default values.
+func ParseConfig(src map[string]string) (config.Configuration, error) {
 	conf := map[string]string{}
 	// we need to copy the configmap data because the content is altered
 	for k, v := range src {
 		conf[k] = v
 	}
 
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		err := fmt.Errorf("%v: %v", key, fmt.Sprintf(format, args...))
+		klog.Warning(err)
+		errs = append(errs, err)
+	}
+
+	for key, r := range validRanges {
+		val, ok := conf[key]
+		if !ok {
+			continue
+		}
+
+		j, err := strconv.Atoi(val)
+		if err != nil {
+			// reported decoding the value
+			continue
+		}
+
+		if j < r.min || j > r.max {
+			invalid(key, "%v is out of the allowed range [%v, %v]. Using the default.", j, r.min, r.max)
+			delete(conf, key)
+		}
+	}
+
 	to := config.NewDefault()
 	errors := make([]int, 0)
 	skipUrls := make([]string, 0)
@@ -119,15 +185,15 @@ func ReadConfig(src map[string]string) config.Configuration {
 			dictName := results[0]
 			size, err := strconv.Atoi(results[1])
 			if err != nil {
-				klog.Errorf("Ignoring non integer value %v for Lua dictionary %v: %v.", results[1], dictName, err)
+				invalid(luaSharedDictsKey, "Ignoring non integer value %v for Lua dictionary %v: %v.", results[1], dictName, err)
 				continue
 			}
 			if size > maxAllowedLuaDictSize {
-				klog.Errorf("Ignoring %v for Lua dictionary %v: maximum size is %v.", size, dictName, maxAllowedLuaDictSize)
+				invalid(luaSharedDictsKey, "Ignoring %v for Lua dictionary %v: maximum size is %v.", size, dictName, maxAllowedLuaDictSize)
 				continue
 			}
 			if len(luaSharedDicts)+1 > maxNumberOfLuaDicts {
-				klog.Errorf("Ignoring %v for Lua dictionary %v: can not configure more than %v dictionaries.",
+				invalid(luaSharedDictsKey, "Ignoring %v for Lua dictionary %v: can not configure more than %v dictionaries.",
 					size, dictName, maxNumberOfLuaDicts)
 				continue
 			}
@@ -147,7 +213,9 @@ func ReadConfig(src map[string]string) config.Configuration {
 		for _, i := range splitAndTrimSpace(val, ",") {
 			j, err := strconv.Atoi(i)
 			if err != nil {
-				klog.Warningf("%v is not a valid http code: %v", i, err)
+				invalid(customHTTPErrors, "%v is not a valid http code: %v", i, err)
+			} else if j < 300 || j > 599 {
+				invalid(customHTTPErrors, "error code %v is not valid for custom error pages", j)
 			} else {
 				errors = append(errors, j)
 			}
@@ -187,7 +255,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 					bindAddressIpv4List = append(bindAddressIpv4List, fmt.Sprintf("%v", ns))
 				}
 			} else {
-				klog.Warningf("%v is not a valid textual representation of an IP address", i)
+				invalid(bindAddress, "%v is not a valid textual representation of an IP address", i)
 			}
 		}
 	}
@@ -211,12 +279,12 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, httpRedirectCode)
 		j, err := strconv.Atoi(val)
 		if err != nil {
-			klog.Warningf("%v is not a valid HTTP code: %v", val, err)
+			invalid(httpRedirectCode, "%v is not a valid HTTP code: %v", val, err)
 		} else {
 			if validRedirectCodes.Has(j) {
 				to.HTTPRedirectCode = j
 			} else {
-				klog.Warningf("The code %v is not a valid as HTTP redirect code. Using the default.", val)
+				invalid(httpRedirectCode, "The code %v is not a valid as HTTP redirect code. Using the default.", val)
 			}
 		}
 	}
@@ -227,7 +295,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 
 		authURL, message := parser.StringToURL(val)
 		if authURL == nil {
-			klog.Warningf("Global auth location denied - %v.", message)
+			invalid(globalAuthURL, "Global auth location denied - %v.", message)
 		} else {
 			to.GlobalExternalAuth.URL = val
 			to.GlobalExternalAuth.Host = authURL.Hostname()
@@ -239,7 +307,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, globalAuthMethod)
 
 		if len(val) != 0 && !authreq.ValidMethod(val) {
-			klog.Warningf("Global auth location denied - %v.", "invalid HTTP method")
+			invalid(globalAuthMethod, "Global auth location denied - %v.", "invalid HTTP method")
 		} else {
 			to.GlobalExternalAuth.Method = val
 		}
@@ -251,7 +319,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 
 		signinURL, _ := parser.StringToURL(val)
 		if signinURL == nil {
-			klog.Warningf("Global auth location denied - %v.", "global-auth-signin setting is undefined and will not be set")
+			invalid(globalAuthSignin, "Global auth location denied - %v.", "global-auth-signin setting is undefined and will not be set")
 		} else {
 			to.GlobalExternalAuth.SigninURL = val
 		}
@@ -264,7 +332,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 		redirectParam := strings.TrimSpace(val)
 		dummySigninURL, _ := parser.StringToURL(fmt.Sprintf("%s?%s=dummy", to.GlobalExternalAuth.SigninURL, redirectParam))
 		if dummySigninURL == nil {
-			klog.Warningf("Global auth redirect parameter denied - %v.", "global-auth-signin-redirect-param setting is invalid and will not be set")
+			invalid(globalAuthSigninRedirectParam, "Global auth redirect parameter denied - %v.", "global-auth-signin-redirect-param setting is invalid and will not be set")
 		} else {
 			to.GlobalExternalAuth.SigninURLRedirectParam = redirectParam
 		}
@@ -278,7 +346,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 			harr := splitAndTrimSpace(val, ",")
 			for _, header := range harr {
 				if !authreq.ValidHeader(header) {
-					klog.Warningf("Global auth location denied - %v.", "invalid headers list")
+					invalid(globalAuthResponseHeaders, "Global auth location denied - %v.", "invalid headers list")
 				} else {
 					responseHeaders = append(responseHeaders, header)
 				}
@@ -308,7 +376,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 
 		cacheDurations, err := authreq.ParseStringToCacheDurations(val)
 		if err != nil {
-			klog.Warningf("Global auth location denied - %s", err)
+			invalid(globalAuthCacheDuration, "Global auth location denied - %s", err)
 		}
 		to.GlobalExternalAuth.AuthCacheDuration = cacheDurations
 	}
@@ -318,7 +386,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, proxyHeaderTimeout)
 		duration, err := time.ParseDuration(val)
 		if err != nil {
-			klog.Warningf("proxy-protocol-header-timeout of %v encountered an error while being parsed %v. Switching to use default value instead.", val, err)
+			invalid(proxyHeaderTimeout, "proxy-protocol-header-timeout of %v encountered an error while being parsed %v. Switching to use default value instead.", val, err)
 		} else {
 			to.ProxyProtocolHeaderTimeout = duration
 		}
@@ -329,7 +397,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, proxyStreamResponses)
 		j, err := strconv.Atoi(val)
 		if err != nil {
-			klog.Warningf("%v is not a valid number: %v", val, err)
+			invalid(proxyStreamResponses, "%v is not a valid number: %v", val, err)
 		} else {
 			streamResponses = j
 		}
@@ -375,7 +443,6 @@ func ReadConfig(src map[string]string) config.Configuration {
 	to.LuaSharedDicts = luaSharedDicts
 
 	config := &mapstructure.DecoderConfig{
-		Metadata:         nil,
 		WeaklyTypedInput: true,
 		Result:           &to,
 		TagName:          "json",
@@ -387,7 +454,19 @@ func ReadConfig(src map[string]string) config.Configuration {
 	}
 	err = decoder.Decode(conf)
 	if err != nil {
-		klog.Warningf("unexpected error merging defaults: %v", err)
+		if merr, ok := err.(*mapstructure.Error); ok {
+			for _, e := range merr.Errors {
+				err := fmt.Errorf("%v", e)
+				klog.Warning(err)
+				errs = append(errs, err)
+			}
+		} else {
+			klog.Warningf("unexpected error merging defaults: %v", err)
+		}
+	}
+
+	for _, key := range unknownKeys(conf) {
+		invalid(key, "unknown configuration key")
 	}
 
 	hash, err := hashstructure.Hash(to, &hashstructure.HashOptions{
@@ -399,7 +478,36 @@ func ReadConfig(src map[string]string) config.Configuration {
 
 	to.Checksum = fmt.Sprintf("%v", hash)
 
-	return to
+	return to, utilerrors.NewAggregate(errs)
+}
+
+// unknownKeys returns the sorted keys of the configuration not present in
+// config.Configuration. The keys are decoded without values because
+// mapstructure does not report the unused keys when a value is invalid.
+func unknownKeys(conf map[string]string) []string {
+	keys := make(map[string]interface{}, len(conf))
+	for key := range conf {
+		keys[key] = nil
+	}
+
+	metadata := &mapstructure.Metadata{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Metadata: metadata,
+		Result:   &config.Configuration{},
+		TagName:  "json",
+	})
+	if err != nil {
+		klog.Warningf("unexpected error checking the configuration keys: %v", err)
+		return nil
+	}
+
+	if err := decoder.Decode(keys); err != nil {
+		klog.Warningf("unexpected error checking the configuration keys: %v", err)
+		return nil
+	}
+
+	sort.Strings(metadata.Unused)
+	return metadata.Unused
 }
 
 func filterErrors(codes []int) []int {
@@ -19,11 +19,13 @@ package template
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/mitchellh/hashstructure"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
@@ -418,3 +420,53 @@ func TestSplitAndTrimSpace(t *testing.T) {
 		}
 	}
 }
+
+func TestParseConfigErrors(t *testing.T) {
+	def := config.NewDefault()
+
+	cfg, err := ParseConfig(map[string]string{
+		"proxy-read-timout":  "30",
+		"keep-alive":         "forever",
+		"gzip-level":         "20",
+		"proxy-send-timeout": "30",
+	})
+
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		t.Fatalf("expected an aggregate error but returned %v", err)
+	}
+
+	if len(agg.Errors()) != 3 {
+		t.Fatalf("expected 3 errors but returned %v", agg.Errors())
+	}
+
+	for _, key := range []string{"proxy-read-timout", "keep-alive", "gzip-level"} {
+		found := false
+		for _, e := range agg.Errors() {
+			if strings.Contains(e.Error(), key) {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("expected an error for the key %v but returned %v", key, agg.Errors())
+		}
+	}
+
+	if cfg.KeepAlive != def.KeepAlive {
+		t.Errorf("expected the default keep-alive %v but returned %v", def.KeepAlive, cfg.KeepAlive)
+	}
+
+	if cfg.GzipLevel != def.GzipLevel {
+		t.Errorf("expected the default gzip-level %v but returned %v", def.GzipLevel, cfg.GzipLevel)
+	}
+
+	if cfg.ProxySendTimeout != 30 {
+		t.Errorf("expected proxy-send-timeout 30 but returned %v", cfg.ProxySendTimeout)
+	}
+
+	_, err = ParseConfig(map[string]string{"proxy-send-timeout": "30"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"sort"
+	"strings"
+
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+// LocationRoute contains the settings of a location applied by Lua
+// when the locations of a server are routed dynamically
+type LocationRoute struct {
+	Path           string               `json:"path"`
+	Exact          bool                 `json:"exact,omitempty"`
+	Upstream       string               `json:"upstream"`
+	Namespace      string               `json:"namespace"`
+	Ingress        string               `json:"ingress"`
+	Service        string               `json:"service"`
+	ServicePort    string               `json:"servicePort"`
+	LocationPath   string               `json:"locationPath"`
+	Denied         bool                 `json:"denied,omitempty"`
+	Whitelist      []string             `json:"whitelist,omitempty"`
+	Redirect       *RouteRedirect       `json:"redirect,omitempty"`
+	GlobalThrottle *RouteGlobalThrottle `json:"globalThrottle,omitempty"`
+}
+
+// RouteRedirect contains the redirect of a location
+type RouteRedirect struct {
+	URL  string `json:"url"`
+	Code int    `json:"code"`
+}
+
+// RouteGlobalThrottle contains the global rate limit of a location
+type RouteGlobalThrottle struct {
+	Namespace    string   `json:"namespace"`
+	Limit        int      `json:"limit"`
+	WindowSize   int      `json:"windowSize"`
+	Key          string   `json:"key"`
+	IgnoredCIDRs []string `json:"ignoredCIDRs,omitempty"`
+}
+
+// RoutedLocations returns the locations of a server routed by Lua instead
+// of a location block in nginx.conf. The first location is the root location
+// of the server, the only one rendered, which handles the requests of all
+// the routed locations. Locations are routed only if they have the same
+// settings as the root location, except the settings applied by Lua.
+// Returns nil if the server does not use dynamic locations.
+func RoutedLocations(server *ingress.Server, cfg config.Configuration) []*ingress.Location {
+	if !cfg.EnableDynamicLocations || server == nil {
+		return nil
+	}
+
+	// the order of regular expressions cannot be reproduced by Lua
+	if enforceRegexModifier(server.Locations) {
+		return nil
+	}
+
+	var root *ingress.Location
+	for _, location := range server.Locations {
+		if location.Path == slash && !isExactLocation(location) {
+			root = location
+			break
+		}
+	}
+
+	if root == nil || root.Denied != nil || !isRoutable(root, cfg) {
+		return nil
+	}
+
+	settings := WithoutRoutedSettings(root)
+
+	routed := []*ingress.Location{root}
+	for _, location := range server.Locations {
+		if location == root || !isRoutable(location, cfg) {
+			continue
+		}
+
+		if WithoutRoutedSettings(location).Equal(settings) {
+			routed = append(routed, location)
+		}
+	}
+
+	return routed
+}
+
+// WithoutRoutedSettings returns a copy of the location without the
+// settings applied by Lua when the location is routed dynamically
+func WithoutRoutedSettings(location *ingress.Location) *ingress.Location {
+	copyOfLocation := *location
+
+	copyOfLocation.Path = ""
+	copyOfLocation.PathType = nil
+	copyOfLocation.IsDefBackend = false
+	copyOfLocation.Ingress = nil
+	copyOfLocation.IngressPath = ""
+	copyOfLocation.Backend = ""
+	copyOfLocation.Service = nil
+	copyOfLocation.Port = intstr.IntOrString{}
+	copyOfLocation.Denied = nil
+	copyOfLocation.Whitelist = ipwhitelist.SourceRange{}
+	copyOfLocation.Redirect.URL = ""
+	copyOfLocation.Redirect.Code = 0
+	copyOfLocation.GlobalRateLimit = globalratelimit.Config{}
+
+	return &copyOfLocation
+}
+
+// isRoutable returns true if the location does not use features that
+// require a location block or depend on the path of the location
+func isRoutable(location *ingress.Location, cfg config.Configuration) bool {
+	if location.BackendProtocol != "" && location.BackendProtocol != "HTTP" {
+		return false
+	}
+
+	if strings.HasPrefix(location.Backend, "custom-default-backend-") {
+		return false
+	}
+
+	// authentication changes the order of the access checks
+	if location.Satisfy != "" || location.BasicDigestAuth.Secured || location.ExternalAuth.URL != "" {
+		return false
+	}
+
+	// the ModSecurity rule of each location tags the audit events with the Ingress
+	if cfg.EnableModsecurityAuditEvents && (cfg.EnableModsecurity || location.ModSecurity.Enable) {
+		return false
+	}
+
+	if isLocationInLocationList(location, cfg.NoTLSRedirectLocations) ||
+		isLocationInLocationList(location, cfg.NoAuthLocations) {
+		return false
+	}
+
+	return true
+}
+
+func isExactLocation(location *ingress.Location) bool {
+	return location.PathType != nil && *location.PathType == networkingv1beta1.PathTypeExact
+}
+
+// LocationRoutes returns the routes of the servers using dynamic locations.
+// The routes of a server are sorted in the order used to match a request:
+// exact locations first, followed by the prefix locations from the longest
+// to the shortest path.
+func LocationRoutes(servers []*ingress.Server, cfg config.Configuration) map[string][]LocationRoute {
+	routes := make(map[string][]LocationRoute)
+
+	for _, server := range servers {
+		locations := RoutedLocations(server, cfg)
+		if len(locations) == 0 {
+			continue
+		}
+
+		serverRoutes := make([]LocationRoute, 0, len(locations))
+		for _, location := range locations {
+			serverRoutes = append(serverRoutes, locationRoute(server.Hostname, location))
+		}
+
+		sort.SliceStable(serverRoutes, func(i, j int) bool {
+			if serverRoutes[i].Exact != serverRoutes[j].Exact {
+				return serverRoutes[i].Exact
+			}
+
+			if len(serverRoutes[i].Path) != len(serverRoutes[j].Path) {
+				return len(serverRoutes[i].Path) > len(serverRoutes[j].Path)
+			}
+
+			return serverRoutes[i].Path < serverRoutes[j].Path
+		})
+
+		routes[server.Hostname] = serverRoutes
+	}
+
+	return routes
+}
+
+func locationRoute(hostname string, location *ingress.Location) LocationRoute {
+	info := getIngressInformation(location.Ingress, hostname, location.IngressPath)
+
+	route := LocationRoute{
+		Path:         location.Path,
+		Exact:        isExactLocation(location),
+		Upstream:     location.Backend,
+		Namespace:    info.Namespace,
+		Ingress:      info.Rule,
+		Service:      info.Service,
+		ServicePort:  info.ServicePort,
+		LocationPath: info.Path,
+		Denied:       location.Denied != nil,
+		Whitelist:    location.Whitelist.CIDR,
+	}
+
+	if location.Redirect.URL != "" {
+		route.Redirect = &RouteRedirect{
+			URL:  location.Redirect.URL,
+			Code: location.Redirect.Code,
+		}
+	}
+
+	if location.GlobalRateLimit.Limit > 0 && location.GlobalRateLimit.WindowSize > 0 {
+		route.GlobalThrottle = &RouteGlobalThrottle{
+			Namespace:    location.GlobalRateLimit.Namespace,
+			Limit:        location.GlobalRateLimit.Limit,
+			WindowSize:   location.GlobalRateLimit.WindowSize,
+			Key:          location.GlobalRateLimit.Key,
+			IgnoredCIDRs: location.GlobalRateLimit.IgnoredCIDRs,
+		}
+	}
+
+	return route
+}
+
+// dynamicRootLocation returns the root location of a server using
+// dynamic locations, or nil if the locations are rendered in nginx.conf
+func dynamicRootLocation(s interface{}, c interface{}) *ingress.Location {
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Server' type but %T was returned", s)
+		return nil
+	}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return nil
+	}
+
+	routed := RoutedLocations(server, cfg)
+	if len(routed) == 0 {
+		return nil
+	}
+
+	return routed[0]
+}
+
+// renderedLocations returns the locations of a server that require a
+// location block in nginx.conf
+func renderedLocations(s interface{}, c interface{}) []*ingress.Location {
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Server' type but %T was returned", s)
+		return []*ingress.Location{}
+	}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return server.Locations
+	}
+
+	routed := RoutedLocations(server, cfg)
+	if len(routed) == 0 {
+		return server.Locations
+	}
+
+	isRouted := make(map[*ingress.Location]bool, len(routed))
+	for _, location := range routed[1:] {
+		isRouted[location] = true
+	}
+
+	locations := make([]*ingress.Location, 0, len(server.Locations)-len(routed)+1)
+	for _, location := range server.Locations {
+		if !isRouted[location] {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
+
+// isDynamicRootLocation returns true if the location is the root
+// location of a server using dynamic locations
+func isDynamicRootLocation(l interface{}, r interface{}) bool {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return false
+	}
+
+	root, ok := r.(*ingress.Location)
+	if !ok || root == nil {
+		return false
+	}
+
+	return location == root
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func dynamicLocationsServer() *ingress.Server {
+	exact := networking.PathTypeExact
+
+	return &ingress.Server{
+		Hostname: "example.com",
+		Locations: []*ingress.Location{
+			{Path: "/", Backend: "default-root-80"},
+			{Path: "/api", Backend: "default-api-80", Whitelist: ipwhitelist.SourceRange{CIDR: []string{"10.0.0.0/8"}}},
+			{Path: "/health", PathType: &exact, Backend: "default-health-80"},
+			{Path: "/auth", Backend: "default-auth-80", ExternalAuth: authreq.Config{URL: "http://auth.example.com"}},
+			{Path: "/limited", Backend: "default-limited-80", RateLimit: ratelimit.Config{RPS: ratelimit.Zone{Name: "limited", Limit: 1}}},
+			{Path: "/.well-known/acme-challenge", Backend: "default-acme-80"},
+		},
+	}
+}
+
+func TestRoutedLocations(t *testing.T) {
+	cfg := config.NewDefault()
+	server := dynamicLocationsServer()
+
+	if routed := RoutedLocations(server, cfg); routed != nil {
+		t.Errorf("expected no routed locations when dynamic locations are disabled but %v returned", len(routed))
+	}
+
+	cfg.EnableDynamicLocations = true
+
+	routed := RoutedLocations(server, cfg)
+	paths := []string{}
+	for _, location := range routed {
+		paths = append(paths, location.Path)
+	}
+
+	expected := []string{"/", "/api", "/health"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected routed locations %v but %v returned", expected, paths)
+	}
+
+	regexServer := dynamicLocationsServer()
+	regexServer.Locations[1].Rewrite = rewrite.Config{UseRegex: true}
+	if routed := RoutedLocations(regexServer, cfg); routed != nil {
+		t.Errorf("expected no routed locations when the server uses regular expressions but %v returned", len(routed))
+	}
+
+	authServer := dynamicLocationsServer()
+	authServer.Locations[0].ExternalAuth = authreq.Config{URL: "http://auth.example.com"}
+	if routed := RoutedLocations(authServer, cfg); routed != nil {
+		t.Errorf("expected no routed locations when the root location uses authentication but %v returned", len(routed))
+	}
+
+	auditCfg := cfg
+	auditCfg.EnableModsecurity = true
+	auditCfg.EnableModsecurityAuditEvents = true
+	if routed := RoutedLocations(server, auditCfg); routed != nil {
+		t.Errorf("expected no routed locations when ModSecurity audit events are enabled but %v returned", len(routed))
+	}
+}
+
+func TestRenderedLocations(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.EnableDynamicLocations = true
+	server := dynamicLocationsServer()
+
+	root := dynamicRootLocation(server, cfg)
+	if root != server.Locations[0] {
+		t.Fatalf("expected the root location to be the dynamic root location")
+	}
+
+	paths := []string{}
+	for _, location := range renderedLocations(server, cfg) {
+		paths = append(paths, location.Path)
+		if isDynamicRootLocation(location, root) != (location == root) {
+			t.Errorf("unexpected result of isDynamicRootLocation for location %v", location.Path)
+		}
+	}
+
+	expected := []string{"/", "/auth", "/limited", "/.well-known/acme-challenge"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected rendered locations %v but %v returned", expected, paths)
+	}
+
+	cfg.EnableDynamicLocations = false
+	if len(renderedLocations(server, cfg)) != len(server.Locations) {
+		t.Errorf("expected all the locations to be rendered when dynamic locations are disabled")
+	}
+
+	if isDynamicRootLocation(server.Locations[0], dynamicRootLocation(server, cfg)) {
+		t.Errorf("expected no dynamic root location when dynamic locations are disabled")
+	}
+}
+
+func TestLocationRoutes(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.EnableDynamicLocations = true
+
+	server := dynamicLocationsServer()
+	server.Locations = append(server.Locations,
+		&ingress.Location{
+			Path:     "/old",
+			Backend:  "default-old-80",
+			Redirect: redirect.Config{URL: "https://example.org", Code: 301},
+		},
+		&ingress.Location{
+			Path:            "/throttled",
+			Backend:         "default-throttled-80",
+			GlobalRateLimit: globalratelimit.Config{Namespace: "abc", Limit: 10, WindowSize: 60, Key: "$remote_addr"},
+		},
+	)
+
+	routes := LocationRoutes([]*ingress.Server{server, {Hostname: "static.example.com"}}, cfg)
+	if len(routes) != 1 {
+		t.Fatalf("expected routes for one server but %v returned", len(routes))
+	}
+
+	paths := []string{}
+	for _, route := range routes["example.com"] {
+		paths = append(paths, route.Path)
+	}
+
+	expected := []string{"/health", "/throttled", "/api", "/old", "/"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected routes %v but %v returned", expected, paths)
+	}
+
+	byPath := map[string]LocationRoute{}
+	for _, route := range routes["example.com"] {
+		byPath[route.Path] = route
+	}
+
+	if !byPath["/health"].Exact {
+		t.Errorf("expected an exact route for /health")
+	}
+
+	if !reflect.DeepEqual(byPath["/api"].Whitelist, []string{"10.0.0.0/8"}) {
+		t.Errorf("expected the whitelist of /api but %v returned", byPath["/api"].Whitelist)
+	}
+
+	redirectRoute := byPath["/old"].Redirect
+	if redirectRoute == nil || redirectRoute.URL != "https://example.org" || redirectRoute.Code != 301 {
+		t.Errorf("expected a redirect for /old but %v returned", redirectRoute)
+	}
+
+	throttle := byPath["/throttled"].GlobalThrottle
+	if throttle == nil || throttle.Limit != 10 || throttle.WindowSize != 60 || throttle.Key != "$remote_addr" {
+		t.Errorf("expected a global throttle for /throttled but %v returned", throttle)
+	}
+
+	if byPath["/"].Upstream != "default-root-80" || byPath["/"].Redirect != nil || byPath["/"].GlobalThrottle != nil {
+		t.Errorf("unexpected route for /: %v", byPath["/"])
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+// SettingType defines the OpenAPI type of the value of a setting
+type SettingType struct {
+	// Type contains the type of the value: string, boolean, integer, number or array
+	Type string
+	// Items contains the type of the elements of an array
+	Items string
+}
+
+var (
+	stringSetting      = SettingType{Type: "string"}
+	stringArraySetting = SettingType{Type: "array", Items: "string"}
+)
+
+// specialSettings contains the settings that are not decoded directly
+// into a field of the configuration
+var specialSettings = map[string]SettingType{
+	bindAddress:                   stringArraySetting,
+	globalAuthURL:                 stringSetting,
+	globalAuthMethod:              stringSetting,
+	globalAuthSignin:              stringSetting,
+	globalAuthSigninRedirectParam: stringSetting,
+	globalAuthResponseHeaders:     stringArraySetting,
+	globalAuthRequestRedirect:     stringSetting,
+	globalAuthSnippet:             stringSetting,
+	globalAuthCacheKey:            stringSetting,
+	globalAuthCacheDuration:       stringSetting,
+	luaSharedDictsKey:             stringSetting,
+	proxyHeaderTimeout:            stringSetting,
+}
+
+// Settings returns the settings accepted in the configuration ConfigMap
+// and the type of their values
+func Settings() map[string]SettingType {
+	settings := make(map[string]SettingType)
+	addStructSettings(reflect.TypeOf(config.Configuration{}), settings)
+
+	for name, setting := range specialSettings {
+		settings[name] = setting
+	}
+
+	return settings
+}
+
+// addStructSettings adds the fields of a struct with a json tag. Fields
+// containing structs, maps or pointers are not settings and are ignored.
+func addStructSettings(t reflect.Type, settings map[string]SettingType) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if field.Anonymous && len(tag) > 1 && tag[1] == "squash" {
+			addStructSettings(field.Type, settings)
+			continue
+		}
+
+		name := tag[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if field.Type == reflect.TypeOf(time.Duration(0)) {
+			settings[name] = stringSetting
+			continue
+		}
+
+		if kind := openAPIType(field.Type.Kind()); kind != "" {
+			settings[name] = SettingType{Type: kind}
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Slice {
+			if items := openAPIType(field.Type.Elem().Kind()); items != "" {
+				settings[name] = SettingType{Type: "array", Items: items}
+			}
+		}
+	}
+}
+
+func openAPIType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return ""
+	}
+}
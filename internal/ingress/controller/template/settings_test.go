@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+)
+
+func TestSettings(t *testing.T) {
+	settings := Settings()
+
+	expected := map[string]SettingType{
+		"proxy-body-size":               {Type: "string"},
+		"use-gzip":                      {Type: "boolean"},
+		"keep-alive":                    {Type: "integer"},
+		"custom-http-errors":            {Type: "array", Items: "integer"},
+		"block-cidrs":                   {Type: "array", Items: "string"},
+		"global-auth-url":               {Type: "string"},
+		"bind-address":                  {Type: "array", Items: "string"},
+		"proxy-protocol-header-timeout": {Type: "string"},
+	}
+
+	for name, setting := range expected {
+		if settings[name] != setting {
+			t.Errorf("expected %v to be %v but returned %v", name, setting, settings[name])
+		}
+	}
+
+	for _, name := range []string{"checksum", "global-external-auth"} {
+		if _, ok := settings[name]; ok {
+			t.Errorf("unexpected setting %v", name)
+		}
+	}
+}
@@ -43,10 +43,15 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
+	"k8s.io/ingress-nginx/internal/nginx"
 )
 
 const (
@@ -177,13 +182,21 @@ var (
 		"shouldLoadModSecurityModule":        shouldLoadModSecurityModule,
 		"buildHTTPListener":                  buildHTTPListener,
 		"buildHTTPSListener":                 buildHTTPSListener,
+		"buildHTTP3Listener":                 buildHTTP3Listener,
+		"isHTTP3Server":                      isHTTP3Server,
+		"buildAltSvcHeader":                  buildAltSvcHeader,
 		"buildOpentracingForLocation":        buildOpentracingForLocation,
 		"shouldLoadOpentracingModule":        shouldLoadOpentracingModule,
 		"buildModSecurityForLocation":        buildModSecurityForLocation,
+		"buildModSecurityAuditLog":           buildModSecurityAuditLog,
 		"buildMirrorLocations":               buildMirrorLocations,
 		"shouldLoadAuthDigestModule":         shouldLoadAuthDigestModule,
 		"shouldLoadInfluxDBModule":           shouldLoadInfluxDBModule,
 		"buildServerName":                    buildServerName,
+		"buildCorsOriginRegex":               buildCorsOriginRegex,
+		"dynamicRootLocation":                dynamicRootLocation,
+		"renderedLocations":                  renderedLocations,
+		"isDynamicRootLocation":              isDynamicRootLocation,
 	}
 )
 
@@ -344,6 +357,7 @@ func locationConfigForLua(l interface{}, a interface{}) string {
 		force_no_ssl_redirect = %t,
 		use_port_in_redirects = %t,
 		global_throttle = { namespace = "%v", limit = %d, window_size = %d, key = %v, ignored_cidrs = %v },
+		query_params = %v,
 	}`,
 		location.Rewrite.ForceSSLRedirect,
 		location.Rewrite.SSLRedirect,
@@ -354,9 +368,44 @@ func locationConfigForLua(l interface{}, a interface{}) string {
 		location.GlobalRateLimit.WindowSize,
 		parseComplexNginxVarIntoLuaTable(location.GlobalRateLimit.Key),
 		ignoredCIDRs,
+		queryParamsForLua(location.Rewrite.QueryParams),
 	)
 }
 
+// queryParamsForLua formats the query string operations of a location
+// into a Lua table represented as string
+func queryParamsForLua(qp rewrite.QueryParams) string {
+	if qp.Empty() {
+		return "nil"
+	}
+
+	remove, err := convertGoSliceIntoLuaTable(qp.Remove, false)
+	if err != nil {
+		klog.Errorf("failed to convert %v into Lua table: %q", qp.Remove, err)
+		remove = "{}"
+	}
+
+	return fmt.Sprintf("{ remove = %v, rename = %v, add = %v }",
+		remove, convertGoMapIntoLuaTable(qp.Rename), convertGoMapIntoLuaTable(qp.Add))
+}
+
+// convertGoMapIntoLuaTable returns a Lua table with the content of the map,
+// sorted by key to produce a stable output
+func convertGoMapIntoLuaTable(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, fmt.Sprintf("[\"%v\"] = \"%v\"", k, m[k]))
+	}
+
+	return fmt.Sprintf("{ %v }", strings.Join(entries, ", "))
+}
+
 // buildResolvers returns the resolvers reading the /etc/resolv.conf file
 func buildResolvers(res interface{}, disableIpv6 interface{}) string {
 	// NGINX need IPV6 addresses to be surrounded by brackets
@@ -572,8 +621,16 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 	// defProxyPass returns the default proxy_pass, just the name of the upstream
 	defProxyPass := fmt.Sprintf("%v %s%s;", proxyPass, proto, upstreamName)
 
+	// rewrite rules defined using the rewrite-rules annotation are applied
+	// in order and before the rewrite-target annotation
+	rewriteRules := buildRewriteRules(location.Rewrite.Rules)
+
 	// if the path in the ingress rule is equals to the target: no special rewrite
 	if path == location.Rewrite.Target {
+		if len(rewriteRules) > 0 {
+			return fmt.Sprintf("\n%v%v", rewriteRules, defProxyPass)
+		}
+
 		return defProxyPass
 	}
 
@@ -585,14 +642,33 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		}
 
 		return fmt.Sprintf(`
-rewrite "(?i)%s" %s break;
-%v%v %s%s;`, path, location.Rewrite.Target, xForwardedPrefix, proxyPass, proto, upstreamName)
+%vrewrite "(?i)%s" %s break;
+%v%v %s%s;`, rewriteRules, path, location.Rewrite.Target, xForwardedPrefix, proxyPass, proto, upstreamName)
+	}
+
+	if len(rewriteRules) > 0 {
+		return fmt.Sprintf("\n%v%v", rewriteRules, defProxyPass)
 	}
 
 	// default proxy_pass
 	return defProxyPass
 }
 
+// buildRewriteRules returns the rewrite directives, one per line, for
+// the rules defined in the rewrite-rules annotation
+func buildRewriteRules(rules []rewrite.Rule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for _, rule := range rules {
+		buf.WriteString(fmt.Sprintf("rewrite \"%s\" %s %s;\n", rule.Regex, rule.Replacement, rule.Flag))
+	}
+
+	return buf.String()
+}
+
 func filterRateLimits(input interface{}) []ratelimit.Config {
 	ratelimits := []ratelimit.Config{}
 	found := sets.String{}
@@ -1263,6 +1339,140 @@ func buildHTTPSListener(t interface{}, s interface{}) string {
 	return strings.Join(out, "\n")
 }
 
+// sslProtocolsRegex matches the ssl_protocols directive of a snippet
+var sslProtocolsRegex = regexp.MustCompile(`(?m)^\s*ssl_protocols\s+([^;]+);`)
+
+// isHTTP3Server returns true if the server is configured with HTTP/3.
+// QUIC requires TLSv1.3 in the SSL protocols of the server, defined by the
+// configuration option ssl-protocols or the server-snippet annotation, and
+// the server must terminate TLS, so SSL Passthrough is not supported.
+func isHTTP3Server(t interface{}, s interface{}) bool {
+	tc, ok := t.(config.TemplateConfig)
+	if !ok {
+		klog.Errorf("expected a 'config.TemplateConfig' type but %T was returned", t)
+		return false
+	}
+
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	if !tc.IsHTTP3Enabled || server.SSLPassthrough {
+		return false
+	}
+
+	protocols := tc.Cfg.SSLProtocols
+	if matches := sslProtocolsRegex.FindAllStringSubmatch(server.ServerSnippet, -1); len(matches) > 0 {
+		protocols = matches[len(matches)-1][1]
+	}
+
+	for _, protocol := range strings.Fields(protocols) {
+		if protocol == "TLSv1.3" {
+			return true
+		}
+	}
+
+	klog.V(3).InfoS("HTTP/3 requires TLSv1.3, skipping QUIC listener", "server", server.Hostname, "protocols", protocols)
+	return false
+}
+
+// buildHTTP3Listener returns the QUIC listen directives used to serve HTTP/3.
+// The UDP port is always bound by NGINX, even if SSL Passthrough is enabled,
+// and proxy_protocol is not supported over QUIC.
+func buildHTTP3Listener(t interface{}, s interface{}) string {
+	var out []string
+
+	tc, ok := t.(config.TemplateConfig)
+	if !ok {
+		klog.Errorf("expected a 'config.TemplateConfig' type but %T was returned", t)
+		return ""
+	}
+
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Server' type but %T was returned", s)
+		return ""
+	}
+
+	if !isHTTP3Server(tc, server) {
+		return ""
+	}
+
+	co := ""
+	if server.Hostname == "_" {
+		co = "default_server"
+		if tc.Cfg.ReusePort {
+			co = "default_server reuseport"
+		}
+	}
+
+	addrV4 := []string{""}
+	if len(tc.Cfg.BindAddressIpv4) > 0 {
+		addrV4 = tc.Cfg.BindAddressIpv4
+	}
+
+	out = append(out, quicListener(addrV4, co, tc)...)
+
+	if !tc.IsIPV6Enabled {
+		return strings.Join(out, "\n")
+	}
+
+	addrV6 := []string{"[::]"}
+	if len(tc.Cfg.BindAddressIpv6) > 0 {
+		addrV6 = tc.Cfg.BindAddressIpv6
+	}
+
+	out = append(out, quicListener(addrV6, co, tc)...)
+
+	return strings.Join(out, "\n")
+}
+
+func quicListener(addresses []string, co string, tc config.TemplateConfig) []string {
+	out := make([]string, 0)
+	for _, address := range addresses {
+		lo := []string{"listen"}
+
+		if address == "" {
+			lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.QUIC))
+		} else {
+			lo = append(lo, fmt.Sprintf("%v:%v", address, tc.ListenPorts.QUIC))
+		}
+
+		lo = append(lo, "http3")
+
+		if co != "" {
+			lo = append(lo, co)
+		}
+
+		lo = append(lo, ";")
+		out = append(out, strings.Join(lo, " "))
+	}
+
+	return out
+}
+
+// buildAltSvcHeader returns the directive used to advertise HTTP/3 support
+// to clients connected using HTTP/1.1 or HTTP/2. NGINX implements the
+// draft 29 of HTTP/3, advertised using the h3-29 protocol identifier.
+// The header must be set in every location, because the more_set_headers
+// directives of a server are not inherited by the locations that define
+// their own.
+func buildAltSvcHeader(t interface{}, s interface{}) string {
+	tc, ok := t.(config.TemplateConfig)
+	if !ok {
+		klog.Errorf("expected a 'config.TemplateConfig' type but %T was returned", t)
+		return ""
+	}
+
+	if !isHTTP3Server(tc, s) {
+		return ""
+	}
+
+	return fmt.Sprintf(`more_set_headers 'Alt-Svc: h3-29=":%v"; ma=%v';`, tc.ListenPorts.QUIC, tc.Cfg.HTTP3AltSvcMaxAge)
+}
+
 func commonListenOptions(template config.TemplateConfig, hostname string) string {
 	var out []string
 
@@ -1430,6 +1640,17 @@ func buildModSecurityForLocation(cfg config.Configuration, location *ingress.Loc
 `, location.ModSecurity.Snippet))
 	}
 
+	rules := buildModSecurityRules(location.ModSecurity)
+	if cfg.EnableModsecurityAuditEvents && location.Ingress != nil {
+		rules += buildModSecurityIngressRule(location.Ingress)
+	}
+
+	if rules != "" {
+		buffer.WriteString(fmt.Sprintf(`modsecurity_rules '
+%v';
+`, rules))
+	}
+
 	if location.ModSecurity.TransactionID != "" {
 		buffer.WriteString(fmt.Sprintf(`modsecurity_transaction_id "%v";
 `, location.ModSecurity.TransactionID))
@@ -1438,6 +1659,10 @@ func buildModSecurityForLocation(cfg config.Configuration, location *ingress.Loc
 	if !isMSEnabled {
 		buffer.WriteString(`modsecurity_rules_file /etc/nginx/modsecurity/modsecurity.conf;
 `)
+
+		if cfg.EnableModsecurityAuditEvents {
+			buffer.WriteString(buildModSecurityAuditLog())
+		}
 	}
 
 	if !cfg.EnableOWASPCoreRules && location.ModSecurity.OWASPRules {
@@ -1445,35 +1670,149 @@ func buildModSecurityForLocation(cfg config.Configuration, location *ingress.Loc
 `)
 	}
 
+	// the rule engine must be configured after modsecurity.conf, which turns it on
+	if location.ModSecurity.Mode != "" {
+		buffer.WriteString(fmt.Sprintf(`modsecurity_rules 'SecRuleEngine %v';
+`, location.ModSecurity.Mode))
+	}
+
 	return buffer.String()
 }
 
+// buildModSecurityRules returns the ModSecurity rules with the paranoia
+// level, anomaly thresholds and rule overrides of a location. The values
+// are validated by the annotation parser and do not require escaping.
+// When the OWASP Core Rule Set is loaded globally its initialization runs
+// before these rules and derives tx.executing_paranoia_level from the
+// default paranoia level, so both variables are set.
+func buildModSecurityRules(modsec modsecurity.Config) string {
+	var buffer bytes.Buffer
+
+	if modsec.ParanoiaLevel > 0 {
+		buffer.WriteString(fmt.Sprintf(`SecAction "id:900000,phase:1,nolog,pass,t:none,setvar:tx.paranoia_level=%v,setvar:tx.executing_paranoia_level=%v"
+`, modsec.ParanoiaLevel, modsec.ParanoiaLevel))
+	}
+
+	var thresholds []string
+	if modsec.InboundAnomalyThreshold > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("setvar:tx.inbound_anomaly_score_threshold=%v", modsec.InboundAnomalyThreshold))
+	}
+	if modsec.OutboundAnomalyThreshold > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("setvar:tx.outbound_anomaly_score_threshold=%v", modsec.OutboundAnomalyThreshold))
+	}
+	if len(thresholds) > 0 {
+		buffer.WriteString(fmt.Sprintf(`SecAction "id:900110,phase:1,nolog,pass,t:none,%v"
+`, strings.Join(thresholds, ",")))
+	}
+
+	if len(modsec.Overrides.RemoveByID) > 0 {
+		buffer.WriteString(fmt.Sprintf(`SecRuleRemoveById %v
+`, strings.Join(modsec.Overrides.RemoveByID, " ")))
+	}
+
+	for _, tag := range modsec.Overrides.RemoveByTag {
+		buffer.WriteString(fmt.Sprintf(`SecRuleRemoveByTag "%v"
+`, tag))
+	}
+
+	for _, update := range modsec.Overrides.UpdateTargets {
+		buffer.WriteString(fmt.Sprintf(`SecRuleUpdateTargetById %v "%v"
+`, update.RuleID, strings.Join(update.Targets, "|")))
+	}
+
+	return buffer.String()
+}
+
+// buildModSecurityIngressRule returns the ModSecurity rule that tags the audit
+// events with the Ingress of the location, read by the controller. The rule
+// only matches the transactions that matched rules with a severity, so the
+// requests without matches are not logged.
+func buildModSecurityIngressRule(ing *ingress.Ingress) string {
+	return fmt.Sprintf(`SecRule HIGHEST_SEVERITY "@lt 255" "id:%v,phase:5,pass,t:none,log,noauditlog,tag:%v%v/%v"
+`, nginx.ModSecurityIngressRuleID, nginx.ModSecurityIngressTag, ing.Namespace, ing.Name)
+}
+
+// buildModSecurityAuditLog returns the ModSecurity rules that write the
+// audit log in the JSON format read by the controller
+func buildModSecurityAuditLog() string {
+	return fmt.Sprintf(`modsecurity_rules '
+SecAuditLogType Serial
+SecAuditLogFormat JSON
+SecAuditLogParts ABFHZ
+SecAuditLog %v
+';
+`, nginx.ModSecurityAuditLogPath)
+}
+
 func buildMirrorLocations(locs []*ingress.Location) string {
 	var buffer bytes.Buffer
 
 	mapped := sets.String{}
 
 	for _, loc := range locs {
-		if loc.Mirror.Source == "" || loc.Mirror.Target == "" {
-			continue
-		}
+		for _, target := range loc.Mirror.Targets {
+			if target.Source == "" {
+				continue
+			}
 
-		if mapped.Has(loc.Mirror.Source) {
-			continue
+			if mapped.Has(target.Source) {
+				continue
+			}
+
+			mapped.Insert(target.Source)
+			buffer.WriteString(buildMirrorLocation(loc.Mirror, target))
 		}
+	}
 
-		mapped.Insert(loc.Mirror.Source)
-		buffer.WriteString(fmt.Sprintf(`location = %v {
-internal;
-proxy_pass %v;
+	return buffer.String()
 }
 
-`, loc.Mirror.Source, loc.Mirror.Target))
+// buildMirrorLocation returns the internal location used to mirror requests to a target
+func buildMirrorLocation(cfg mirror.Config, target mirror.Target) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf("location = %v {\ninternal;\n", target.Source))
+
+	if cfg.SamplePercentage < 100 {
+		// the decision depends on the request ID to mirror the same requests to all the targets
+		buffer.WriteString(fmt.Sprintf(`rewrite_by_lua_block {
+if ngx.crc32_short(ngx.var.req_id) %% 100 >= %v then
+return ngx.exit(ngx.HTTP_NO_CONTENT)
+end
+}
+`, cfg.SamplePercentage))
 	}
 
+	names := make([]string, 0, len(cfg.Headers))
+	for name := range cfg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		buffer.WriteString(fmt.Sprintf("proxy_set_header %v %q;\n", name, cfg.Headers[name]))
+	}
+
+	if target.UpstreamName != "" {
+		buffer.WriteString(fmt.Sprintf(`set $proxy_upstream_name %q;
+proxy_pass http://upstream_balancer$request_uri;
+}
+
+`, target.UpstreamName))
+		return buffer.String()
+	}
+
+	buffer.WriteString(fmt.Sprintf("proxy_pass %v;\n}\n\n", target.URL))
+
 	return buffer.String()
 }
 
+// buildCorsOriginRegex returns the regular expression used to check
+// if the origin of a request is allowed
+func buildCorsOriginRegex(origins []string) string {
+	return cors.OriginRegex(origins)
+}
+
 // shouldLoadAuthDigestModule determines whether or not the ngx_http_auth_digest_module module needs to be loaded.
 func shouldLoadAuthDigestModule(s interface{}) bool {
 	servers, ok := s.([]*ingress.Server)
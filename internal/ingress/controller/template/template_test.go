@@ -37,6 +37,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
@@ -334,6 +335,64 @@ func TestBuildProxyPass(t *testing.T) {
 	}
 }
 
+func TestBuildProxyPassWithRewriteRules(t *testing.T) {
+	defaultBackend := "upstream-name"
+
+	rules := []rewrite.Rule{
+		{Regex: "^/old/(.*)", Replacement: "/new/$1", Flag: "last"},
+		{Regex: "^/new/(.*)", Replacement: "/$1", Flag: "break"},
+	}
+
+	loc := &ingress.Location{
+		Path:    "/",
+		Rewrite: rewrite.Config{Rules: rules},
+		Backend: defaultBackend,
+	}
+
+	backends := []*ingress.Backend{{Name: defaultBackend}}
+
+	expected := `
+rewrite "^/old/(.*)" /new/$1 last;
+rewrite "^/new/(.*)" /$1 break;
+proxy_pass http://upstream_balancer;`
+
+	pp := buildProxyPass("example.com", backends, loc)
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+
+	loc.Path = "/there"
+	loc.Rewrite.Target = "/something"
+
+	expected = `
+rewrite "^/old/(.*)" /new/$1 last;
+rewrite "^/new/(.*)" /$1 break;
+rewrite "(?i)/there" /something break;
+proxy_pass http://upstream_balancer;`
+
+	pp = buildProxyPass("example.com", backends, loc)
+	if pp != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, pp)
+	}
+}
+
+func TestQueryParamsForLua(t *testing.T) {
+	if actual := queryParamsForLua(rewrite.QueryParams{}); actual != "nil" {
+		t.Errorf("expected 'nil' but returned '%v'", actual)
+	}
+
+	qp := rewrite.QueryParams{
+		Add:    map[string]string{"b": "2", "a": "1"},
+		Remove: []string{"utm_source"},
+		Rename: map[string]string{"q": "query"},
+	}
+
+	expected := `{ remove = { "utm_source", }, rename = { ["q"] = "query" }, add = { ["a"] = "1", ["b"] = "2" } }`
+	if actual := queryParamsForLua(qp); actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+}
+
 func TestBuildAuthLocation(t *testing.T) {
 	invalidType := &ingress.Ingress{}
 	expected := ""
@@ -1473,6 +1532,85 @@ func TestModSecurityForLocation(t *testing.T) {
 	}
 }
 
+func TestModSecurityStructuredSettingsForLocation(t *testing.T) {
+	location := &ingress.Location{
+		Ingress: &ingress.Ingress{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "tenant-a"},
+			},
+		},
+		ModSecurity: modsecurity.Config{
+			Enable:                   true,
+			EnableSet:                true,
+			Mode:                     modsecurity.ModeDetectionOnly,
+			ParanoiaLevel:            2,
+			InboundAnomalyThreshold:  10,
+			OutboundAnomalyThreshold: 8,
+			Overrides: modsecurity.RuleOverrides{
+				RemoveByID:    []string{"920350", "942100-942199"},
+				RemoveByTag:   []string{"attack-sqli"},
+				UpdateTargets: []modsecurity.TargetUpdate{{RuleID: "942100", Targets: []string{"!ARGS:password", "!ARGS:token"}}},
+			},
+		},
+	}
+
+	expected := `modsecurity on;
+modsecurity_rules '
+SecAction "id:900000,phase:1,nolog,pass,t:none,setvar:tx.paranoia_level=2,setvar:tx.executing_paranoia_level=2"
+SecAction "id:900110,phase:1,nolog,pass,t:none,setvar:tx.inbound_anomaly_score_threshold=10,setvar:tx.outbound_anomaly_score_threshold=8"
+SecRuleRemoveById 920350 942100-942199
+SecRuleRemoveByTag "attack-sqli"
+SecRuleUpdateTargetById 942100 "!ARGS:password|!ARGS:token"
+';
+modsecurity_rules_file /etc/nginx/modsecurity/modsecurity.conf;
+modsecurity_rules 'SecRuleEngine DetectionOnly';
+`
+	if actual := buildModSecurityForLocation(config.Configuration{}, location); actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+
+	// with the Core Rule Set loaded globally the rules of the location are
+	// evaluated after its initialization, which sets the paranoia level
+	location.ModSecurity = modsecurity.Config{Enable: true, EnableSet: true, ParanoiaLevel: 3}
+	expected = `modsecurity_rules '
+SecAction "id:900000,phase:1,nolog,pass,t:none,setvar:tx.paranoia_level=3,setvar:tx.executing_paranoia_level=3"
+';
+`
+	actual := buildModSecurityForLocation(config.Configuration{EnableModsecurity: true, EnableOWASPCoreRules: true}, location)
+	if actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+
+	location.ModSecurity = modsecurity.Config{Enable: true, EnableSet: true}
+	expected = fmt.Sprintf(`modsecurity on;
+modsecurity_rules '
+SecRule HIGHEST_SEVERITY "@lt 255" "id:99990,phase:5,pass,t:none,log,noauditlog,tag:ingress-nginx/ingress=tenant-a/app"
+';
+modsecurity_rules_file /etc/nginx/modsecurity/modsecurity.conf;
+modsecurity_rules '
+SecAuditLogType Serial
+SecAuditLogFormat JSON
+SecAuditLogParts ABFHZ
+SecAuditLog %v
+';
+`, nginx.ModSecurityAuditLogPath)
+	if actual := buildModSecurityForLocation(config.Configuration{EnableModsecurityAuditEvents: true}, location); actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+
+	// the transaction ID of the user is not modified
+	location.ModSecurity.TransactionID = "$request_id"
+	expected = `modsecurity_rules '
+SecRule HIGHEST_SEVERITY "@lt 255" "id:99990,phase:5,pass,t:none,log,noauditlog,tag:ingress-nginx/ingress=tenant-a/app"
+';
+modsecurity_transaction_id "$request_id";
+`
+	actual = buildModSecurityForLocation(config.Configuration{EnableModsecurity: true, EnableModsecurityAuditEvents: true}, location)
+	if actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+}
+
 func TestBuildServerName(t *testing.T) {
 
 	testCases := []struct {
@@ -1576,3 +1714,153 @@ func TestConvertGoSliceIntoLuaTablet(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildMirrorLocations(t *testing.T) {
+	source := "/_mirror-c89a5111-b2e9-4af8-be19-c2a4a924c256"
+
+	loc := &ingress.Location{
+		Path: "/",
+		Mirror: mirror.Config{
+			Source:      source,
+			RequestBody: "on",
+			Target:      "https://test.env.com/$request_uri",
+			Targets: []mirror.Target{
+				{Source: source, URL: "https://test.env.com/$request_uri"},
+				{Source: source + "-1", Service: "shadow", ServicePort: intstr.FromInt(8080), UpstreamName: "default-shadow-8080"},
+			},
+			SamplePercentage: 10,
+			Headers:          map[string]string{"X-Shadow": "true"},
+		},
+	}
+
+	expected := `location = /_mirror-c89a5111-b2e9-4af8-be19-c2a4a924c256 {
+internal;
+rewrite_by_lua_block {
+if ngx.crc32_short(ngx.var.req_id) % 100 >= 10 then
+return ngx.exit(ngx.HTTP_NO_CONTENT)
+end
+}
+proxy_set_header X-Shadow "true";
+proxy_pass https://test.env.com/$request_uri;
+}
+
+location = /_mirror-c89a5111-b2e9-4af8-be19-c2a4a924c256-1 {
+internal;
+rewrite_by_lua_block {
+if ngx.crc32_short(ngx.var.req_id) % 100 >= 10 then
+return ngx.exit(ngx.HTTP_NO_CONTENT)
+end
+}
+proxy_set_header X-Shadow "true";
+set $proxy_upstream_name "default-shadow-8080";
+proxy_pass http://upstream_balancer$request_uri;
+}
+
+`
+
+	// the same mirror configuration in two locations generates the locations only once
+	actual := buildMirrorLocations([]*ingress.Location{loc, loc})
+	if actual != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+}
+
+func TestIsHTTP3Server(t *testing.T) {
+	tc := config.TemplateConfig{
+		Cfg: config.Configuration{
+			SSLProtocols: "TLSv1.2 TLSv1.3",
+		},
+	}
+
+	if isHTTP3Server(tc, &ingress.Server{Hostname: "foo.bar"}) {
+		t.Errorf("expected no HTTP/3 when it is disabled")
+	}
+
+	tc.IsHTTP3Enabled = true
+
+	testCases := []struct {
+		title     string
+		protocols string
+		server    *ingress.Server
+		expected  bool
+	}{
+		{"TLSv1.3 enabled", "TLSv1.2 TLSv1.3", &ingress.Server{Hostname: "foo.bar"}, true},
+		{"TLSv1.3 disabled", "TLSv1.2", &ingress.Server{Hostname: "foo.bar"}, false},
+		{"SSL Passthrough", "TLSv1.2 TLSv1.3", &ingress.Server{Hostname: "foo.bar", SSLPassthrough: true}, false},
+		{"TLSv1.3 disabled by the server snippet", "TLSv1.2 TLSv1.3", &ingress.Server{Hostname: "foo.bar", ServerSnippet: "ssl_protocols TLSv1.2;"}, false},
+		{"TLSv1.3 enabled by the server snippet", "TLSv1.2", &ingress.Server{Hostname: "foo.bar", ServerSnippet: "more_set_headers 'X-Foo: bar';\nssl_protocols TLSv1.2 TLSv1.3;"}, true},
+	}
+
+	for _, testCase := range testCases {
+		tc.Cfg.SSLProtocols = testCase.protocols
+		if actual := isHTTP3Server(tc, testCase.server); actual != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, actual)
+		}
+	}
+}
+
+func TestBuildHTTP3Listener(t *testing.T) {
+	tc := config.TemplateConfig{
+		ListenPorts: &config.ListenPorts{
+			HTTPS: 443,
+			QUIC:  8443,
+		},
+		Cfg: config.Configuration{
+			ReusePort:    true,
+			SSLProtocols: "TLSv1.2 TLSv1.3",
+		},
+	}
+
+	if actual := buildHTTP3Listener(tc, &ingress.Server{Hostname: "foo.bar"}); actual != "" {
+		t.Errorf("expected no listener when HTTP/3 is disabled but returned '%v'", actual)
+	}
+
+	tc.IsHTTP3Enabled = true
+	tc.IsIPV6Enabled = true
+
+	testCases := []struct {
+		title    string
+		server   *ingress.Server
+		expected string
+	}{
+		{"server", &ingress.Server{Hostname: "foo.bar"}, "listen 8443 http3 ;\nlisten [::]:8443 http3 ;"},
+		{"default server", &ingress.Server{Hostname: "_"}, "listen 8443 http3 default_server reuseport ;\nlisten [::]:8443 http3 default_server reuseport ;"},
+		{"SSL Passthrough", &ingress.Server{Hostname: "foo.bar", SSLPassthrough: true}, ""},
+	}
+
+	for _, testCase := range testCases {
+		actual := buildHTTP3Listener(tc, testCase.server)
+		if actual != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, actual)
+		}
+	}
+}
+
+func TestBuildAltSvcHeader(t *testing.T) {
+	tc := config.TemplateConfig{
+		ListenPorts: &config.ListenPorts{
+			QUIC: 443,
+		},
+		Cfg: config.Configuration{
+			HTTP3AltSvcMaxAge: 3600,
+			SSLProtocols:      "TLSv1.3",
+		},
+	}
+
+	server := &ingress.Server{Hostname: "foo.bar"}
+
+	if actual := buildAltSvcHeader(tc, server); actual != "" {
+		t.Errorf("expected no header when HTTP/3 is disabled but returned '%v'", actual)
+	}
+
+	tc.IsHTTP3Enabled = true
+	expected := `more_set_headers 'Alt-Svc: h3-29=":443"; ma=3600';`
+	if actual := buildAltSvcHeader(tc, server); actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+
+	tc.Cfg.SSLProtocols = "TLSv1.2"
+	if actual := buildAltSvcHeader(tc, server); actual != "" {
+		t.Errorf("expected no header when the server does not use TLSv1.3 but returned '%v'", actual)
+	}
+}
@@ -64,6 +64,27 @@ func sysctlSomaxconn() int {
 	return maxConns
 }
 
+// connectionMemory is an estimation of the memory used by a proxied
+// connection, including the buffers of the client and upstream connections
+const connectionMemory = 64 * 1024
+
+// memoryMaxWorkerConnections returns the number of connections of each worker
+// fitting in half of the memory limit, leaving the rest for the shared memory
+// zones and the configuration. It returns 0 if the memory is not limited.
+func memoryMaxWorkerConnections(memoryLimit int64, workers int) int {
+	if memoryLimit <= 0 || workers <= 0 {
+		return 0
+	}
+
+	connections := int(memoryLimit / 2 / connectionMemory / int64(workers))
+	if connections < 1024 {
+		// the memory limit is too low, use the minimum of NGINX
+		connections = 1024
+	}
+
+	return connections
+}
+
 // rlimitMaxNumFiles returns hard limit for RLIMIT_NOFILE
 func rlimitMaxNumFiles() int {
 	var rLimit syscall.Rlimit
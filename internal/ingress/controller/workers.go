@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// oldWorkersMetricsInterval defines how often the metrics
+	// of the workers shutting down are updated
+	oldWorkersMetricsInterval = 10 * time.Second
+
+	// oldWorkersPollInterval defines how often the workers are checked
+	// while a reload waits for the workers shutting down
+	oldWorkersPollInterval = time.Second
+
+	// defaultWorkerShutdownTimeout is the default value of worker-shutdown-timeout
+	defaultWorkerShutdownTimeout = 240 * time.Second
+)
+
+// updateOldWorkers updates the metrics of the NGINX workers shutting down
+// after a reload and returns the number of generations of these workers
+func (n *NGINXController) updateOldWorkers() int {
+	old, err := n.workerTracker.OldWorkers()
+	if err != nil {
+		klog.V(3).Infof("Error reading the NGINX worker processes: %v", err)
+		return 0
+	}
+
+	n.metricCollector.SetOldWorkers(old.Generations, old.Processes, old.ResidentMemory)
+
+	return old.Generations
+}
+
+// tooManyOldWorkers returns true when the generations of NGINX workers
+// shutting down exceed the maximum and the next reload must wait
+func (n *NGINXController) tooManyOldWorkers() bool {
+	maxGenerations := n.cfg.MaxOldWorkerGenerations
+	if maxGenerations <= 0 {
+		return false
+	}
+
+	return n.updateOldWorkers() > maxGenerations
+}
+
+// waitForOldWorkers delays a reload while the generations of NGINX workers
+// shutting down exceed the maximum. NGINX terminates the old workers after
+// the worker-shutdown-timeout, so the reload waits up to that timeout.
+// It must be called without holding the sync lock.
+func (n *NGINXController) waitForOldWorkers() {
+	maxGenerations := n.cfg.MaxOldWorkerGenerations
+	klog.Warningf("Delaying reload, more than %v generations of NGINX workers are shutting down", maxGenerations)
+
+	timeout := parseWorkerShutdownTimeout(n.store.GetBackendConfiguration().WorkerShutdownTimeout)
+	err := wait.PollImmediate(oldWorkersPollInterval, timeout, func() (bool, error) {
+		if n.syncQueue.IsShuttingDown() {
+			return true, nil
+		}
+
+		return !n.tooManyOldWorkers(), nil
+	})
+	if err != nil {
+		klog.Warningf("NGINX workers still shutting down after %v, reloading anyway", timeout)
+	}
+}
+
+// parseWorkerShutdownTimeout returns the duration of the worker-shutdown-timeout.
+// NGINX uses seconds when the value does not contain a unit.
+func parseWorkerShutdownTimeout(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return defaultWorkerShutdownTimeout
+	}
+
+	return timeout
+}
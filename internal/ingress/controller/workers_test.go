@@ -0,0 +1,43 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWorkerShutdownTimeout(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"240s", 240 * time.Second},
+		{"10m", 10 * time.Minute},
+		{"30", 30 * time.Second},
+		{"", defaultWorkerShutdownTimeout},
+		{"1d", defaultWorkerShutdownTimeout},
+		{"-10s", defaultWorkerShutdownTimeout},
+	}
+
+	for _, tc := range testCases {
+		timeout := parseWorkerShutdownTimeout(tc.value)
+		if timeout != tc.expected {
+			t.Errorf("expected %v for %q but %v returned", tc.expected, tc.value, timeout)
+		}
+	}
+}
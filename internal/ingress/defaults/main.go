@@ -31,6 +31,10 @@ type Backend struct {
 	// By default this is disabled
 	CustomHTTPErrors []int `json:"custom-http-errors"`
 
+	// ConfigMap, as namespace/name, with the pages served for the errors intercepted
+	// with custom-http-errors, instead of sending the request to the default backend
+	CustomErrorPages string `json:"custom-error-pages"`
+
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_max_body_size
 	// Sets the maximum allowed size of the client request body
 	ProxyBodySize string `json:"proxy-body-size"`
@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leader
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Task is a duty of the ingress controller that only runs in the
+// replica holding the leader election lock, like the status updates
+type Task struct {
+	// Name of the task, used in the logs and metrics
+	Name string
+
+	// Start runs the task when the controller starts leading.
+	// The task must stop when the stop channel is closed.
+	Start func(stopCh chan struct{})
+
+	// Stop is called after the stop channel is closed when the controller
+	// stops leading. It waits for the work in progress of the task, like
+	// an update of the status, to finish the handover to the next leader.
+	// Optional.
+	Stop func()
+
+	// Healthy returns an error if the running task is not working. Optional.
+	Healthy func() error
+}
+
+type metricsRecorder interface {
+	SetLeaderTask(task string, running, healthy bool)
+	SetLeaderTaskStopDuration(task string, duration time.Duration)
+}
+
+// Tasks runs the registered tasks while the controller is the leader
+type Tasks struct {
+	lock sync.Mutex
+
+	tasks []Task
+
+	// stopCh is closed when the controller stops leading.
+	// It is nil while the controller is not the leader.
+	stopCh chan struct{}
+
+	// stopTimeout limits the time waiting for each task to stop
+	stopTimeout time.Duration
+
+	metrics metricsRecorder
+}
+
+// NewTasks returns an empty set of leader tasks
+func NewTasks(metrics metricsRecorder, stopTimeout time.Duration) *Tasks {
+	return &Tasks{
+		metrics:     metrics,
+		stopTimeout: stopTimeout,
+	}
+}
+
+// Register adds a task. The task starts immediately if the controller is the leader.
+func (t *Tasks) Register(task Task) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.tasks = append(t.tasks, task)
+	t.metrics.SetLeaderTask(task.Name, false, true)
+
+	if t.stopCh != nil {
+		t.start(task)
+	}
+}
+
+// Start runs the tasks. It is called when the controller starts leading.
+func (t *Tasks) Start() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopCh != nil {
+		return
+	}
+
+	t.stopCh = make(chan struct{})
+	for _, task := range t.tasks {
+		t.start(task)
+	}
+}
+
+func (t *Tasks) start(task Task) {
+	klog.V(2).InfoS("Starting leader task", "task", task.Name)
+	t.metrics.SetLeaderTask(task.Name, true, true)
+
+	go task.Start(t.stopCh)
+}
+
+// Stop stops the tasks and waits for their work in progress, up to the stop
+// timeout for each task. It is called when the controller stops leading.
+func (t *Tasks) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopCh == nil {
+		return
+	}
+
+	close(t.stopCh)
+	t.stopCh = nil
+
+	var wg sync.WaitGroup
+	for _, task := range t.tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			t.stop(task)
+		}(task)
+	}
+
+	wg.Wait()
+}
+
+func (t *Tasks) stop(task Task) {
+	defer t.metrics.SetLeaderTask(task.Name, false, true)
+
+	if task.Stop == nil {
+		return
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		task.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		klog.V(2).InfoS("Leader task stopped", "task", task.Name, "duration", time.Since(start))
+	case <-time.After(t.stopTimeout):
+		klog.Warningf("Leader task %v did not stop after %v", task.Name, t.stopTimeout)
+	}
+
+	t.metrics.SetLeaderTaskStopDuration(task.Name, time.Since(start))
+}
+
+// Check updates the health of the running tasks and
+// returns an error containing the unhealthy tasks
+func (t *Tasks) Check() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.stopCh == nil {
+		return nil
+	}
+
+	var errs []string
+	for _, task := range t.tasks {
+		if task.Healthy == nil {
+			continue
+		}
+
+		err := task.Healthy()
+		t.metrics.SetLeaderTask(task.Name, true, err == nil)
+		if err != nil {
+			klog.Warningf("Leader task %v is not healthy: %v", task.Name, err)
+			errs = append(errs, fmt.Sprintf("%v: %v", task.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("unhealthy leader tasks: %v", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leader
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	lock    sync.Mutex
+	running map[string]bool
+	healthy map[string]bool
+	stopped map[string]time.Duration
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		running: map[string]bool{},
+		healthy: map[string]bool{},
+		stopped: map[string]time.Duration{},
+	}
+}
+
+func (m *fakeMetrics) SetLeaderTask(task string, running, healthy bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.running[task] = running
+	m.healthy[task] = healthy
+}
+
+func (m *fakeMetrics) SetLeaderTaskStopDuration(task string, duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stopped[task] = duration
+}
+
+func TestTasks(t *testing.T) {
+	metrics := newFakeMetrics()
+	tasks := NewTasks(metrics, time.Second)
+
+	started := make(chan string, 2)
+	var inFlight sync.WaitGroup
+	finished := false
+
+	tasks.Register(Task{
+		Name: "status",
+		Start: func(stopCh chan struct{}) {
+			inFlight.Add(1)
+			started <- "status"
+			<-stopCh
+			// simulates an update in progress when the leadership is lost
+			time.Sleep(50 * time.Millisecond)
+			finished = true
+			inFlight.Done()
+		},
+		Stop: func() {
+			inFlight.Wait()
+		},
+		Healthy: func() error {
+			return fmt.Errorf("update failed")
+		},
+	})
+
+	if metrics.running["status"] {
+		t.Errorf("expected the task not to run before leading")
+	}
+
+	tasks.Start()
+	<-started
+
+	tasks.Register(Task{
+		Name: "late",
+		Start: func(stopCh chan struct{}) {
+			started <- "late"
+		},
+	})
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a task registered while leading to start")
+	}
+
+	if err := tasks.Check(); err == nil {
+		t.Errorf("expected an error checking an unhealthy task")
+	}
+
+	metrics.lock.Lock()
+	if !metrics.running["status"] || metrics.healthy["status"] {
+		t.Errorf("expected the task to run and be unhealthy")
+	}
+	metrics.lock.Unlock()
+
+	tasks.Stop()
+
+	if !finished {
+		t.Errorf("expected the stop to wait for the work in progress of the task")
+	}
+
+	metrics.lock.Lock()
+	defer metrics.lock.Unlock()
+
+	if metrics.running["status"] || metrics.running["late"] {
+		t.Errorf("expected the tasks to stop")
+	}
+
+	if _, ok := metrics.stopped["status"]; !ok {
+		t.Errorf("expected the stop duration of the task")
+	}
+
+	if err := tasks.Check(); err != nil {
+		t.Errorf("expected no errors checking the tasks of a follower, but returned %v", err)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	tasks := NewTasks(newFakeMetrics(), 10*time.Millisecond)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	tasks.Register(Task{
+		Name:  "blocked",
+		Start: func(stopCh chan struct{}) {},
+		Stop: func() {
+			<-block
+		},
+	})
+
+	tasks.Start()
+
+	done := make(chan struct{})
+	go func() {
+		tasks.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the stop to finish after the timeout")
+	}
+}
@@ -18,6 +18,8 @@ package collectors
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,17 +41,41 @@ type Controller struct {
 	configHash        prometheus.Gauge
 	configSuccess     prometheus.Gauge
 	configSuccessTime prometheus.Gauge
+	configMapErrors   prometheus.Gauge
+
+	oldWorkerGenerations prometheus.Gauge
+	oldWorkerProcesses   prometheus.Gauge
+	oldWorkerMemory      prometheus.Gauge
+
+	detectedCPUs        prometheus.Gauge
+	detectedMemoryLimit prometheus.Gauge
+	workerSettings      *prometheus.GaugeVec
 
 	reloadOperation             *prometheus.CounterVec
 	reloadOperationErrors       *prometheus.CounterVec
 	checkIngressOperation       *prometheus.CounterVec
 	checkIngressOperationErrors *prometheus.CounterVec
 	sslExpireTime               *prometheus.GaugeVec
+	annotationErrors            *prometheus.GaugeVec
 
 	constLabels prometheus.Labels
 	labels      prometheus.Labels
 
 	leaderElection *prometheus.GaugeVec
+
+	leaderTaskRunning      *prometheus.GaugeVec
+	leaderTaskHealthy      *prometheus.GaugeVec
+	leaderTaskStopDuration *prometheus.GaugeVec
+
+	externalNameResolutionErrors *prometheus.CounterVec
+
+	modSecurityAuditEvents *prometheus.CounterVec
+
+	geoIPBuildTime *prometheus.GaugeVec
+	geoIPAge       *prometheus.Desc
+	// geoIPBuildTimes contains the build time of each GeoIP2 database,
+	// used to compute the age of the databases when the metrics are collected
+	geoIPBuildTimes *sync.Map
 }
 
 // NewController creates a new prometheus collector for the
@@ -91,6 +117,57 @@ func NewController(pod, namespace, class string) *Controller {
 				Help:        "Timestamp of the last successful configuration reload.",
 				ConstLabels: constLabels,
 			}),
+		configMapErrors: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "configmap_errors",
+				Help:        "Number of errors found reading the configuration ConfigMap",
+				ConstLabels: constLabels,
+			}),
+		oldWorkerGenerations: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_old_worker_generations",
+				Help:        "Number of previous configurations with NGINX worker processes shutting down after a reload",
+				ConstLabels: constLabels,
+			}),
+		oldWorkerProcesses: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_old_worker_processes",
+				Help:        "Number of NGINX worker processes shutting down after a reload",
+				ConstLabels: constLabels,
+			}),
+		oldWorkerMemory: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_old_worker_resident_memory_bytes",
+				Help:        "Resident memory of the NGINX worker processes shutting down after a reload",
+				ConstLabels: constLabels,
+			}),
+		detectedCPUs: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "detected_cpus",
+				Help:        "Number of CPUs available to the ingress controller, from the CPU quota of the cgroup and the allowed CPUs",
+				ConstLabels: constLabels,
+			}),
+		detectedMemoryLimit: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "detected_memory_limit_bytes",
+				Help:        "Memory limit of the cgroup of the ingress controller, 0 indicates no limit",
+				ConstLabels: constLabels,
+			}),
+		workerSettings: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_worker_settings",
+				Help:        "Number of NGINX worker processes, and maximum connections and open files of each worker in the running configuration",
+				ConstLabels: constLabels,
+			},
+			[]string{"setting"},
+		),
 		reloadOperation: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: PrometheusNamespace,
@@ -132,6 +209,14 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			sslLabelHost,
 		),
+		annotationErrors: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "ingress_annotation_errors",
+				Help:      `Number of errors found parsing the annotations of the Ingress`,
+			},
+			ingressOperation,
+		),
 		leaderElection: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace:   PrometheusNamespace,
@@ -141,6 +226,66 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			[]string{"name"},
 		),
+		leaderTaskRunning: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "leader_task_running",
+				Help:        "Gauge reporting if a leader task is running, 1 while the controller is the leader",
+				ConstLabels: constLabels,
+			},
+			[]string{"task"},
+		),
+		leaderTaskHealthy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "leader_task_healthy",
+				Help:        "Gauge reporting if a leader task is healthy, 0 indicates the last health check of the running task failed",
+				ConstLabels: constLabels,
+			},
+			[]string{"task"},
+		),
+		leaderTaskStopDuration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "leader_task_last_stop_duration_seconds",
+				Help:        "Time spent waiting for the work in progress of a leader task the last time the controller stopped leading",
+				ConstLabels: constLabels,
+			},
+			[]string{"task"},
+		),
+		externalNameResolutionErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "external_name_resolution_errors",
+				Help:        "Cumulative number of errors resolving the name of an ExternalName Service used as backend",
+				ConstLabels: constLabels,
+			},
+			[]string{"namespace", "service"},
+		),
+		modSecurityAuditEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "modsecurity_audit_events",
+				Help:      "Cumulative number of requests of the Ingress matching ModSecurity rules",
+			},
+			ingressOperation,
+		),
+		geoIPBuildTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "geoip_database_build_timestamp_seconds",
+				Help:        "Timestamp of the build of the GeoIP2 database on disk",
+				ConstLabels: constLabels,
+			},
+			[]string{"database"},
+		),
+		geoIPAge: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "", "geoip_database_age_seconds"),
+			"Number of seconds since the build of the GeoIP2 database on disk",
+			[]string{"database"},
+			constLabels,
+		),
+		geoIPBuildTimes: &sync.Map{},
 	}
 
 	return cm
@@ -184,6 +329,43 @@ func (cm *Controller) IncCheckErrorCount(namespace, name string) {
 	cm.checkIngressOperationErrors.MustCurryWith(cm.constLabels).With(labels).Inc()
 }
 
+// IncExternalNameResolutionErrors increment the counter of errors resolving the name of an ExternalName Service
+func (cm *Controller) IncExternalNameResolutionErrors(namespace, service string) {
+	cm.externalNameResolutionErrors.WithLabelValues(namespace, service).Inc()
+}
+
+// IncModSecurityAuditEvent increments the counter of requests of an Ingress matching ModSecurity
+// rules. The IDs of the rules are only logged, since they are not bounded.
+func (cm *Controller) IncModSecurityAuditEvent(namespace, ingress string) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"ingress":   ingress,
+	}
+	cm.modSecurityAuditEvents.MustCurryWith(cm.constLabels).With(labels).Inc()
+}
+
+// RemoveModSecurityMetrics removes the ModSecurity metrics of Ingresses that no longer exist
+func (cm *Controller) RemoveModSecurityMetrics(ingresses []string) {
+	auditEvents := cm.modSecurityAuditEvents.MustCurryWith(cm.constLabels)
+	for _, ing := range ingresses {
+		parts := strings.SplitN(ing, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		auditEvents.Delete(prometheus.Labels{
+			"namespace": parts[0],
+			"ingress":   parts[1],
+		})
+	}
+}
+
+// SetGeoIPDatabase sets the build time of a GeoIP2 database
+func (cm *Controller) SetGeoIPDatabase(database string, buildTime time.Time) {
+	cm.geoIPBuildTime.WithLabelValues(database).Set(float64(buildTime.Unix()))
+	cm.geoIPBuildTimes.Store(database, buildTime)
+}
+
 // ConfigSuccess set a boolean flag according to the output of the controller configuration reload
 func (cm *Controller) ConfigSuccess(hash uint64, success bool) {
 	if success {
@@ -209,7 +391,22 @@ func (cm Controller) Describe(ch chan<- *prometheus.Desc) {
 	cm.checkIngressOperation.Describe(ch)
 	cm.checkIngressOperationErrors.Describe(ch)
 	cm.sslExpireTime.Describe(ch)
+	cm.annotationErrors.Describe(ch)
+	cm.configMapErrors.Describe(ch)
+	cm.oldWorkerGenerations.Describe(ch)
+	cm.oldWorkerProcesses.Describe(ch)
+	cm.oldWorkerMemory.Describe(ch)
+	cm.detectedCPUs.Describe(ch)
+	cm.detectedMemoryLimit.Describe(ch)
+	cm.workerSettings.Describe(ch)
 	cm.leaderElection.Describe(ch)
+	cm.leaderTaskRunning.Describe(ch)
+	cm.leaderTaskHealthy.Describe(ch)
+	cm.leaderTaskStopDuration.Describe(ch)
+	cm.externalNameResolutionErrors.Describe(ch)
+	cm.modSecurityAuditEvents.Describe(ch)
+	cm.geoIPBuildTime.Describe(ch)
+	ch <- cm.geoIPAge
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -222,7 +419,26 @@ func (cm Controller) Collect(ch chan<- prometheus.Metric) {
 	cm.checkIngressOperation.Collect(ch)
 	cm.checkIngressOperationErrors.Collect(ch)
 	cm.sslExpireTime.Collect(ch)
+	cm.annotationErrors.Collect(ch)
+	cm.configMapErrors.Collect(ch)
+	cm.oldWorkerGenerations.Collect(ch)
+	cm.oldWorkerProcesses.Collect(ch)
+	cm.oldWorkerMemory.Collect(ch)
+	cm.detectedCPUs.Collect(ch)
+	cm.detectedMemoryLimit.Collect(ch)
+	cm.workerSettings.Collect(ch)
 	cm.leaderElection.Collect(ch)
+	cm.leaderTaskRunning.Collect(ch)
+	cm.leaderTaskHealthy.Collect(ch)
+	cm.leaderTaskStopDuration.Collect(ch)
+	cm.externalNameResolutionErrors.Collect(ch)
+	cm.modSecurityAuditEvents.Collect(ch)
+	cm.geoIPBuildTime.Collect(ch)
+	cm.geoIPBuildTimes.Range(func(database, buildTime interface{}) bool {
+		ch <- prometheus.MustNewConstMetric(cm.geoIPAge, prometheus.GaugeValue,
+			time.Since(buildTime.(time.Time)).Seconds(), database.(string))
+		return true
+	})
 }
 
 // SetSSLExpireTime sets the expiration time of SSL Certificates
@@ -240,6 +456,66 @@ func (cm *Controller) SetSSLExpireTime(servers []*ingress.Server) {
 	}
 }
 
+// SetAnnotationErrors sets the number of errors found parsing the annotations
+// of each Ingress. Ingresses not present anymore are removed.
+func (cm *Controller) SetAnnotationErrors(ingresses []*ingress.Ingress) {
+	cm.annotationErrors.Reset()
+
+	for _, ing := range ingresses {
+		labels := prometheus.Labels{
+			"namespace": ing.Namespace,
+			"ingress":   ing.Name,
+		}
+		cm.annotationErrors.MustCurryWith(cm.constLabels).With(labels).Set(float64(len(ing.AnnotationErrors)))
+	}
+}
+
+// SetConfigMapErrors sets the number of errors found reading the configuration ConfigMap
+func (cm *Controller) SetConfigMapErrors(count int) {
+	cm.configMapErrors.Set(float64(count))
+}
+
+// SetOldWorkers sets the number of generations, processes and resident memory
+// of the NGINX worker processes shutting down after a reload
+func (cm *Controller) SetOldWorkers(generations, processes int, memory uint64) {
+	cm.oldWorkerGenerations.Set(float64(generations))
+	cm.oldWorkerProcesses.Set(float64(processes))
+	cm.oldWorkerMemory.Set(float64(memory))
+}
+
+// SetDetectedResources sets the number of CPUs and the memory limit available to the ingress controller
+func (cm *Controller) SetDetectedResources(cpus int, memoryLimit int64) {
+	cm.detectedCPUs.Set(float64(cpus))
+	cm.detectedMemoryLimit.Set(float64(memoryLimit))
+}
+
+// SetWorkerSettings sets the number of NGINX worker processes, and
+// the maximum connections and open files of each worker process
+func (cm *Controller) SetWorkerSettings(processes, connections, openFiles int) {
+	cm.workerSettings.WithLabelValues("processes").Set(float64(processes))
+	cm.workerSettings.WithLabelValues("connections").Set(float64(connections))
+	cm.workerSettings.WithLabelValues("open_files").Set(float64(openFiles))
+}
+
+// SetLeaderTask sets if a leader task is running and healthy
+func (cm *Controller) SetLeaderTask(task string, running, healthy bool) {
+	cm.leaderTaskRunning.WithLabelValues(task).Set(boolToFloat64(running))
+	cm.leaderTaskHealthy.WithLabelValues(task).Set(boolToFloat64(healthy))
+}
+
+func boolToFloat64(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// SetLeaderTaskStopDuration sets the time spent waiting for a leader task to stop
+func (cm *Controller) SetLeaderTaskStopDuration(task string, duration time.Duration) {
+	cm.leaderTaskStopDuration.WithLabelValues(task).Set(duration.Seconds())
+}
+
 // RemoveMetrics removes metrics for hostnames not available anymore
 func (cm *Controller) RemoveMetrics(hosts []string, registry prometheus.Gatherer) {
 	cm.removeSSLExpireMetrics(true, hosts, registry)
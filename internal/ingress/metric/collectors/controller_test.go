@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/ingress-nginx/internal/ingress"
 )
 
@@ -100,6 +102,120 @@ func TestControllerCounters(t *testing.T) {
 			`,
 			metrics: []string{"nginx_ingress_controller_ssl_expire_time_seconds"},
 		},
+		{
+			name: "should set the annotation errors of each ingress",
+			test: func(cm *Controller) {
+				ingresses := []*ingress.Ingress{
+					{
+						Ingress: networking.Ingress{
+							ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "valid"},
+						},
+					},
+					{
+						Ingress: networking.Ingress{
+							ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "invalid"},
+						},
+						AnnotationErrors: []string{"first error", "second error"},
+					},
+				}
+				cm.SetAnnotationErrors(ingresses)
+			},
+			want: `
+				# HELP nginx_ingress_controller_ingress_annotation_errors Number of errors found parsing the annotations of the Ingress
+				# TYPE nginx_ingress_controller_ingress_annotation_errors gauge
+				nginx_ingress_controller_ingress_annotation_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="invalid",namespace="default"} 2
+				nginx_ingress_controller_ingress_annotation_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="valid",namespace="default"} 0
+			`,
+			metrics: []string{"nginx_ingress_controller_ingress_annotation_errors"},
+		},
+		{
+			name: "should set the configmap errors",
+			test: func(cm *Controller) {
+				cm.SetConfigMapErrors(3)
+			},
+			want: `
+				# HELP nginx_ingress_controller_configmap_errors Number of errors found reading the configuration ConfigMap
+				# TYPE nginx_ingress_controller_configmap_errors gauge
+				nginx_ingress_controller_configmap_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 3
+			`,
+			metrics: []string{"nginx_ingress_controller_configmap_errors"},
+		},
+		{
+			name: "should set the old workers",
+			test: func(cm *Controller) {
+				cm.SetOldWorkers(2, 4, 1024)
+			},
+			want: `
+				# HELP nginx_ingress_controller_nginx_old_worker_generations Number of previous configurations with NGINX worker processes shutting down after a reload
+				# TYPE nginx_ingress_controller_nginx_old_worker_generations gauge
+				nginx_ingress_controller_nginx_old_worker_generations{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+				# HELP nginx_ingress_controller_nginx_old_worker_processes Number of NGINX worker processes shutting down after a reload
+				# TYPE nginx_ingress_controller_nginx_old_worker_processes gauge
+				nginx_ingress_controller_nginx_old_worker_processes{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 4
+				# HELP nginx_ingress_controller_nginx_old_worker_resident_memory_bytes Resident memory of the NGINX worker processes shutting down after a reload
+				# TYPE nginx_ingress_controller_nginx_old_worker_resident_memory_bytes gauge
+				nginx_ingress_controller_nginx_old_worker_resident_memory_bytes{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1024
+			`,
+			metrics: []string{
+				"nginx_ingress_controller_nginx_old_worker_generations",
+				"nginx_ingress_controller_nginx_old_worker_processes",
+				"nginx_ingress_controller_nginx_old_worker_resident_memory_bytes",
+			},
+		},
+		{
+			name: "should count the errors resolving ExternalName services",
+			test: func(cm *Controller) {
+				cm.IncExternalNameResolutionErrors("default", "external")
+				cm.IncExternalNameResolutionErrors("default", "external")
+			},
+			want: `
+				# HELP nginx_ingress_controller_external_name_resolution_errors Cumulative number of errors resolving the name of an ExternalName Service used as backend
+				# TYPE nginx_ingress_controller_external_name_resolution_errors counter
+				nginx_ingress_controller_external_name_resolution_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="default",service="external"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_external_name_resolution_errors"},
+		},
+		{
+			name: "should count the ModSecurity audit events of each Ingress",
+			test: func(cm *Controller) {
+				cm.IncModSecurityAuditEvent("tenant-a", "app")
+				cm.IncModSecurityAuditEvent("tenant-a", "app")
+				cm.IncModSecurityAuditEvent("tenant-b", "app")
+			},
+			want: `
+				# HELP nginx_ingress_controller_modsecurity_audit_events Cumulative number of requests of the Ingress matching ModSecurity rules
+				# TYPE nginx_ingress_controller_modsecurity_audit_events counter
+				nginx_ingress_controller_modsecurity_audit_events{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="app",namespace="tenant-a"} 2
+				nginx_ingress_controller_modsecurity_audit_events{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="app",namespace="tenant-b"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_modsecurity_audit_events"},
+		},
+		{
+			name: "should remove the ModSecurity audit events of removed Ingresses",
+			test: func(cm *Controller) {
+				cm.IncModSecurityAuditEvent("tenant-a", "app")
+				cm.IncModSecurityAuditEvent("tenant-b", "app")
+				cm.RemoveModSecurityMetrics([]string{"tenant-a/app"})
+			},
+			want: `
+				# HELP nginx_ingress_controller_modsecurity_audit_events Cumulative number of requests of the Ingress matching ModSecurity rules
+				# TYPE nginx_ingress_controller_modsecurity_audit_events counter
+				nginx_ingress_controller_modsecurity_audit_events{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="app",namespace="tenant-b"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_modsecurity_audit_events"},
+		},
+		{
+			name: "should set the build time of the GeoIP2 databases",
+			test: func(cm *Controller) {
+				cm.SetGeoIPDatabase("GeoLite2-City", time.Unix(1614643200, 0))
+			},
+			want: `
+				# HELP nginx_ingress_controller_geoip_database_build_timestamp_seconds Timestamp of the build of the GeoIP2 database on disk
+				# TYPE nginx_ingress_controller_geoip_database_build_timestamp_seconds gauge
+				nginx_ingress_controller_geoip_database_build_timestamp_seconds{controller_class="nginx",controller_namespace="default",controller_pod="pod",database="GeoLite2-City"} 1.6146432e+09
+			`,
+			metrics: []string{"nginx_ingress_controller_geoip_database_build_timestamp_seconds"},
+		},
 	}
 
 	for _, c := range cases {
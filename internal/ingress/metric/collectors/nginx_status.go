@@ -17,6 +17,7 @@ limitations under the License.
 package collectors
 
 import (
+	"fmt"
 	"log"
 	"regexp"
 	"strconv"
@@ -163,23 +164,41 @@ func parse(data string) *basicStatus {
 	}
 }
 
+// getNGINXStatus returns the data of the nginx status page
+func getNGINXStatus() (*basicStatus, error) {
+	status, data, err := nginx.NewGetStatusRequest(nginx.StatusPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if status < 200 || status >= 400 {
+		return nil, fmt.Errorf("unexpected status code %v", status)
+	}
+
+	return parse(string(data)), nil
+}
+
+// NGINXActiveConnections returns the number of active client connections
+// in nginx, excluding the connection used to obtain the status
+func NGINXActiveConnections() (int, error) {
+	s, err := getNGINXStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Active - 1, nil
+}
+
 // nginxStatusCollector scrape the nginx status
 func (p nginxStatusCollector) scrape(ch chan<- prometheus.Metric) {
 	klog.V(3).InfoS("starting scraping socket", "path", nginx.StatusPath)
-	status, data, err := nginx.NewGetStatusRequest(nginx.StatusPath)
+	s, err := getNGINXStatus()
 	if err != nil {
 		log.Printf("%v", err)
 		klog.Warningf("unexpected error obtaining nginx status info: %v", err)
 		return
 	}
 
-	if status < 200 || status >= 400 {
-		klog.Warningf("unexpected error obtaining nginx status info (status %v)", status)
-		return
-	}
-
-	s := parse(string(data))
-
 	ch <- prometheus.MustNewConstMetric(p.data.connectionsTotal,
 		prometheus.CounterValue, float64(s.Accepted), "accepted")
 	ch <- prometheus.MustNewConstMetric(p.data.connectionsTotal,
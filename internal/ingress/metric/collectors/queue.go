@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var queueLabels = []string{"queue"}
+
+// Queue defines the metrics of the named work queues used to
+// sync the configuration of the ingress controller
+type Queue struct {
+	prometheus.Collector
+
+	depth          *prometheus.GaugeVec
+	adds           *prometheus.CounterVec
+	latency        *prometheus.HistogramVec
+	workDuration   *prometheus.HistogramVec
+	unfinishedWork *prometheus.GaugeVec
+	longestRunning *prometheus.GaugeVec
+	retries        *prometheus.CounterVec
+}
+
+var _ workqueue.MetricsProvider = &Queue{}
+
+// NewQueue creates a new prometheus collector for the work queues.
+// The collector must be set as the metrics provider of the workqueue
+// package before the queues are created.
+func NewQueue(pod, namespace, class string) *Queue {
+	constLabels := prometheus.Labels{
+		"controller_namespace": namespace,
+		"controller_class":     class,
+		"controller_pod":       pod,
+	}
+
+	return &Queue{
+		depth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_depth",
+				Help:        "Number of changes waiting in the queue",
+				ConstLabels: constLabels,
+			},
+			queueLabels,
+		),
+		adds: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_adds_total",
+				Help:        "Cumulative number of changes added to the queue",
+				ConstLabels: constLabels,
+			},
+			queueLabels,
+		),
+		latency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_latency_seconds",
+				Help:        "Time a change waits in the queue before it is processed",
+				ConstLabels: constLabels,
+				Buckets:     prometheus.ExponentialBuckets(0.001, 2, 16),
+			},
+			queueLabels,
+		),
+		workDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_work_duration_seconds",
+				Help:        "Time spent processing a change of the queue, including the time waiting to coalesce changes",
+				ConstLabels: constLabels,
+				Buckets:     prometheus.ExponentialBuckets(0.001, 2, 16),
+			},
+			queueLabels,
+		),
+		unfinishedWork: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_unfinished_work_seconds",
+				Help:        "Seconds of work in progress not yet observed by the work duration",
+				ConstLabels: constLabels,
+			},
+			queueLabels,
+		),
+		longestRunning: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_longest_running_processor_seconds",
+				Help:        "Seconds the longest running processor of the queue has been running",
+				ConstLabels: constLabels,
+			},
+			queueLabels,
+		),
+		retries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "queue_retries_total",
+				Help:        "Cumulative number of changes of the queue processed again after an error",
+				ConstLabels: constLabels,
+			},
+			queueLabels,
+		),
+	}
+}
+
+// NewDepthMetric implements workqueue.MetricsProvider
+func (q *Queue) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return q.depth.WithLabelValues(name)
+}
+
+// NewAddsMetric implements workqueue.MetricsProvider
+func (q *Queue) NewAddsMetric(name string) workqueue.CounterMetric {
+	return q.adds.WithLabelValues(name)
+}
+
+// NewLatencyMetric implements workqueue.MetricsProvider
+func (q *Queue) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return q.latency.WithLabelValues(name)
+}
+
+// NewWorkDurationMetric implements workqueue.MetricsProvider
+func (q *Queue) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return q.workDuration.WithLabelValues(name)
+}
+
+// NewUnfinishedWorkSecondsMetric implements workqueue.MetricsProvider
+func (q *Queue) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return q.unfinishedWork.WithLabelValues(name)
+}
+
+// NewLongestRunningProcessorSecondsMetric implements workqueue.MetricsProvider
+func (q *Queue) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return q.longestRunning.WithLabelValues(name)
+}
+
+// NewRetriesMetric implements workqueue.MetricsProvider
+func (q *Queue) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return q.retries.WithLabelValues(name)
+}
+
+// Describe implements prometheus.Collector
+func (q Queue) Describe(ch chan<- *prometheus.Desc) {
+	q.depth.Describe(ch)
+	q.adds.Describe(ch)
+	q.latency.Describe(ch)
+	q.workDuration.Describe(ch)
+	q.unfinishedWork.Describe(ch)
+	q.longestRunning.Describe(ch)
+	q.retries.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (q Queue) Collect(ch chan<- prometheus.Metric) {
+	q.depth.Collect(ch)
+	q.adds.Collect(ch)
+	q.latency.Collect(ch)
+	q.workDuration.Collect(ch)
+	q.unfinishedWork.Collect(ch)
+	q.longestRunning.Collect(ch)
+	q.retries.Collect(ch)
+}
@@ -45,6 +45,8 @@ type socketData struct {
 
 	Method string `json:"method"`
 
+	Protocol string `json:"protocol"`
+
 	RequestLength float64 `json:"requestLength"`
 	RequestTime   float64 `json:"requestTime"`
 
@@ -72,6 +74,8 @@ type SocketCollector struct {
 
 	requests *prometheus.CounterVec
 
+	requestsPerProtocol *prometheus.CounterVec
+
 	listener net.Listener
 
 	metricMapping map[string]interface{}
@@ -98,10 +102,13 @@ var (
 // updating the library to latest version changed the output of the metrics
 var defObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
 
+// SocketPath is the unix socket used by NGINX to send the metrics of the requests
+const SocketPath = "/tmp/prometheus-nginx.socket"
+
 // NewSocketCollector creates a new SocketCollector instance using
 // the ingress watch namespace and class used by the controller
 func NewSocketCollector(pod, namespace, class string, metricsPerHost bool) (*SocketCollector, error) {
-	socket := "/tmp/prometheus-nginx.socket"
+	socket := SocketPath
 	// unix sockets must be unlink()ed before being used
 	_ = syscall.Unlink(socket)
 
@@ -180,6 +187,16 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost bool) (*Soc
 			[]string{"ingress", "namespace", "status", "service"},
 		),
 
+		requestsPerProtocol: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "requests_per_protocol",
+				Help:        "The total number of client requests per HTTP protocol version (HTTP/1.1, HTTP/2.0, HTTP/3.0).",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "protocol"},
+		),
+
 		bytesSent: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:        "bytes_sent",
@@ -268,6 +285,19 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			requestsMetric.Inc()
 		}
 
+		if stats.Protocol != "" {
+			protocolMetric, err := sc.requestsPerProtocol.GetMetricWith(prometheus.Labels{
+				"namespace": stats.Namespace,
+				"ingress":   stats.Ingress,
+				"protocol":  stats.Protocol,
+			})
+			if err != nil {
+				klog.ErrorS(err, "Error fetching requests per protocol metric")
+			} else {
+				protocolMetric.Inc()
+			}
+		}
+
 		if stats.Latency != -1 {
 			latencyMetric, err := sc.upstreamLatency.GetMetricWith(latencyLabels)
 			if err != nil {
@@ -409,6 +439,7 @@ func (sc SocketCollector) Describe(ch chan<- *prometheus.Desc) {
 	sc.requestLength.Describe(ch)
 
 	sc.requests.Describe(ch)
+	sc.requestsPerProtocol.Describe(ch)
 
 	sc.upstreamLatency.Describe(ch)
 
@@ -424,6 +455,7 @@ func (sc SocketCollector) Collect(ch chan<- prometheus.Metric) {
 	sc.requestLength.Collect(ch)
 
 	sc.requests.Collect(ch)
+	sc.requestsPerProtocol.Collect(ch)
 
 	sc.upstreamLatency.Collect(ch)
 
@@ -447,6 +479,11 @@ func handleMessages(conn io.ReadCloser, fn func([]byte)) {
 		return
 	}
 
+	// connections without data are used to check the socket is listening
+	if len(data) == 0 {
+		return
+	}
+
 	fn(data)
 }
 
@@ -282,6 +282,48 @@ func TestCollector(t *testing.T) {
 			wantAfter: `
 			`,
 		},
+
+		{
+			name: "requests should be counted per protocol",
+			data: []string{`[
+			{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"protocol":"HTTP/3.0",
+				"path":"/admin",
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app"
+			},
+			{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"protocol":"HTTP/2.0",
+				"path":"/admin",
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app"
+			},
+			{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"protocol":"HTTP/3.0",
+				"path":"/admin",
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_requests_per_protocol"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_requests_per_protocol The total number of client requests per HTTP protocol version (HTTP/1.1, HTTP/2.0, HTTP/3.0).
+				# TYPE nginx_ingress_controller_requests_per_protocol counter
+				nginx_ingress_controller_requests_per_protocol{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",protocol="HTTP/2.0"} 1
+				nginx_ingress_controller_requests_per_protocol{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",protocol="HTTP/3.0"} 2
+			`,
+		},
 	}
 
 	for _, c := range cases {
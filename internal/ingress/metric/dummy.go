@@ -17,6 +17,8 @@ limitations under the License.
 package metric
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress-nginx/internal/ingress"
 )
@@ -44,6 +46,12 @@ func (dc DummyCollector) IncCheckCount(string, string) {}
 // IncCheckErrorCount ...
 func (dc DummyCollector) IncCheckErrorCount(string, string) {}
 
+// IncExternalNameResolutionErrors ...
+func (dc DummyCollector) IncExternalNameResolutionErrors(string, string) {}
+
+// IncModSecurityAuditEvent ...
+func (dc DummyCollector) IncModSecurityAuditEvent(string, string) {}
+
 // RemoveMetrics ...
 func (dc DummyCollector) RemoveMetrics(ingresses, endpoints []string) {}
 
@@ -56,6 +64,30 @@ func (dc DummyCollector) Stop() {}
 // SetSSLExpireTime ...
 func (dc DummyCollector) SetSSLExpireTime([]*ingress.Server) {}
 
+// SetAnnotationErrors ...
+func (dc DummyCollector) SetAnnotationErrors([]*ingress.Ingress) {}
+
+// SetConfigMapErrors ...
+func (dc DummyCollector) SetConfigMapErrors(int) {}
+
+// SetOldWorkers ...
+func (dc DummyCollector) SetOldWorkers(int, int, uint64) {}
+
+// SetDetectedResources ...
+func (dc DummyCollector) SetDetectedResources(int, int64) {}
+
+// SetWorkerSettings ...
+func (dc DummyCollector) SetWorkerSettings(int, int, int) {}
+
+// SetGeoIPDatabase ...
+func (dc DummyCollector) SetGeoIPDatabase(string, time.Time) {}
+
+// SetLeaderTask ...
+func (dc DummyCollector) SetLeaderTask(string, bool, bool) {}
+
+// SetLeaderTaskStopDuration ...
+func (dc DummyCollector) SetLeaderTaskStopDuration(string, time.Duration) {}
+
 // SetHosts ...
 func (dc DummyCollector) SetHosts(hosts sets.String) {}
 
@@ -25,6 +25,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
 	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
@@ -43,10 +44,42 @@ type Collector interface {
 	IncCheckCount(string, string)
 	IncCheckErrorCount(string, string)
 
+	// IncExternalNameResolutionErrors increments the errors resolving the name of an ExternalName Service
+	IncExternalNameResolutionErrors(namespace, service string)
+
+	// IncModSecurityAuditEvent increments the requests of an Ingress matching ModSecurity rules
+	IncModSecurityAuditEvent(namespace, ingress string)
+
 	RemoveMetrics(ingresses, endpoints []string)
 
 	SetSSLExpireTime([]*ingress.Server)
 
+	// SetAnnotationErrors sets the number of annotation errors of each Ingress
+	SetAnnotationErrors([]*ingress.Ingress)
+
+	// SetConfigMapErrors sets the number of errors found reading the configuration ConfigMap
+	SetConfigMapErrors(int)
+
+	// SetOldWorkers sets the generations, processes and resident memory
+	// of the NGINX workers shutting down after a reload
+	SetOldWorkers(generations, processes int, memory uint64)
+
+	// SetDetectedResources sets the number of CPUs and the memory limit
+	// detected from the cgroup of the ingress controller
+	SetDetectedResources(cpus int, memoryLimit int64)
+
+	// SetWorkerSettings sets the number of NGINX worker processes, and
+	// the maximum connections and open files of each worker process
+	SetWorkerSettings(processes, connections, openFiles int)
+
+	// SetGeoIPDatabase sets the build time of a GeoIP2 database on disk
+	SetGeoIPDatabase(database string, buildTime time.Time)
+
+	// SetLeaderTask sets if a task of the leader is running and healthy
+	SetLeaderTask(task string, running, healthy bool)
+	// SetLeaderTaskStopDuration sets the time spent stopping a task of the leader
+	SetLeaderTaskStopDuration(task string, duration time.Duration)
+
 	// SetHosts sets the hostnames that are being served by the ingress controller
 	SetHosts(sets.String)
 
@@ -60,6 +93,8 @@ type collector struct {
 
 	ingressController *collectors.Controller
 
+	queue *collectors.Queue
+
 	socket *collectors.SocketCollector
 
 	registry *prometheus.Registry
@@ -91,12 +126,18 @@ func NewCollector(metricsPerHost bool, registry *prometheus.Registry) (Collector
 
 	ic := collectors.NewController(podName, podNamespace, class.IngressClass)
 
+	// the provider is used by the named queues created after this point
+	qc := collectors.NewQueue(podName, podNamespace, class.IngressClass)
+	workqueue.SetProvider(qc)
+
 	return Collector(&collector{
 		nginxStatus:  nc,
 		nginxProcess: pc,
 
 		ingressController: ic,
 
+		queue: qc,
+
 		socket: s,
 
 		registry: registry,
@@ -115,6 +156,14 @@ func (c *collector) IncCheckErrorCount(namespace string, name string) {
 	c.ingressController.IncCheckErrorCount(namespace, name)
 }
 
+func (c *collector) IncExternalNameResolutionErrors(namespace, service string) {
+	c.ingressController.IncExternalNameResolutionErrors(namespace, service)
+}
+
+func (c *collector) IncModSecurityAuditEvent(namespace, ingress string) {
+	c.ingressController.IncModSecurityAuditEvent(namespace, ingress)
+}
+
 func (c *collector) IncReloadCount() {
 	c.ingressController.IncReloadCount()
 }
@@ -126,12 +175,14 @@ func (c *collector) IncReloadErrorCount() {
 func (c *collector) RemoveMetrics(ingresses, hosts []string) {
 	c.socket.RemoveMetrics(ingresses, c.registry)
 	c.ingressController.RemoveMetrics(hosts, c.registry)
+	c.ingressController.RemoveModSecurityMetrics(ingresses)
 }
 
 func (c *collector) Start() {
 	c.registry.MustRegister(c.nginxStatus)
 	c.registry.MustRegister(c.nginxProcess)
 	c.registry.MustRegister(c.ingressController)
+	c.registry.MustRegister(c.queue)
 	c.registry.MustRegister(c.socket)
 
 	// the default nginx.conf does not contains
@@ -148,6 +199,7 @@ func (c *collector) Stop() {
 	c.registry.Unregister(c.nginxStatus)
 	c.registry.Unregister(c.nginxProcess)
 	c.registry.Unregister(c.ingressController)
+	c.registry.Unregister(c.queue)
 	c.registry.Unregister(c.socket)
 
 	c.nginxStatus.Stop()
@@ -164,6 +216,38 @@ func (c *collector) SetSSLExpireTime(servers []*ingress.Server) {
 	c.ingressController.SetSSLExpireTime(servers)
 }
 
+func (c *collector) SetAnnotationErrors(ingresses []*ingress.Ingress) {
+	c.ingressController.SetAnnotationErrors(ingresses)
+}
+
+func (c *collector) SetConfigMapErrors(count int) {
+	c.ingressController.SetConfigMapErrors(count)
+}
+
+func (c *collector) SetOldWorkers(generations, processes int, memory uint64) {
+	c.ingressController.SetOldWorkers(generations, processes, memory)
+}
+
+func (c *collector) SetDetectedResources(cpus int, memoryLimit int64) {
+	c.ingressController.SetDetectedResources(cpus, memoryLimit)
+}
+
+func (c *collector) SetWorkerSettings(processes, connections, openFiles int) {
+	c.ingressController.SetWorkerSettings(processes, connections, openFiles)
+}
+
+func (c *collector) SetGeoIPDatabase(database string, buildTime time.Time) {
+	c.ingressController.SetGeoIPDatabase(database, buildTime)
+}
+
+func (c *collector) SetLeaderTask(task string, running, healthy bool) {
+	c.ingressController.SetLeaderTask(task, running, healthy)
+}
+
+func (c *collector) SetLeaderTaskStopDuration(task string, duration time.Duration) {
+	c.ingressController.SetLeaderTaskStopDuration(task, duration)
+}
+
 func (c *collector) SetHosts(hosts sets.String) {
 	c.socket.SetHosts(hosts)
 }
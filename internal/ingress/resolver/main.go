@@ -41,6 +41,9 @@ type Resolver interface {
 
 	// GetService searches for services containing the namespace and name using a the character /
 	GetService(string) (*apiv1.Service, error)
+
+	// GetNamespace returns the Namespace with the given name
+	GetNamespace(string) (*apiv1.Namespace, error)
 }
 
 // AuthSSLCert contains the necessary information to do certificate based
@@ -27,6 +27,7 @@ import (
 // Mock implements the Resolver interface
 type Mock struct {
 	ConfigMaps map[string]*apiv1.ConfigMap
+	Namespaces map[string]*apiv1.Namespace
 }
 
 // GetDefaultBackend returns the backend that must be used as default
@@ -51,6 +52,14 @@ func (m Mock) GetService(string) (*apiv1.Service, error) {
 	return nil, nil
 }
 
+// GetNamespace returns the Namespace with the given name
+func (m Mock) GetNamespace(name string) (*apiv1.Namespace, error) {
+	if v, ok := m.Namespaces[name]; ok {
+		return v, nil
+	}
+	return nil, errors.New("no namespace")
+}
+
 // GetConfigMap searches for configMaps contenating the namespace and name using a the character /
 func (m Mock) GetConfigMap(name string) (*apiv1.ConfigMap, error) {
 	if v, ok := m.ConfigMaps[name]; ok {
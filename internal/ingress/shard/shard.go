@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+// Shard defines the hosts rendered by an ingress controller when the
+// hosts are split across groups of ingress controllers
+type Shard struct {
+	// ID of the shard, from 0 to Count-1
+	ID int
+	// Count is the total number of shards
+	Count int
+}
+
+// Enabled returns true if the hosts are split across more than one shard
+func (s Shard) Enabled() bool {
+	return s.Count > 1
+}
+
+// Validate returns an error if the ID is not a valid shard
+func (s Shard) Validate() error {
+	if s.Count < 0 {
+		return fmt.Errorf("the number of shards cannot be negative")
+	}
+
+	if s.Enabled() && (s.ID < 0 || s.ID >= s.Count) {
+		return fmt.Errorf("the shard ID must be between 0 and %v", s.Count-1)
+	}
+
+	return nil
+}
+
+// Owns returns true if the host belongs to the shard.
+// All the hosts belong to the shard if sharding is disabled.
+func (s Shard) Owns(host string) bool {
+	if !s.Enabled() {
+		return true
+	}
+
+	return ForHost(host, s.Count) == s.ID
+}
+
+// OwnsIngress returns true if the shard publishes the status of the Ingress
+func (s Shard) OwnsIngress(ing *networking.Ingress) bool {
+	if !s.Enabled() {
+		return true
+	}
+
+	return ForIngress(ing, s.Count) == s.ID
+}
+
+// ForHost returns the shard of a host. The host is
+// hashed using FNV-1a, ignoring the case.
+func ForHost(host string, count int) int {
+	if count <= 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(host)))
+
+	return int(h.Sum32() % uint32(count))
+}
+
+// ForIngress returns the shard publishing the status of an Ingress.
+// An Ingress with hosts in different shards uses the shard of its first
+// host in alphabetical order. Ingresses without hosts use the shard 0.
+func ForIngress(ing *networking.Ingress, count int) int {
+	hosts := Hosts(ing)
+	if len(hosts) == 0 {
+		return 0
+	}
+
+	return ForHost(hosts[0], count)
+}
+
+// Hosts returns the hosts defined in the rules of an Ingress in alphabetical order
+func Hosts(ing *networking.Ingress) []string {
+	seen := make(map[string]bool)
+	hosts := []string{}
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host == "" || seen[rule.Host] {
+			continue
+		}
+
+		seen[rule.Host] = true
+		hosts = append(hosts, rule.Host)
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
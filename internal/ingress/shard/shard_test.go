@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+func TestForHost(t *testing.T) {
+	if ForHost("example.com", 0) != 0 || ForHost("example.com", 1) != 0 {
+		t.Errorf("expected the shard 0 when sharding is disabled")
+	}
+
+	if ForHost("example.com", 8) != ForHost("EXAMPLE.com", 8) {
+		t.Errorf("expected the same shard for hosts with a different case")
+	}
+
+	counts := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		shard := ForHost(fmt.Sprintf("host-%v.example.com", i), 4)
+		if shard < 0 || shard >= 4 {
+			t.Fatalf("unexpected shard %v", shard)
+		}
+		counts[shard]++
+	}
+
+	for shard, count := range counts {
+		if count == 0 {
+			t.Errorf("expected hosts in the shard %v", shard)
+		}
+	}
+}
+
+func TestOwns(t *testing.T) {
+	shards := []Shard{{ID: 0, Count: 3}, {ID: 1, Count: 3}, {ID: 2, Count: 3}}
+
+	for i := 0; i < 100; i++ {
+		host := fmt.Sprintf("host-%v.example.com", i)
+
+		owners := 0
+		for _, s := range shards {
+			if s.Owns(host) {
+				owners++
+			}
+		}
+
+		if owners != 1 {
+			t.Errorf("expected one shard to own %v but %v returned", host, owners)
+		}
+	}
+
+	if !(Shard{}).Owns("example.com") {
+		t.Errorf("expected all the hosts to be owned when sharding is disabled")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		shard   Shard
+		isValid bool
+	}{
+		{Shard{}, true},
+		{Shard{ID: 0, Count: 1}, true},
+		{Shard{ID: 2, Count: 3}, true},
+		{Shard{ID: 3, Count: 3}, false},
+		{Shard{ID: -1, Count: 3}, false},
+		{Shard{ID: 0, Count: -1}, false},
+	}
+
+	for _, tc := range testCases {
+		err := tc.shard.Validate()
+		if (err == nil) != tc.isValid {
+			t.Errorf("unexpected result validating %+v: %v", tc.shard, err)
+		}
+	}
+}
+
+func TestForIngress(t *testing.T) {
+	ing := &networking.Ingress{
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{Host: "foo.example.com"},
+				{Host: "bar.example.com"},
+				{Host: "foo.example.com"},
+				{Host: ""},
+			},
+		},
+	}
+
+	expected := []string{"bar.example.com", "foo.example.com"}
+	if hosts := Hosts(ing); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected hosts %v but %v returned", expected, hosts)
+	}
+
+	if ForIngress(ing, 16) != ForHost("bar.example.com", 16) {
+		t.Errorf("expected the shard of the first host")
+	}
+
+	if ForIngress(&networking.Ingress{}, 16) != 0 {
+		t.Errorf("expected the shard 0 for an Ingress without hosts")
+	}
+}
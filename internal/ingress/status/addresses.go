@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/class"
+)
+
+// DefaultAddressSet is the name of the address set defined by the flags
+// --publish-service and --publish-status-address, or the nodes running
+// the ingress controller if none of these flags are set
+const DefaultAddressSet = "default"
+
+// AddressSet defines the sources of the addresses published in the status of the Ingresses
+type AddressSet struct {
+	// Services fronting the ingress controller, in the form namespace/name
+	Services []string
+	// Addresses contains IP addresses or hostnames
+	Addresses []string
+}
+
+// ParseAddressSets parses address sets defined as name=source, where the
+// source is a Service in the form namespace/name, an IP address or a
+// hostname. Repeating a name adds more sources to the same set.
+func ParseAddressSets(values []string) (map[string]AddressSet, error) {
+	sets := make(map[string]AddressSet)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid address set %q, expected name=source", value)
+		}
+
+		name, source := parts[0], parts[1]
+		if name == DefaultAddressSet {
+			return nil, fmt.Errorf("the name of the address set %q is reserved", DefaultAddressSet)
+		}
+
+		set := sets[name]
+		if strings.Contains(source, "/") {
+			set.Services = append(set.Services, source)
+		} else {
+			set.Addresses = append(set.Addresses, source)
+		}
+
+		sets[name] = set
+	}
+
+	return sets, nil
+}
+
+// ParseClassAddressSets parses the address sets of IngressClasses defined
+// as class=set. The sets must be defined in the given address sets.
+func ParseClassAddressSets(values []string, sets map[string]AddressSet) (map[string]string, error) {
+	classSets := make(map[string]string)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid address set of IngressClass %q, expected class=set", value)
+		}
+
+		if _, ok := sets[parts[1]]; !ok && parts[1] != DefaultAddressSet {
+			return nil, fmt.Errorf("the address set %q of IngressClass %v is not defined", parts[1], parts[0])
+		}
+
+		classSets[parts[0]] = parts[1]
+	}
+
+	return classSets, nil
+}
+
+// defaultAddressSet returns the address set defined by the flags
+// --publish-status-address or --publish-service, in that order
+func (s *statusSync) defaultAddressSet() AddressSet {
+	re := regexp.MustCompile(`,\s*`)
+
+	if s.PublishStatusAddress != "" {
+		return AddressSet{Addresses: re.Split(s.PublishStatusAddress, -1)}
+	}
+
+	if s.PublishService != "" {
+		return AddressSet{Services: re.Split(s.PublishService, -1)}
+	}
+
+	return AddressSet{}
+}
+
+// addressSetFor returns the name of the address set published in the
+// status of an Ingress. The annotation publish-address-set takes
+// precedence over the address set of the class of the Ingress.
+func (s *statusSync) addressSetFor(ing *ingress.Ingress) string {
+	name := ""
+	if ing.ParsedAnnotations != nil {
+		name = ing.ParsedAnnotations.PublishAddressSet
+	}
+
+	if name == "" {
+		name = s.PublishClassAddressSets[ingressClass(ing)]
+	}
+
+	if name == "" || name == DefaultAddressSet {
+		return DefaultAddressSet
+	}
+
+	if _, ok := s.PublishAddressSets[name]; !ok {
+		klog.Warningf("Ingress %v/%v uses the undefined address set %q, publishing the default addresses", ing.Namespace, ing.Name, name)
+		return DefaultAddressSet
+	}
+
+	return name
+}
+
+// ingressClass returns the class of an Ingress, from the annotation or the IngressClassName field
+func ingressClass(ing *ingress.Ingress) string {
+	if value, ok := ing.GetAnnotations()[class.IngressKey]; ok {
+		return value
+	}
+
+	if ing.Spec.IngressClassName != nil {
+		return *ing.Spec.IngressClassName
+	}
+
+	return ""
+}
+
+// filterIPFamily returns the hostnames and the IP addresses of the given family.
+// All the addresses are returned if the family is empty.
+func filterIPFamily(addrs []string, family apiv1.IPFamily) []string {
+	if family == "" {
+		return addrs
+	}
+
+	filtered := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip != nil && (ip.To4() != nil) != (family == apiv1.IPv4Protocol) {
+			continue
+		}
+
+		filtered = append(filtered, addr)
+	}
+
+	return filtered
+}
+
+// servicePorts returns the ports of a Service
+func servicePorts(svc *apiv1.Service) []apiv1.PortStatus {
+	ports := make([]apiv1.PortStatus, 0, len(svc.Spec.Ports))
+	for _, port := range svc.Spec.Ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = apiv1.ProtocolTCP
+		}
+
+		ports = append(ports, apiv1.PortStatus{Port: port.Port, Protocol: protocol})
+	}
+
+	return ports
+}
+
+// mergePorts returns the ports of both lists without duplicates, sorted by port and protocol
+func mergePorts(lhs, rhs []apiv1.PortStatus) []apiv1.PortStatus {
+	ports := append([]apiv1.PortStatus{}, lhs...)
+	for _, port := range rhs {
+		found := false
+		for _, p := range ports {
+			if p.Port == port.Port && p.Protocol == port.Protocol {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ports = append(ports, port)
+		}
+	}
+
+	sort.SliceStable(ports, func(a, b int) bool {
+		if ports[a].Port != ports[b].Port {
+			return ports[a].Port < ports[b].Port
+		}
+
+		return ports[a].Protocol < ports[b].Protocol
+	})
+
+	return ports
+}
+
+// portsEqual returns true if both lists contain the same ports in the same order
+func portsEqual(lhs, rhs []apiv1.PortStatus) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for i := range lhs {
+		if lhs[i].Port != rhs[i].Port || lhs[i].Protocol != rhs[i].Protocol {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+)
+
+func TestParseAddressSets(t *testing.T) {
+	sets, err := ParseAddressSets([]string{"internal=ingress-nginx/internal", "internal=10.0.0.1", "external=lb.example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]AddressSet{
+		"internal": {Services: []string{"ingress-nginx/internal"}, Addresses: []string{"10.0.0.1"}},
+		"external": {Addresses: []string{"lb.example.com"}},
+	}
+	if !reflect.DeepEqual(sets, expected) {
+		t.Errorf("expected %v but returned %v", expected, sets)
+	}
+
+	for _, value := range []string{"internal", "=10.0.0.1", "internal=", "default=10.0.0.1"} {
+		if _, err := ParseAddressSets([]string{value}); err == nil {
+			t.Errorf("expected an error parsing %q", value)
+		}
+	}
+
+	classSets, err := ParseClassAddressSets([]string{"nginx-internal=internal", "nginx=default"}, sets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if classSets["nginx-internal"] != "internal" || classSets["nginx"] != DefaultAddressSet {
+		t.Errorf("unexpected address sets of IngressClasses %v", classSets)
+	}
+
+	if _, err := ParseClassAddressSets([]string{"nginx=undefined"}, sets); err == nil {
+		t.Errorf("expected an error using an undefined address set")
+	}
+}
+
+func TestFilterIPFamily(t *testing.T) {
+	addrs := []string{"10.0.0.1", "2001:db8::1", "lb.example.com"}
+
+	testCases := []struct {
+		family   apiv1.IPFamily
+		expected []string
+	}{
+		{"", addrs},
+		{apiv1.IPv4Protocol, []string{"10.0.0.1", "lb.example.com"}},
+		{apiv1.IPv6Protocol, []string{"2001:db8::1", "lb.example.com"}},
+	}
+
+	for _, tc := range testCases {
+		if filtered := filterIPFamily(addrs, tc.family); !reflect.DeepEqual(filtered, tc.expected) {
+			t.Errorf("expected %v for family %q but returned %v", tc.expected, tc.family, filtered)
+		}
+	}
+}
+
+func TestAddressSetFor(t *testing.T) {
+	className := "nginx-internal"
+
+	s := statusSync{
+		Config: Config{
+			PublishAddressSets: map[string]AddressSet{
+				"internal": {Addresses: []string{"10.0.0.1"}},
+				"external": {Addresses: []string{"192.0.2.1"}},
+			},
+			PublishClassAddressSets: map[string]string{className: "internal"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		ing      *ingress.Ingress
+		expected string
+	}{
+		{
+			"without class nor annotation",
+			&ingress.Ingress{},
+			DefaultAddressSet,
+		},
+		{
+			"with the class of an address set",
+			&ingress.Ingress{Ingress: networking.Ingress{Spec: networking.IngressSpec{IngressClassName: &className}}},
+			"internal",
+		},
+		{
+			"with the annotation",
+			&ingress.Ingress{
+				Ingress:           networking.Ingress{Spec: networking.IngressSpec{IngressClassName: &className}},
+				ParsedAnnotations: &annotations.Ingress{PublishAddressSet: "external"},
+			},
+			"external",
+		},
+		{
+			"with an undefined address set",
+			&ingress.Ingress{ParsedAnnotations: &annotations.Ingress{PublishAddressSet: "undefined"}},
+			DefaultAddressSet,
+		},
+	}
+
+	for _, tc := range testCases {
+		if name := s.addressSetFor(tc.ing); name != tc.expected {
+			t.Errorf("%v: expected the address set %v but returned %v", tc.name, tc.expected, name)
+		}
+	}
+}
+
+func TestRunningStatuses(t *testing.T) {
+	s := statusSync{
+		Config: Config{
+			Client: testclient.NewSimpleClientset(&apiv1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: apiv1.NamespaceDefault},
+				Spec: apiv1.ServiceSpec{
+					Type:       apiv1.ServiceTypeClusterIP,
+					ClusterIP:  "10.0.0.1",
+					ClusterIPs: []string{"10.0.0.1", "fd00::1"},
+					Ports: []apiv1.ServicePort{
+						{Port: 443, Protocol: apiv1.ProtocolTCP},
+						{Port: 80},
+					},
+				},
+			}),
+			PublishStatusAddress: "192.0.2.1",
+			PublishAddressSets: map[string]AddressSet{
+				"internal": {Services: []string{"default/internal"}},
+				"missing":  {Services: []string{"default/missing"}},
+			},
+			PublishPorts: true,
+			ListenPorts:  []int32{80, 443},
+		},
+	}
+
+	statuses, err := s.runningStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ports := []apiv1.PortStatus{{Port: 80, Protocol: apiv1.ProtocolTCP}, {Port: 443, Protocol: apiv1.ProtocolTCP}}
+	expected := map[string][]apiv1.LoadBalancerIngress{
+		DefaultAddressSet: {{IP: "192.0.2.1", Ports: ports}},
+		"internal":        {{IP: "10.0.0.1", Ports: ports}, {IP: "fd00::1", Ports: ports}},
+	}
+
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("expected %v but returned %v", expected, statuses)
+	}
+}
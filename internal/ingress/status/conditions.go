@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"reflect"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// ConditionsAnnotation is the annotation containing the conditions
+// of an Ingress. The Ingress API does not contain status conditions.
+const ConditionsAnnotation = "ingress-nginx.k8s.io/conditions"
+
+const (
+	// ConditionAccepted reports if the rules of the Ingress are valid
+	// and do not conflict with the rules of other Ingresses
+	ConditionAccepted = "Accepted"
+	// ConditionResolvedRefs reports if the Services and Secrets
+	// referenced by the Ingress exist and can be used
+	ConditionResolvedRefs = "ResolvedRefs"
+	// ConditionProgrammed reports if the configuration containing
+	// the Ingress was applied to NGINX
+	ConditionProgrammed = "Programmed"
+)
+
+// Condition describes the state of an Ingress in the ingress controller
+type Condition struct {
+	Type               string                `json:"type"`
+	Status             apiv1.ConditionStatus `json:"status"`
+	Reason             string                `json:"reason"`
+	Message            string                `json:"message,omitempty"`
+	LastTransitionTime metav1.Time           `json:"lastTransitionTime"`
+}
+
+type conditionLister interface {
+	// IngressConditions returns the conditions of the
+	// Ingresses, using the namespace/name of the Ingress as key
+	IngressConditions() map[string][]Condition
+}
+
+// currentConditions returns the conditions in the annotation of the Ingress
+func currentConditions(ing *ingress.Ingress) []Condition {
+	value, ok := ing.GetAnnotations()[ConditionsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var conditions []Condition
+	if err := json.Unmarshal([]byte(value), &conditions); err != nil {
+		return nil
+	}
+
+	return conditions
+}
+
+// OnlyConditionsChanged returns true if the only change between two
+// versions of an Ingress is the annotation with the conditions, written
+// by the ingress controller. These updates do not require a sync.
+func OnlyConditionsChanged(old, cur *networking.Ingress) bool {
+	if old == nil || cur == nil ||
+		old.GetAnnotations()[ConditionsAnnotation] == cur.GetAnnotations()[ConditionsAnnotation] {
+		return false
+	}
+
+	withoutConditions := func(ing *networking.Ingress) *networking.Ingress {
+		copyIng := ing.DeepCopy()
+		copyIng.ResourceVersion = ""
+		copyIng.ManagedFields = nil
+		delete(copyIng.Annotations, ConditionsAnnotation)
+		if len(copyIng.Annotations) == 0 {
+			copyIng.Annotations = nil
+		}
+
+		return copyIng
+	}
+
+	return reflect.DeepEqual(withoutConditions(old), withoutConditions(cur))
+}
+
+// MergeConditions returns the new conditions, preserving the last
+// transition time of the current conditions with the same status
+func MergeConditions(current, conditions []Condition) []Condition {
+	merged := make([]Condition, 0, len(conditions))
+	for _, condition := range conditions {
+		for _, c := range current {
+			if c.Type == condition.Type && c.Status == condition.Status {
+				condition.LastTransitionTime = c.LastTransitionTime
+			}
+		}
+
+		merged = append(merged, condition)
+	}
+
+	return merged
+}
+
+// conditionsEqual returns true if the conditions have the
+// same status, reason and message, in the same order
+func conditionsEqual(lhs, rhs []Condition) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+
+	for i := range lhs {
+		if lhs[i].Type != rhs[i].Type ||
+			lhs[i].Status != rhs[i].Status ||
+			lhs[i].Reason != rhs[i].Reason ||
+			lhs[i].Message != rhs[i].Message {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+func TestCurrentConditions(t *testing.T) {
+	ing := &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					ConditionsAnnotation: `[{"type":"Accepted","status":"True","reason":"Accepted","lastTransitionTime":"2021-01-01T00:00:00Z"}]`,
+				},
+			},
+		},
+	}
+
+	conditions := currentConditions(ing)
+	if len(conditions) != 1 || conditions[0].Type != ConditionAccepted || conditions[0].Status != apiv1.ConditionTrue {
+		t.Errorf("unexpected conditions %v", conditions)
+	}
+
+	ing.Annotations[ConditionsAnnotation] = "invalid"
+	if conditions := currentConditions(ing); conditions != nil {
+		t.Errorf("expected no conditions from an invalid annotation but %v returned", conditions)
+	}
+}
+
+func TestMergeConditions(t *testing.T) {
+	before := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	current := []Condition{
+		{Type: ConditionAccepted, Status: apiv1.ConditionTrue, Reason: "Accepted", LastTransitionTime: before},
+		{Type: ConditionProgrammed, Status: apiv1.ConditionTrue, Reason: "Programmed", LastTransitionTime: before},
+	}
+
+	conditions := []Condition{
+		{Type: ConditionAccepted, Status: apiv1.ConditionTrue, Reason: "Accepted", LastTransitionTime: now},
+		{Type: ConditionProgrammed, Status: apiv1.ConditionFalse, Reason: "SyncFailed", LastTransitionTime: now},
+	}
+
+	merged := MergeConditions(current, conditions)
+	if !merged[0].LastTransitionTime.Equal(&before) {
+		t.Errorf("expected the transition time of a condition with the same status to be preserved")
+	}
+
+	if !merged[1].LastTransitionTime.Equal(&now) {
+		t.Errorf("expected a new transition time for a condition with a different status")
+	}
+
+	if conditionsEqual(current, merged) {
+		t.Errorf("expected the conditions to be different")
+	}
+
+	if !conditionsEqual(merged, MergeConditions(merged, conditions)) {
+		t.Errorf("expected the conditions to be equal")
+	}
+}
+
+func TestOnlyConditionsChanged(t *testing.T) {
+	old := &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "example",
+			ResourceVersion: "1",
+		},
+	}
+
+	cur := old.DeepCopy()
+	cur.ResourceVersion = "2"
+	cur.Annotations = map[string]string{ConditionsAnnotation: "[]"}
+
+	if !OnlyConditionsChanged(old, cur) {
+		t.Errorf("expected only the conditions to change")
+	}
+
+	cur.Spec.Rules = []networking.IngressRule{{Host: "example.com"}}
+	if OnlyConditionsChanged(old, cur) {
+		t.Errorf("expected a change of the rules")
+	}
+
+	if OnlyConditionsChanged(old, old.DeepCopy()) {
+		t.Errorf("expected no change of the conditions")
+	}
+}
@@ -18,11 +18,12 @@ package status
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
-	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,6 +34,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 
@@ -49,6 +51,16 @@ var UpdateInterval = 60
 type Syncer interface {
 	Run(chan struct{})
 
+	// Update enqueues an update of the status of the Ingresses
+	Update()
+
+	// Stop stops the updates when the controller stops leading,
+	// waiting for the update in progress
+	Stop()
+
+	// Healthy returns the error of the last update
+	Healthy() error
+
 	Shutdown()
 }
 
@@ -65,11 +77,33 @@ type Config struct {
 
 	PublishStatusAddress string
 
+	// PublishAddressSets contains the named address sets that
+	// can be selected by IngressClasses and Ingresses
+	PublishAddressSets map[string]AddressSet
+
+	// PublishClassAddressSets contains the address set of each IngressClass
+	PublishClassAddressSets map[string]string
+
+	// PublishIPFamily limits the IP addresses published to a family.
+	// All the families are published if it is empty.
+	PublishIPFamily apiv1.IPFamily
+
+	// PublishPorts adds the ports to the addresses published in the status
+	PublishPorts bool
+
+	// ListenPorts contains the ports published with addresses
+	// not obtained from a Service
+	ListenPorts []int32
+
 	UpdateStatusOnShutdown bool
 
 	UseNodeInternalIP bool
 
 	IngressLister ingressLister
+
+	// ConditionLister returns the conditions published in the Ingresses.
+	// The conditions are not published if it is nil.
+	ConditionLister conditionLister
 }
 
 // statusSync keeps the status IP in each Ingress rule updated executing a periodic check
@@ -85,11 +119,34 @@ type statusSync struct {
 	// workqueue used to keep in sync the status IP/s
 	// in the Ingress rules
 	syncQueue *task.Queue
+
+	state *leaderState
+}
+
+// leaderState tracks the updates of the status while the controller is the leader
+type leaderState struct {
+	// lock is held during the updates
+	lock sync.Mutex
+
+	leading bool
+
+	// lastError contains the error of the last update
+	lastError error
+
+	// startWorker starts the worker of the queue once, the
+	// worker keeps running until the queue shuts down
+	startWorker sync.Once
 }
 
 // Start starts the loop to keep the status in sync
 func (s statusSync) Run(stopCh chan struct{}) {
-	go s.syncQueue.Run(time.Second, stopCh)
+	s.state.lock.Lock()
+	s.state.leading = true
+	s.state.lock.Unlock()
+
+	s.state.startWorker.Do(func() {
+		go s.syncQueue.Run(time.Second, stopCh)
+	})
 
 	// trigger initial sync
 	s.syncQueue.EnqueueTask(task.GetDummyObject("sync status"))
@@ -102,6 +159,29 @@ func (s statusSync) Run(stopCh chan struct{}) {
 	}, stopCh)
 }
 
+// Update enqueues an update of the status of the Ingresses
+func (s statusSync) Update() {
+	s.syncQueue.EnqueueSkippableTask(task.GetDummyObject("sync status"))
+}
+
+// Stop stops the updates when the controller stops leading. It waits
+// for the update in progress, so the next leader does not race with it.
+func (s statusSync) Stop() {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	s.state.leading = false
+	s.state.lastError = nil
+}
+
+// Healthy returns the error of the last update
+func (s statusSync) Healthy() error {
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	return s.state.lastError
+}
+
 // Shutdown stops the sync. In case the instance is the leader it will remove the current IP
 // if there is no other instances running.
 func (s statusSync) Shutdown() {
@@ -130,7 +210,12 @@ func (s statusSync) Shutdown() {
 	}
 
 	klog.InfoS("removing value from ingress status", "address", addrs)
-	s.updateStatus([]apiv1.LoadBalancerIngress{})
+	statuses := map[string][]apiv1.LoadBalancerIngress{DefaultAddressSet: {}}
+	for name := range s.PublishAddressSets {
+		statuses[name] = []apiv1.LoadBalancerIngress{}
+	}
+
+	s.updateStatus(statuses)
 }
 
 func (s *statusSync) sync(key interface{}) error {
@@ -139,11 +224,20 @@ func (s *statusSync) sync(key interface{}) error {
 		return nil
 	}
 
-	addrs, err := s.runningAddresses()
+	s.state.lock.Lock()
+	defer s.state.lock.Unlock()
+
+	if !s.state.leading {
+		klog.V(2).InfoS("skipping Ingress status update (not the leader)")
+		return nil
+	}
+
+	statuses, err := s.runningStatuses()
+	s.state.lastError = err
 	if err != nil {
 		return err
 	}
-	s.updateStatus(sliceToStatus(addrs))
+	s.updateStatus(statuses)
 
 	return nil
 }
@@ -156,6 +250,7 @@ func (s statusSync) keyfunc(input interface{}) (interface{}, error) {
 func NewStatusSyncer(config Config) Syncer {
 	st := statusSync{
 		Config: config,
+		state:  &leaderState{},
 	}
 	st.syncQueue = task.NewCustomTaskQueue(st.sync, st.keyfunc)
 
@@ -165,16 +260,75 @@ func NewStatusSyncer(config Config) Syncer {
 // runningAddresses returns a list of IP addresses and/or FQDN where the
 // ingress controller is currently running
 func (s *statusSync) runningAddresses() ([]string, error) {
-	if s.PublishStatusAddress != "" {
-		re := regexp.MustCompile(`,\s*`)
-		multipleAddrs := re.Split(s.PublishStatusAddress, -1)
-		return multipleAddrs, nil
+	addrs, _, err := s.addresses(s.defaultAddressSet())
+	return addrs, err
+}
+
+// runningStatuses returns the status published in the Ingresses of each address set.
+// An address set is not included if its addresses cannot be obtained.
+func (s *statusSync) runningStatuses() (map[string][]apiv1.LoadBalancerIngress, error) {
+	addrs, ports, err := s.addresses(s.defaultAddressSet())
+	if err != nil {
+		return nil, err
 	}
 
-	if s.PublishService != "" {
-		return statusAddressFromService(s.PublishService, s.Client)
+	statuses := map[string][]apiv1.LoadBalancerIngress{
+		DefaultAddressSet: s.withPorts(sliceToStatus(addrs), ports),
 	}
 
+	for name, set := range s.PublishAddressSets {
+		addrs, ports, err := s.addresses(set)
+		if err != nil {
+			klog.Warningf("error obtaining the addresses of the address set %v: %v", name, err)
+			continue
+		}
+
+		statuses[name] = s.withPorts(sliceToStatus(addrs), ports)
+	}
+
+	return statuses, nil
+}
+
+// addresses returns the addresses and ports of an address set.
+// An empty address set uses the nodes running the ingress controller.
+func (s *statusSync) addresses(set AddressSet) ([]string, []apiv1.PortStatus, error) {
+	if len(set.Services) == 0 && len(set.Addresses) == 0 {
+		addrs, err := s.nodeAddresses()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return filterIPFamily(addrs, s.PublishIPFamily), s.listenPorts(), nil
+	}
+
+	addrs := []string{}
+	ports := []apiv1.PortStatus{}
+
+	if len(set.Addresses) > 0 {
+		addrs = append(addrs, set.Addresses...)
+		ports = s.listenPorts()
+	}
+
+	for _, service := range set.Services {
+		svcAddrs, svcPorts, err := statusAddressFromService(service, s.Client)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, addr := range svcAddrs {
+			if !stringInSlice(addr, addrs) {
+				addrs = append(addrs, addr)
+			}
+		}
+
+		ports = mergePorts(ports, svcPorts)
+	}
+
+	return filterIPFamily(addrs, s.PublishIPFamily), ports, nil
+}
+
+// nodeAddresses returns the addresses of the nodes running the ingress controller
+func (s *statusSync) nodeAddresses() ([]string, error) {
 	// get information about all the pods running the ingress controller
 	pods, err := s.Client.CoreV1().Pods(k8s.IngressPodDetails.Namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(k8s.IngressPodDetails.Labels).String(),
@@ -205,15 +359,39 @@ func (s *statusSync) runningAddresses() ([]string, error) {
 			continue
 		}
 
-		name := k8s.GetNodeIPOrName(s.Client, pod.Spec.NodeName, s.UseNodeInternalIP)
-		if !stringInSlice(name, addrs) {
-			addrs = append(addrs, name)
+		for _, name := range k8s.GetNodeAddresses(s.Client, pod.Spec.NodeName, s.UseNodeInternalIP) {
+			if !stringInSlice(name, addrs) {
+				addrs = append(addrs, name)
+			}
 		}
 	}
 
 	return addrs, nil
 }
 
+// listenPorts returns the ports published with addresses not obtained from a Service
+func (s *statusSync) listenPorts() []apiv1.PortStatus {
+	ports := make([]apiv1.PortStatus, 0, len(s.ListenPorts))
+	for _, port := range s.ListenPorts {
+		ports = append(ports, apiv1.PortStatus{Port: port, Protocol: apiv1.ProtocolTCP})
+	}
+
+	return mergePorts(ports, nil)
+}
+
+// withPorts adds the ports to the addresses when PublishPorts is enabled
+func (s *statusSync) withPorts(lbi []apiv1.LoadBalancerIngress, ports []apiv1.PortStatus) []apiv1.LoadBalancerIngress {
+	if !s.PublishPorts || len(ports) == 0 {
+		return lbi
+	}
+
+	for i := range lbi {
+		lbi[i].Ports = append([]apiv1.PortStatus{}, ports...)
+	}
+
+	return lbi
+}
+
 func (s *statusSync) isRunningMultiplePods() bool {
 	pods, err := s.Client.CoreV1().Pods(k8s.IngressPodDetails.Namespace).List(context.TODO(), metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(k8s.IngressPodDetails.Labels).String(),
@@ -244,31 +422,57 @@ func sliceToStatus(endpoints []string) []apiv1.LoadBalancerIngress {
 }
 
 // updateStatus changes the status information of Ingress rules
-func (s *statusSync) updateStatus(newIngressPoint []apiv1.LoadBalancerIngress) {
+func (s *statusSync) updateStatus(statuses map[string][]apiv1.LoadBalancerIngress) {
 	ings := s.IngressLister.ListIngresses()
 
+	var conditions map[string][]Condition
+	if s.ConditionLister != nil {
+		conditions = s.ConditionLister.IngressConditions()
+	}
+
 	p := pool.NewLimited(10)
 	defer p.Close()
 
 	batch := p.Batch()
-	sort.SliceStable(newIngressPoint, lessLoadBalancerIngress(newIngressPoint))
+	for _, addrs := range statuses {
+		sort.SliceStable(addrs, lessLoadBalancerIngress(addrs))
+	}
 
 	for _, ing := range ings {
 		curIPs := ing.Status.LoadBalancer.Ingress
 		sort.SliceStable(curIPs, lessLoadBalancerIngress(curIPs))
-		if ingressSliceEqual(curIPs, newIngressPoint) {
+
+		// the addresses are not updated if the address set is not available
+		newIngressPoint, ok := statuses[s.addressSetFor(ing)]
+		addressesChanged := ok && !ingressSliceEqual(curIPs, newIngressPoint)
+
+		var ingConditions []Condition
+		if newConditions, ok := conditions[k8s.MetaNamespaceKey(ing)]; ok {
+			current := currentConditions(ing)
+			merged := MergeConditions(current, newConditions)
+			if !conditionsEqual(current, merged) {
+				ingConditions = merged
+			}
+		}
+
+		if !addressesChanged && ingConditions == nil {
 			klog.V(3).InfoS("skipping update of Ingress (no change)", "namespace", ing.Namespace, "ingress", ing.Name)
 			continue
 		}
 
-		batch.Queue(runUpdate(ing, newIngressPoint, s.Client))
+		if !addressesChanged {
+			batch.Queue(runConditionsUpdate(ing, ingConditions, s.Client))
+			continue
+		}
+
+		batch.Queue(runUpdate(ing, newIngressPoint, ingConditions, s.Client))
 	}
 
 	batch.QueueComplete()
 	batch.WaitAll()
 }
 
-func runUpdate(ing *ingress.Ingress, status []apiv1.LoadBalancerIngress,
+func runUpdate(ing *ingress.Ingress, status []apiv1.LoadBalancerIngress, conditions []Condition,
 	client clientset.Interface) pool.WorkFunc {
 	return func(wu pool.WorkUnit) (interface{}, error) {
 		if wu.IsCancelled() {
@@ -288,10 +492,54 @@ func runUpdate(ing *ingress.Ingress, status []apiv1.LoadBalancerIngress,
 			klog.Warningf("error updating ingress rule: %v", err)
 		}
 
+		if conditions != nil {
+			updateConditions(ing, conditions, client)
+		}
+
+		return true, nil
+	}
+}
+
+func runConditionsUpdate(ing *ingress.Ingress, conditions []Condition, client clientset.Interface) pool.WorkFunc {
+	return func(wu pool.WorkUnit) (interface{}, error) {
+		if wu.IsCancelled() {
+			return nil, nil
+		}
+
+		updateConditions(ing, conditions, client)
+
 		return true, nil
 	}
 }
 
+// updateConditions writes the conditions in the annotation of the Ingress.
+// The status subresource of the Ingress does not contain conditions.
+func updateConditions(ing *ingress.Ingress, conditions []Condition, client clientset.Interface) {
+	value, err := json.Marshal(conditions)
+	if err != nil {
+		klog.Warningf("error encoding the conditions of Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				ConditionsAnnotation: string(value),
+			},
+		},
+	})
+	if err != nil {
+		klog.Warningf("error encoding the conditions of Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+		return
+	}
+
+	klog.V(2).InfoS("updating Ingress conditions", "namespace", ing.Namespace, "ingress", ing.Name, "conditions", string(value))
+	_, err = client.NetworkingV1beta1().Ingresses(ing.Namespace).Patch(context.TODO(), ing.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.Warningf("error updating the conditions of Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+	}
+}
+
 func lessLoadBalancerIngress(addrs []apiv1.LoadBalancerIngress) func(int, int) bool {
 	return func(a, b int) bool {
 		switch strings.Compare(addrs[a].Hostname, addrs[b].Hostname) {
@@ -316,31 +564,34 @@ func ingressSliceEqual(lhs, rhs []apiv1.LoadBalancerIngress) bool {
 		if lhs[i].Hostname != rhs[i].Hostname {
 			return false
 		}
+		if !portsEqual(lhs[i].Ports, rhs[i].Ports) {
+			return false
+		}
 	}
 
 	return true
 }
 
-func statusAddressFromService(service string, kubeClient clientset.Interface) ([]string, error) {
+func statusAddressFromService(service string, kubeClient clientset.Interface) ([]string, []apiv1.PortStatus, error) {
 	ns, name, _ := k8s.ParseNameNS(service)
 	svc, err := kubeClient.CoreV1().Services(ns).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch svc.Spec.Type {
 	case apiv1.ServiceTypeExternalName:
-		return []string{svc.Spec.ExternalName}, nil
+		return []string{svc.Spec.ExternalName}, servicePorts(svc), nil
 	case apiv1.ServiceTypeClusterIP:
-		return []string{svc.Spec.ClusterIP}, nil
+		return clusterIPs(svc), servicePorts(svc), nil
 	case apiv1.ServiceTypeNodePort:
 		addresses := sets.NewString()
 		if svc.Spec.ExternalIPs != nil {
 			addresses.Insert(svc.Spec.ExternalIPs...)
 		} else {
-			addresses.Insert(svc.Spec.ClusterIP)
+			addresses.Insert(clusterIPs(svc)...)
 		}
-		return addresses.List(), nil
+		return addresses.List(), servicePorts(svc), nil
 	case apiv1.ServiceTypeLoadBalancer:
 		addresses := sets.NewString()
 		for _, ip := range svc.Status.LoadBalancer.Ingress {
@@ -353,10 +604,19 @@ func statusAddressFromService(service string, kubeClient clientset.Interface) ([
 
 		addresses.Insert(svc.Spec.ExternalIPs...)
 
-		return addresses.List(), nil
+		return addresses.List(), servicePorts(svc), nil
+	}
+
+	return nil, nil, fmt.Errorf("unable to extract IP address/es from service %v", service)
+}
+
+// clusterIPs returns the cluster IP addresses of a Service, one of each family in dual-stack clusters
+func clusterIPs(svc *apiv1.Service) []string {
+	if len(svc.Spec.ClusterIPs) > 0 {
+		return svc.Spec.ClusterIPs
 	}
 
-	return nil, fmt.Errorf("unable to extract IP address/es from service %v", service)
+	return []string{svc.Spec.ClusterIP}
 }
 
 // stringInSlice returns true if s is in list
@@ -277,6 +277,7 @@ func buildIngressLister() ingressLister {
 func buildStatusSync() statusSync {
 	return statusSync{
 		syncQueue: task.NewTaskQueue(fakeSynFn),
+		state:     &leaderState{},
 		Config: Config{
 			Client:         buildSimpleClientSet(),
 			PublishService: apiv1.NamespaceDefault + "/" + "foo",
@@ -701,3 +702,30 @@ func TestIngressSliceEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestSyncAfterStop(t *testing.T) {
+	fkSync := NewStatusSyncer(Config{
+		Client:               buildSimpleClientSet(),
+		PublishStatusAddress: "10.0.0.10",
+		IngressLister:        buildIngressLister(),
+	})
+
+	fk := fkSync.(statusSync)
+	fk.state.leading = true
+	fk.Stop()
+
+	if err := fk.sync("just-test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ing, err := fk.Client.NetworkingV1beta1().Ingresses(apiv1.NamespaceDefault).Get(context.TODO(), "foo_ingress_1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, lbi := range ing.Status.LoadBalancer.Ingress {
+		if lbi.IP == "10.0.0.10" {
+			t.Errorf("expected no updates of the status after stopping")
+		}
+	}
+}
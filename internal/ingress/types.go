@@ -75,9 +75,34 @@ type Configuration struct {
 	// ConfigurationChecksum contains the particular checksum of a Configuration object
 	ConfigurationChecksum string `json:"configurationChecksum,omitempty"`
 
+	// DynamicLocations indicates the locations of the servers are routed using Lua
+	DynamicLocations bool `json:"dynamicLocations,omitempty"`
+
+	// ErrorPages contains the custom error pages of the ConfigMaps used
+	// by the configuration, by ConfigMap namespace/name
+	// +optional
+	ErrorPages map[string]ErrorPages `json:"errorPages,omitempty"`
+
+	// GeoIPChecksum contains the checksum of the GeoIP2 databases, so an
+	// update of the databases requires a reload
+	// +optional
+	GeoIPChecksum string `json:"geoIPChecksum,omitempty"`
+
 	DefaultSSLCertificate *SSLCert `json:"-"`
 }
 
+// ErrorPages contains the custom error pages of a ConfigMap by status code.
+// The pages of the "default" key are used for codes without pages.
+type ErrorPages map[string][]ErrorPage
+
+// ErrorPage describes a custom error page served by NGINX
+type ErrorPage struct {
+	ContentType string `json:"contentType"`
+	// Body contains the text of the page alternating with the names of
+	// the variables replaced when the page is served, "status" or "request_id"
+	Body []string `json:"body"`
+}
+
 // Backend describes one or more remote server/s (endpoints) associated with a service
 // +k8s:deepcopy-gen=true
 type Backend struct {
@@ -339,6 +364,10 @@ type Location struct {
 	// CustomHTTPErrors specifies the error codes that should be intercepted.
 	// +optional
 	CustomHTTPErrors []int `json:"custom-http-errors"`
+	// CustomErrorPages is the ConfigMap, as namespace/name, with the pages
+	// of the intercepted errors. By default the global ConfigMap is used.
+	// +optional
+	CustomErrorPages string `json:"custom-error-pages,omitempty"`
 	// ModSecurity allows to enable and configure modsecurity
 	// +optional
 	ModSecurity modsecurity.Config `json:"modsecurity"`
@@ -397,6 +426,8 @@ type ProxyProtocol struct {
 type Ingress struct {
 	networking.Ingress `json:"-"`
 	ParsedAnnotations  *annotations.Ingress `json:"parsedAnnotations"`
+	// AnnotationErrors contains the errors found parsing the annotations
+	AnnotationErrors []string `json:"annotationErrors,omitempty"`
 }
 
 // GeneralConfig holds the definition of lua general configuration data
@@ -17,6 +17,8 @@ limitations under the License.
 package ingress
 
 import (
+	"reflect"
+
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
@@ -80,6 +82,18 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 		return false
 	}
 
+	if c1.DynamicLocations != c2.DynamicLocations {
+		return false
+	}
+
+	if !reflect.DeepEqual(c1.ErrorPages, c2.ErrorPages) {
+		return false
+	}
+
+	if c1.GeoIPChecksum != c2.GeoIPChecksum {
+		return false
+	}
+
 	return true
 }
 
@@ -437,6 +451,10 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		return false
 	}
 
+	if l1.CustomErrorPages != l2.CustomErrorPages {
+		return false
+	}
+
 	if !(&l1.ModSecurity).Equal(&l2.ModSecurity) {
 		return false
 	}
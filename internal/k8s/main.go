@@ -75,6 +75,36 @@ func GetNodeIPOrName(kubeClient clientset.Interface, name string, useInternalIP
 	return defaultOrInternalIP
 }
 
+// GetNodeAddresses returns the IP addresses of a node in the cluster. The
+// external addresses are used unless useInternalIP is true or the node does
+// not contain external addresses. Dual-stack nodes return both families.
+func GetNodeAddresses(kubeClient clientset.Interface, name string, useInternalIP bool) []string {
+	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Error getting node", "name", name)
+		return nil
+	}
+
+	addressesOfType := func(addressType apiv1.NodeAddressType) []string {
+		addresses := []string{}
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType && address.Address != "" {
+				addresses = append(addresses, address.Address)
+			}
+		}
+
+		return addresses
+	}
+
+	if !useInternalIP {
+		if addresses := addressesOfType(apiv1.NodeExternalIP); len(addresses) > 0 {
+			return addresses
+		}
+	}
+
+	return addressesOfType(apiv1.NodeInternalIP)
+}
+
 var (
 	// IngressPodDetails hold information about the ingress-nginx pod
 	IngressPodDetails *PodInfo
@@ -18,6 +18,7 @@ package k8s
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -214,6 +215,39 @@ func TestGetNodeIP(t *testing.T) {
 	}
 }
 
+func TestGetNodeAddresses(t *testing.T) {
+	cs := testclient.NewSimpleClientset(&apiv1.NodeList{Items: []apiv1.Node{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "demo",
+		},
+		Status: apiv1.NodeStatus{
+			Addresses: []apiv1.NodeAddress{
+				{Type: apiv1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: apiv1.NodeInternalIP, Address: "fd00::1"},
+				{Type: apiv1.NodeExternalIP, Address: "192.0.2.1"},
+				{Type: apiv1.NodeExternalIP, Address: "2001:db8::1"},
+			},
+		},
+	}}})
+
+	testCases := []struct {
+		nodeName      string
+		useInternalIP bool
+		expected      []string
+	}{
+		{"demo", false, []string{"192.0.2.1", "2001:db8::1"}},
+		{"demo", true, []string{"10.0.0.1", "fd00::1"}},
+		{"missing", false, nil},
+	}
+
+	for _, tc := range testCases {
+		addresses := GetNodeAddresses(cs, tc.nodeName, tc.useInternalIP)
+		if !reflect.DeepEqual(addresses, tc.expected) {
+			t.Errorf("expected %v for node %v but returned %v", tc.expected, tc.nodeName, addresses)
+		}
+	}
+}
+
 func TestGetIngressPod(t *testing.T) {
 	// POD_NAME & POD_NAMESPACE not exist
 	os.Setenv("POD_NAME", "")
@@ -19,40 +19,150 @@ package dns
 import (
 	"io/ioutil"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 )
 
 var defResolvConf = "/etc/resolv.conf"
 
+// defaults of resolv.conf(5)
+const (
+	defaultNdots   = 1
+	defaultTimeout = 5 * time.Second
+	maxNdots       = 15
+)
+
+// ResolvConf contains the configuration of the system resolver
+type ResolvConf struct {
+	// Nameservers contains the addresses of the nameservers, in order
+	Nameservers []net.IP
+	// Search contains the domains appended to names that are not fully qualified
+	Search []string
+	// Ndots is the number of dots a name must contain to be
+	// looked up as an absolute name before the search domains
+	Ndots int
+	// Timeout is the time waiting for a response from a nameserver
+	Timeout time.Duration
+}
+
+// GetResolvConf returns the configuration of the resolver located in the file /etc/resolv.conf
+func GetResolvConf() (*ResolvConf, error) {
+	file, err := ioutil.ReadFile(defResolvConf)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := parseResolvConf(string(file))
+	klog.V(3).InfoS("Resolver configuration", "nameservers", conf.Nameservers, "search", conf.Search, "ndots", conf.Ndots)
+	return conf, nil
+}
+
 // GetSystemNameServers returns the list of nameservers located in the file /etc/resolv.conf
 func GetSystemNameServers() ([]net.IP, error) {
-	var nameservers []net.IP
-	file, err := ioutil.ReadFile(defResolvConf)
+	conf, err := GetResolvConf()
 	if err != nil {
-		return nameservers, err
+		return nil, err
+	}
+
+	klog.V(3).InfoS("Nameservers", "hosts", conf.Nameservers)
+	return conf.Nameservers, nil
+}
+
+// parseResolvConf parses the content of a resolv.conf file. As in
+// the system resolver, the last search or domain line wins.
+func parseResolvConf(content string) *ResolvConf {
+	conf := &ResolvConf{
+		Ndots:   defaultNdots,
+		Timeout: defaultTimeout,
 	}
 
-	// Lines of the form "nameserver 1.2.3.4" accumulate.
-	lines := strings.Split(string(file), "\n")
-	for l := range lines {
-		trimmed := strings.TrimSpace(lines[l])
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
 		if len(trimmed) == 0 || trimmed[0] == '#' || trimmed[0] == ';' {
 			continue
 		}
+
 		fields := strings.Fields(trimmed)
 		if len(fields) < 2 {
 			continue
 		}
-		if fields[0] == "nameserver" {
+
+		switch fields[0] {
+		case "nameserver":
+			// Lines of the form "nameserver 1.2.3.4" accumulate.
 			ip := net.ParseIP(fields[1])
 			if ip != nil {
-				nameservers = append(nameservers, ip)
+				conf.Nameservers = append(conf.Nameservers, ip)
+			}
+		case "domain":
+			conf.Search = []string{strings.TrimSuffix(fields[1], ".")}
+		case "search":
+			conf.Search = nil
+			for _, domain := range fields[1:] {
+				if domain = strings.TrimSuffix(domain, "."); domain != "" {
+					conf.Search = append(conf.Search, domain)
+				}
+			}
+		case "options":
+			for _, option := range fields[1:] {
+				parseResolvOption(conf, option)
 			}
 		}
 	}
 
-	klog.V(3).InfoS("Nameservers", "hosts", nameservers)
-	return nameservers, nil
+	return conf
+}
+
+// parseResolvOption sets the value of an option of resolv.conf
+// like ndots:5, ignoring unknown and invalid options
+func parseResolvOption(conf *ResolvConf, option string) {
+	parts := strings.SplitN(option, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	value, err := strconv.Atoi(parts[1])
+	if err != nil || value < 0 {
+		return
+	}
+
+	switch parts[0] {
+	case "ndots":
+		if value > maxNdots {
+			value = maxNdots
+		}
+		conf.Ndots = value
+	case "timeout":
+		if value > 0 {
+			conf.Timeout = time.Duration(value) * time.Second
+		}
+	}
+}
+
+// names returns the fully qualified names to look up for a name, in
+// order, applying the search domains like the system resolver does
+func (conf *ResolvConf) names(name string) []string {
+	if strings.HasSuffix(name, ".") {
+		return []string{name}
+	}
+
+	absolute := strings.Count(name, ".") >= conf.Ndots
+
+	var names []string
+	if absolute {
+		names = append(names, name+".")
+	}
+
+	for _, domain := range conf.Search {
+		names = append(names, name+"."+domain+".")
+	}
+
+	if !absolute {
+		names = append(names, name+".")
+	}
+
+	return names
 }
@@ -20,7 +20,9 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"k8s.io/ingress-nginx/internal/file"
 )
@@ -63,3 +65,50 @@ func TestGetDNSServers(t *testing.T) {
 		t.Errorf("expected %v as nameservers but %v returned", eip, s[0])
 	}
 }
+
+func TestParseResolvConf(t *testing.T) {
+	conf := parseResolvConf(`
+nameserver 10.96.0.10
+domain example.com
+search default.svc.cluster.local svc.cluster.local cluster.local.
+options ndots:5 timeout:2 attempts:10 rotate
+`)
+
+	expected := &ResolvConf{
+		Nameservers: []net.IP{net.ParseIP("10.96.0.10")},
+		Search:      []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"},
+		Ndots:       5,
+		Timeout:     2 * time.Second,
+	}
+	if !reflect.DeepEqual(conf, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, conf)
+	}
+
+	conf = parseResolvConf("nameserver 8.8.8.8\noptions ndots:-1 timeout:0")
+	if conf.Ndots != defaultNdots || conf.Timeout != defaultTimeout {
+		t.Errorf("expected the default options but returned %+v", conf)
+	}
+}
+
+func TestResolvConfNames(t *testing.T) {
+	conf := &ResolvConf{
+		Search: []string{"default.svc.cluster.local", "cluster.local"},
+		Ndots:  2,
+	}
+
+	testCases := []struct {
+		name     string
+		expected []string
+	}{
+		{"example.com.", []string{"example.com."}},
+		{"backend", []string{"backend.default.svc.cluster.local.", "backend.cluster.local.", "backend."}},
+		{"backend.other", []string{"backend.other.default.svc.cluster.local.", "backend.other.cluster.local.", "backend.other."}},
+		{"www.example.com", []string{"www.example.com.", "www.example.com.default.svc.cluster.local.", "www.example.com.cluster.local."}},
+	}
+
+	for _, tc := range testCases {
+		if names := conf.names(tc.name); !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("expected %v for %v but returned %v", tc.expected, tc.name, names)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTTL is the time the records are cached. The resolver of the net
+// package does not return the TTL of the records.
+const DefaultTTL = 30 * time.Second
+
+// ErrNotFound is returned when a name does not exist or has no records of the requested type
+var ErrNotFound = errors.New("no such host")
+
+// Record is an address obtained from the DNS
+type Record struct {
+	IP net.IP
+	// Port is the port of the target of a SRV record, 0 for A and AAAA records
+	Port int
+	// TTL is the time the record can be cached
+	TTL time.Duration
+}
+
+// netResolver contains the lookups of the resolver of the net package
+type netResolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Resolver looks up names using the resolver of the net package, with the
+// nameservers, search domains and options of a resolv.conf file.
+//
+// The resolver of the net package does not return the TTL of the records,
+// so every record uses DefaultTTL, and it does not return the additional
+// section of the SRV responses, so the addresses of the targets are always
+// looked up separately.
+type Resolver struct {
+	conf       *ResolvConf
+	preferIPv6 bool
+
+	resolver netResolver
+
+	// next is the index of the nameserver used by the next connection
+	next uint32
+}
+
+// NewResolver creates a new Resolver. When preferIPv6 is true the IPv6
+// addresses of a name are returned instead of the IPv4 addresses.
+func NewResolver(conf *ResolvConf, preferIPv6 bool) *Resolver {
+	r := &Resolver{
+		conf:       conf,
+		preferIPv6: preferIPv6,
+	}
+
+	r.resolver = &net.Resolver{
+		PreferGo: true,
+		Dial:     r.dial,
+	}
+
+	return r
+}
+
+// LookupHost returns the addresses of a host. Only the addresses of
+// the preferred IP family are returned when the host has both.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]Record, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []Record{{IP: ip}}, nil
+	}
+
+	err := ErrNotFound
+	for _, name := range r.conf.names(host) {
+		records, lerr := r.lookupAddresses(ctx, name)
+		if lerr == nil {
+			return records, nil
+		}
+
+		if lerr != ErrNotFound {
+			err = lerr
+		}
+	}
+
+	return nil, fmt.Errorf("lookup %v: %w", host, err)
+}
+
+// LookupSRV returns the addresses and ports of the targets of the SRV
+// record _service._proto.host with the lowest priority. The weight
+// of the targets is ignored.
+func (r *Resolver) LookupSRV(ctx context.Context, service, proto, host string) ([]Record, error) {
+	err := ErrNotFound
+	for _, name := range r.conf.names(host) {
+		_, srvs, lerr := r.resolver.LookupSRV(ctx, service, proto, name)
+		if lerr != nil {
+			if lerr = lookupError(lerr); lerr != ErrNotFound {
+				err = lerr
+			}
+			continue
+		}
+
+		records, serr := r.srvRecords(ctx, srvs)
+		if serr == nil {
+			return records, nil
+		}
+
+		if serr != ErrNotFound {
+			err = serr
+		}
+	}
+
+	return nil, fmt.Errorf("lookup SRV _%v._%v.%v: %w", service, proto, host, err)
+}
+
+// lookupAddresses returns the A or AAAA records of a fully qualified name
+func (r *Resolver) lookupAddresses(ctx context.Context, name string) ([]Record, error) {
+	ipv4, err4 := r.lookupIP(ctx, "ip4", name)
+	ipv6, err6 := r.lookupIP(ctx, "ip6", name)
+
+	if records := r.preferred(ipv4, ipv6); len(records) > 0 {
+		return records, nil
+	}
+
+	if err4 != nil && err4 != ErrNotFound {
+		return nil, err4
+	}
+
+	if err6 != nil && err6 != ErrNotFound {
+		return nil, err6
+	}
+
+	return nil, ErrNotFound
+}
+
+// lookupIP returns the addresses of a name of the IP family of the network
+func (r *Resolver) lookupIP(ctx context.Context, network, name string) ([]Record, error) {
+	ips, err := r.resolver.LookupIP(ctx, network, name)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	records := make([]Record, 0, len(ips))
+	for _, ip := range ips {
+		records = append(records, Record{IP: ip, TTL: DefaultTTL})
+	}
+
+	return records, nil
+}
+
+// srvRecords returns the addresses of the targets of the SRV records with
+// the lowest priority. The records are sorted by priority.
+func (r *Resolver) srvRecords(ctx context.Context, srvs []*net.SRV) ([]Record, error) {
+	var records []Record
+	for _, srv := range srvs {
+		// a target "." indicates the service is not available
+		if srv.Target == "." {
+			continue
+		}
+
+		if srv.Priority > srvs[0].Priority {
+			break
+		}
+
+		addrs, err := r.lookupAddresses(ctx, srv.Target)
+		if err != nil {
+			return nil, fmt.Errorf("lookup SRV target %v: %w", srv.Target, err)
+		}
+
+		for _, addr := range addrs {
+			addr.Port = int(srv.Port)
+			records = append(records, addr)
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return records, nil
+}
+
+// preferred returns the addresses of the preferred IP family, or the other
+// family when there are no addresses of the preferred one
+func (r *Resolver) preferred(ipv4, ipv6 []Record) []Record {
+	if r.preferIPv6 && len(ipv6) > 0 {
+		return ipv6
+	}
+
+	if len(ipv4) > 0 {
+		return ipv4
+	}
+
+	return ipv6
+}
+
+// dial connects to the nameservers of the configuration instead of the
+// nameservers read by the net package. The nameservers are used in turns,
+// as the net package retries a query with a new connection, up to the
+// attempts option of resolv.conf read by the net package itself.
+func (r *Resolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if len(r.conf.Nameservers) == 0 {
+		return nil, fmt.Errorf("no nameservers configured")
+	}
+
+	next := atomic.AddUint32(&r.next, 1) - 1
+	ns := r.conf.Nameservers[int(next)%len(r.conf.Nameservers)]
+
+	dialer := net.Dialer{Timeout: r.conf.Timeout}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ns.String(), "53"))
+}
+
+// lookupError returns ErrNotFound if the name does not exist or does
+// not have records of the requested type
+func lookupError(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return ErrNotFound
+	}
+
+	return err
+}
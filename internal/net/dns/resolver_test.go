@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// fakeResolver answers the lookups with the addresses and SRV records registered by name
+type fakeResolver struct {
+	ipv4    map[string][]net.IP
+	ipv6    map[string][]net.IP
+	srvs    map[string][]*net.SRV
+	queries []string
+}
+
+func (f *fakeResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	f.queries = append(f.queries, network+" "+host)
+
+	ips := f.ipv4[host]
+	if network == "ip6" {
+		ips = f.ipv6[host]
+	}
+
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return ips, nil
+}
+
+func (f *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	target := "_" + service + "._" + proto + "." + name
+	f.queries = append(f.queries, "srv "+target)
+
+	srvs := f.srvs[target]
+	if len(srvs) == 0 {
+		return "", nil, &net.DNSError{Err: "no such host", Name: target, IsNotFound: true}
+	}
+
+	return target, srvs, nil
+}
+
+func newTestResolver(fake *fakeResolver, preferIPv6 bool) *Resolver {
+	r := NewResolver(&ResolvConf{
+		Nameservers: []net.IP{net.ParseIP("10.96.0.10")},
+		Search:      []string{"default.svc.cluster.local", "cluster.local"},
+		Ndots:       2,
+	}, preferIPv6)
+	r.resolver = fake
+	return r
+}
+
+func TestLookupHost(t *testing.T) {
+	fake := &fakeResolver{
+		ipv4: map[string][]net.IP{
+			"www.example.com.":       {net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")},
+			"backend.cluster.local.": {net.ParseIP("10.0.0.1")},
+		},
+		ipv6: map[string][]net.IP{
+			"www.example.com.": {net.ParseIP("2001:db8::1")},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		host       string
+		preferIPv6 bool
+		expected   []Record
+	}{
+		{
+			"IPv4 addresses",
+			"www.example.com", false,
+			[]Record{{IP: net.ParseIP("192.0.2.1"), TTL: DefaultTTL}, {IP: net.ParseIP("192.0.2.2"), TTL: DefaultTTL}},
+		},
+		{
+			"IPv6 addresses when preferred",
+			"www.example.com", true,
+			[]Record{{IP: net.ParseIP("2001:db8::1"), TTL: DefaultTTL}},
+		},
+		{
+			"IPv4 addresses when there are no IPv6 addresses",
+			"backend", true,
+			[]Record{{IP: net.ParseIP("10.0.0.1"), TTL: DefaultTTL}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := newTestResolver(fake, tc.preferIPv6).LookupHost(context.TODO(), tc.host)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(records, tc.expected) {
+				t.Errorf("expected %v but returned %v", tc.expected, records)
+			}
+		})
+	}
+
+	fake.queries = nil
+	_, err := newTestResolver(fake, false).LookupHost(context.TODO(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not found error but returned %v", err)
+	}
+
+	expected := []string{
+		"ip4 missing.default.svc.cluster.local.", "ip6 missing.default.svc.cluster.local.",
+		"ip4 missing.cluster.local.", "ip6 missing.cluster.local.",
+		"ip4 missing.", "ip6 missing.",
+	}
+	if !reflect.DeepEqual(fake.queries, expected) {
+		t.Errorf("expected the queries %v but returned %v", expected, fake.queries)
+	}
+}
+
+func TestLookupSRV(t *testing.T) {
+	fake := &fakeResolver{
+		ipv4: map[string][]net.IP{
+			"a.example.com.":      {net.ParseIP("192.0.2.10")},
+			"b.example.com.":      {net.ParseIP("192.0.2.11")},
+			"backup.example.com.": {net.ParseIP("192.0.2.12")},
+		},
+		srvs: map[string][]*net.SRV{
+			"_http._tcp.api.example.com.": {
+				{Target: "a.example.com.", Port: 8080, Priority: 10, Weight: 10},
+				{Target: "b.example.com.", Port: 8081, Priority: 10, Weight: 10},
+				{Target: "backup.example.com.", Port: 9090, Priority: 20, Weight: 10},
+			},
+		},
+	}
+
+	records, err := newTestResolver(fake, false).LookupSRV(context.TODO(), "http", "tcp", "api.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Record{
+		{IP: net.ParseIP("192.0.2.10"), Port: 8080, TTL: DefaultTTL},
+		{IP: net.ParseIP("192.0.2.11"), Port: 8081, TTL: DefaultTTL},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected %v but returned %v", expected, records)
+	}
+
+	_, err = newTestResolver(fake, false).LookupSRV(context.TODO(), "grpc", "tcp", "api.example.com")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not found error but returned %v", err)
+	}
+}
+
+func TestDial(t *testing.T) {
+	r := NewResolver(&ResolvConf{
+		Nameservers: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2")},
+	}, false)
+
+	for _, expected := range []string{"127.0.0.1:53", "127.0.0.2:53", "127.0.0.1:53"} {
+		conn, err := r.dial(context.TODO(), "udp", "192.0.2.53:53")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if conn.RemoteAddr().String() != expected {
+			t.Errorf("expected a connection to %v but %v returned", expected, conn.RemoteAddr())
+		}
+
+		conn.Close()
+	}
+}
@@ -37,6 +37,16 @@ func IsPortAvailable(p int) bool {
 	return false
 }
 
+// IsUDPPortAvailable checks if a UDP port is available or not
+func IsUDPPortAvailable(p int) bool {
+	conn, err := _net.ListenPacket("udp", fmt.Sprintf(":%v", p))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
 // IsIPv6Enabled checks if IPV6 is enabled or not and we have
 // at least one configured in the pod
 func IsIPv6Enabled() bool {
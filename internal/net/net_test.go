@@ -59,6 +59,23 @@ func TestIsPortAvailable(t *testing.T) {
 	}
 }
 
+func TestIsUDPPortAvailable(t *testing.T) {
+	if !IsUDPPortAvailable(0) {
+		t.Fatal("expected port 0 to be available (random port) but returned false")
+	}
+
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	p := conn.LocalAddr().(*net.UDPAddr).Port
+	if IsUDPPortAvailable(p) {
+		t.Fatalf("expected port %v to not be available", p)
+	}
+}
+
 /*
 // TODO: this test should be optional or running behind a flag
 func TestIsIPv6Enabled(t *testing.T) {
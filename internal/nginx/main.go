@@ -140,6 +140,20 @@ func readFileToString(path string) (string, error) {
 	return string(contents), nil
 }
 
+// HTTP3Module is the configure option of the NGINX binaries built with HTTP/3 support
+const HTTP3Module = "--with-http_v3_module"
+
+// IsHTTP3Supported returns true if NGINX was built with the HTTP/3 module
+func IsHTTP3Supported() bool {
+	out, err := exec.Command("nginx", "-V").CombinedOutput()
+	if err != nil {
+		klog.ErrorS(err, "unexpected error obtaining NGINX configure options")
+		return false
+	}
+
+	return strings.Contains(string(out), HTTP3Module)
+}
+
 // Version return details about NGINX
 func Version() string {
 	flag := "-v"
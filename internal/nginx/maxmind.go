@@ -18,13 +18,21 @@ package nginx
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
 	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"k8s.io/klog/v2"
 )
 
 // MaxmindLicenseKey maxmind license key to download databases
@@ -39,11 +47,19 @@ var MaxmindEditionFiles []string
 // MaxmindMirror maxmind database mirror url (http://geoip.local)
 var MaxmindMirror = ""
 
+// geoIPPath is the directory of the databases
+var geoIPPath = "/etc/nginx/geoip"
+
+// geoIPClient downloads the databases, with a timeout for the updates in background
+var geoIPClient = &http.Client{Timeout: 10 * time.Minute}
+
 const (
-	geoIPPath   = "/etc/nginx/geoip"
 	dbExtension = ".mmdb"
 
-	maxmindURL = "https://download.maxmind.com/app/geoip_download?license_key=%v&edition_id=%v&suffix=tar.gz"
+	archiveSuffix  = "tar.gz"
+	checksumSuffix = "tar.gz.sha256"
+
+	maxmindURL = "https://download.maxmind.com/app/geoip_download?license_key=%v&edition_id=%v&suffix=%v"
 )
 
 // GeoLite2DBExists checks if the required databases for
@@ -62,7 +78,7 @@ func GeoLite2DBExists() bool {
 // GeoIP2 NGINX module using a license key from MaxMind.
 func DownloadGeoLite2DB() error {
 	for _, dbName := range strings.Split(MaxmindEditionIDs, ",") {
-		err := downloadDatabase(dbName)
+		_, err := downloadDatabase(dbName)
 		if err != nil {
 			return err
 		}
@@ -71,73 +87,260 @@ func DownloadGeoLite2DB() error {
 	return nil
 }
 
-func createURL(mirror, licenseKey, dbName string) string {
+func createURL(mirror, licenseKey, dbName, suffix string) string {
 	if len(mirror) > 0 {
-		return fmt.Sprintf("%s/%s.tar.gz", mirror, dbName)
+		return fmt.Sprintf("%s/%s.%s", mirror, dbName, suffix)
 	}
-	return fmt.Sprintf(maxmindURL, licenseKey, dbName)
+	return fmt.Sprintf(maxmindURL, licenseKey, dbName, suffix)
 }
 
-func downloadDatabase(dbName string) error {
-	url := createURL(MaxmindMirror, MaxmindLicenseKey, dbName)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// redactURL removes the license key from the URL of a database
+func redactURL(url string) string {
+	if MaxmindLicenseKey == "" {
+		return url
+	}
+
+	return strings.Replace(url, MaxmindLicenseKey, "XXXXXXX", -1)
+}
+
+// downloadDatabase downloads a database, verifies the SHA256 of the archive
+// and replaces the database on disk atomically. It returns false when
+// the database on disk is already up to date. The archive is not downloaded
+// when its SHA256 matches the archive of the database on disk.
+func downloadDatabase(dbName string) (bool, error) {
+	checksum, err := downloadChecksum(dbName)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	mmdbFile := dbName + dbExtension
+	dbPath := path.Join(geoIPPath, mmdbFile)
+	checksumPath := path.Join(geoIPPath, "."+dbName+"."+checksumSuffix)
+	if checksum != "" && fileExists(dbPath) && readChecksum(checksumPath) == checksum {
+		klog.V(2).InfoS("GeoIP2 database is up to date", "database", dbName)
+		return false, nil
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	url := createURL(MaxmindMirror, MaxmindLicenseKey, dbName, archiveSuffix)
+	resp, err := geoIPClient.Get(url)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("downloading %v: %v", redactURL(url), err)
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP status %v", resp.Status)
+		return false, fmt.Errorf("downloading %v: HTTP status %v", redactURL(url), resp.Status)
 	}
 
-	archive, err := gzip.NewReader(resp.Body)
+	hash := sha256.New()
+	body := io.TeeReader(resp.Body, hash)
+
+	tmpFile := path.Join(geoIPPath, "."+mmdbFile+".download")
+	defer os.Remove(tmpFile)
+
+	err = extractDatabase(body, mmdbFile, tmpFile)
 	if err != nil {
-		return err
+		return false, fmt.Errorf("extracting %v from %v: %v", mmdbFile, redactURL(url), err)
 	}
-	defer archive.Close()
 
-	mmdbFile := dbName + dbExtension
+	// the hash must include the complete archive
+	if _, err := io.Copy(ioutil.Discard, body); err != nil {
+		return false, fmt.Errorf("downloading %v: %v", redactURL(url), err)
+	}
+
+	if checksum != "" && checksum != hex.EncodeToString(hash.Sum(nil)) {
+		return false, fmt.Errorf("the SHA256 of %v does not match %v", redactURL(url), checksum)
+	}
+
+	if _, err := readDatabaseMetadata(tmpFile); err != nil {
+		return false, fmt.Errorf("invalid database %v: %v", mmdbFile, err)
+	}
+
+	changed := !sameFileContent(tmpFile, dbPath)
+	if changed {
+		if err := os.Rename(tmpFile, dbPath); err != nil {
+			return false, err
+		}
+
+		klog.InfoS("GeoIP2 database updated", "database", dbName)
+	} else {
+		klog.V(2).InfoS("GeoIP2 database is up to date", "database", dbName)
+	}
+
+	// the checksum is only used to skip the next downloads
+	if checksum != "" {
+		if err := ioutil.WriteFile(checksumPath, []byte(checksum), 0644); err != nil {
+			klog.Warningf("Error writing the SHA256 of GeoIP2 database %v: %v", dbName, err)
+		}
+	}
+
+	return changed, nil
+}
+
+// readChecksum returns the SHA256 of the archive of a database on disk,
+// or an empty string if it is unknown
+func readChecksum(checksumPath string) string {
+	content, err := ioutil.ReadFile(checksumPath)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(content))
+}
+
+// downloadChecksum returns the SHA256 of the archive of a database. Mirrors
+// not publishing the checksum are allowed, the archive is not verified.
+func downloadChecksum(dbName string) (string, error) {
+	url := createURL(MaxmindMirror, MaxmindLicenseKey, dbName, checksumSuffix)
+	resp, err := geoIPClient.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("downloading %v: %v", redactURL(url), err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && MaxmindMirror != "" {
+		klog.Warningf("The mirror does not contain the SHA256 of the database %v (%v), the download is not verified", dbName, url)
+		return "", nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %v: HTTP status %v", redactURL(url), resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("downloading %v: %v", redactURL(url), err)
+	}
 
-	tarReader := tar.NewReader(archive)
-	for true {
+	// the content is the output of sha256sum, "<checksum>  <file name>"
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid SHA256 in %v", redactURL(url))
+	}
+
+	return strings.ToLower(fields[0]), nil
+}
+
+// extractDatabase writes the database contained in a tar.gz archive to a file
+func extractDatabase(archive io.Reader, mmdbFile, dst string) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tarReader := tar.NewReader(gz)
+	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
-			break
+			return fmt.Errorf("the archive does not contain the database")
+		}
+
+		if err != nil {
+			return err
 		}
 
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, mmdbFile) {
+			continue
+		}
+
+		outFile, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 		if err != nil {
 			return err
 		}
 
-		switch header.Typeflag {
-		case tar.TypeReg:
-			if !strings.HasSuffix(header.Name, mmdbFile) {
-				continue
-			}
+		defer outFile.Close()
+
+		if _, err := io.CopyN(outFile, tarReader, header.Size); err != nil {
+			return err
+		}
+
+		// the file must be complete on disk before replacing the database
+		if err := outFile.Sync(); err != nil {
+			return err
+		}
+
+		return outFile.Close()
+	}
+}
+
+// fileChecksum returns the SHA256 of the content of a file, nil if it cannot be read
+func fileChecksum(file string) []byte {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil
+	}
+
+	return h.Sum(nil)
+}
 
-			outFile, err := os.Create(path.Join(geoIPPath, mmdbFile))
-			if err != nil {
-				return err
-			}
+// sameFileContent returns true if both files exist and have the same content
+func sameFileContent(file1, file2 string) bool {
+	h1 := fileChecksum(file1)
+	return h1 != nil && bytes.Equal(h1, fileChecksum(file2))
+}
 
-			defer outFile.Close()
+// GeoIPDatabasesChecksum returns the SHA256 of the content of the
+// databases present in the filesystem, used to detect their updates
+func GeoIPDatabasesChecksum(files []string) string {
+	h := sha256.New()
+	for _, file := range files {
+		h.Write([]byte(file))
+		h.Write(fileChecksum(path.Join(geoIPPath, file)))
+	}
 
-			if _, err := io.CopyN(outFile, tarReader, header.Size); err != nil {
-				return err
-			}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-			return nil
+// GeoIPDatabases returns the databases present in the filesystem
+func GeoIPDatabases() []string {
+	var files []string
+	for _, dbName := range strings.Split(MaxmindEditionIDs, ",") {
+		if fileExists(path.Join(geoIPPath, dbName+dbExtension)) {
+			files = append(files, dbName+dbExtension)
 		}
 	}
 
-	return fmt.Errorf("the URL %v does not contains the database %v",
-		fmt.Sprintf(maxmindURL, "XXXXXXX", dbName), mmdbFile)
+	return files
+}
+
+// GeoIPDatabaseBuildTime returns the build time of a database present in the filesystem
+func GeoIPDatabaseBuildTime(mmdbFile string) (time.Time, error) {
+	metadata, err := readDatabaseMetadata(path.Join(geoIPPath, mmdbFile))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(metadata.BuildEpoch), 0), nil
+}
+
+// readDatabaseMetadata reads the metadata of a MaxMind DB file,
+// checking the file is a database supported by the GeoIP2 module
+func readDatabaseMetadata(file string) (*maxminddb.Metadata, error) {
+	reader, err := maxminddb.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if reader.Metadata.BinaryFormatMajorVersion != 2 {
+		return nil, fmt.Errorf("unsupported binary format version %v", reader.Metadata.BinaryFormatMajorVersion)
+	}
+
+	if reader.Metadata.DatabaseType == "" {
+		return nil, fmt.Errorf("invalid metadata: missing database_type")
+	}
+
+	metadata := reader.Metadata
+	return &metadata, nil
 }
 
 // ValidateGeoLite2DBEditions check provided Maxmind database editions names
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// metadataMarker precedes the metadata of a MaxMind DB file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// data types of the MaxMind DB format used by the metadata
+const (
+	mmdbString = 2
+	mmdbUint16 = 5
+	mmdbUint32 = 6
+	mmdbMap    = 7
+	mmdbUint64 = 9
+)
+
+// encodeString encodes a string in the MaxMind DB format, for strings shorter than 29 bytes
+func encodeString(s string) []byte {
+	return append([]byte{byte(mmdbString<<5 | len(s))}, []byte(s)...)
+}
+
+// fakeDatabase returns the content of a MaxMind DB file with an empty search tree
+func fakeDatabase(databaseType string, buildEpoch uint32) []byte {
+	var db bytes.Buffer
+	db.Write(make([]byte, 64))
+	db.Write(metadataMarker)
+	db.WriteByte(mmdbMap<<5 | 5)
+	db.Write(encodeString("node_count"))
+	db.WriteByte(mmdbUint32 << 5)
+	db.Write(encodeString("record_size"))
+	db.Write([]byte{mmdbUint16<<5 | 1, 24})
+	db.Write(encodeString("binary_format_major_version"))
+	db.Write([]byte{mmdbUint16<<5 | 1, 2})
+	db.Write(encodeString("database_type"))
+	db.Write(encodeString(databaseType))
+	db.Write(encodeString("build_epoch"))
+	// uint64 is an extended type
+	db.Write([]byte{4, mmdbUint64 - 7, byte(buildEpoch >> 24), byte(buildEpoch >> 16), byte(buildEpoch >> 8), byte(buildEpoch)})
+	return db.Bytes()
+}
+
+func fakeArchive(t *testing.T, dbName string, content []byte) []byte {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	name := dbName + "_20210302/" + dbName + dbExtension
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tw.Close()
+	gz.Close()
+	return archive.Bytes()
+}
+
+// fakeMirror serves the archives and checksums of the databases like a MaxMind mirror
+type fakeMirror struct {
+	files    map[string][]byte
+	requests []string
+}
+
+func (m *fakeMirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.requests = append(m.requests, r.URL.Path)
+
+	content, ok := m.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Write(content)
+}
+
+func (m *fakeMirror) publish(t *testing.T, dbName string, content []byte) {
+	archive := fakeArchive(t, dbName, content)
+	sum := sha256.Sum256(archive)
+
+	m.files["/"+dbName+".tar.gz"] = archive
+	m.files["/"+dbName+".tar.gz.sha256"] = []byte(hex.EncodeToString(sum[:]) + "  " + dbName + "_20210302.tar.gz\n")
+}
+
+func setupMirror(t *testing.T) (*fakeMirror, func()) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mirror := &fakeMirror{files: map[string][]byte{}}
+	server := httptest.NewServer(mirror)
+
+	oldPath, oldMirror, oldEditions := geoIPPath, MaxmindMirror, MaxmindEditionIDs
+	geoIPPath, MaxmindMirror, MaxmindEditionIDs = dir, server.URL, "GeoLite2-City"
+
+	return mirror, func() {
+		server.Close()
+		os.RemoveAll(dir)
+		geoIPPath, MaxmindMirror, MaxmindEditionIDs = oldPath, oldMirror, oldEditions
+	}
+}
+
+func TestDownloadDatabase(t *testing.T) {
+	mirror, cleanup := setupMirror(t)
+	defer cleanup()
+
+	db := fakeDatabase("GeoLite2-City", 1614643200)
+	mirror.publish(t, "GeoLite2-City", db)
+
+	changed, err := downloadDatabase("GeoLite2-City")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !changed {
+		t.Errorf("expected the first download to change the database")
+	}
+
+	content, err := ioutil.ReadFile(path.Join(geoIPPath, "GeoLite2-City.mmdb"))
+	if err != nil || !bytes.Equal(content, db) {
+		t.Errorf("expected the database on disk to match the archive: %v", err)
+	}
+
+	buildTime, err := GeoIPDatabaseBuildTime("GeoLite2-City.mmdb")
+	if err != nil || !buildTime.Equal(time.Unix(1614643200, 0)) {
+		t.Errorf("expected the build time of the database but returned %v %v", buildTime, err)
+	}
+
+	mirror.requests = nil
+	changed, err = downloadDatabase("GeoLite2-City")
+	if err != nil || changed {
+		t.Errorf("expected no change downloading the same database but returned %v %v", changed, err)
+	}
+
+	if expected := []string{"/GeoLite2-City.tar.gz.sha256"}; !reflect.DeepEqual(mirror.requests, expected) {
+		t.Errorf("expected only the requests %v when the checksum did not change but returned %v", expected, mirror.requests)
+	}
+
+	// the checksum does not match the archive
+	archive := mirror.files["/GeoLite2-City.tar.gz"]
+	mirror.publish(t, "GeoLite2-City", fakeDatabase("GeoLite2-City", 1614902400))
+	mirror.files["/GeoLite2-City.tar.gz"] = archive
+	if _, err := downloadDatabase("GeoLite2-City"); err == nil {
+		t.Errorf("expected an error downloading an archive with an invalid checksum")
+	}
+
+	// the archive does not contain a MaxMind DB file
+	mirror.publish(t, "GeoLite2-City", []byte("not a database"))
+	if _, err := downloadDatabase("GeoLite2-City"); err == nil {
+		t.Errorf("expected an error downloading an invalid database")
+	}
+
+	content, _ = ioutil.ReadFile(path.Join(geoIPPath, "GeoLite2-City.mmdb"))
+	if !bytes.Equal(content, db) {
+		t.Errorf("expected the database to be unchanged after failed downloads")
+	}
+
+	files, _ := ioutil.ReadDir(geoIPPath)
+	if len(files) != 2 {
+		t.Errorf("expected only the database and its checksum in the directory but found %v files", len(files))
+	}
+}
+
+func TestGeoIPUpdater(t *testing.T) {
+	mirror, cleanup := setupMirror(t)
+	defer cleanup()
+
+	var updates [][]string
+	builds := map[string]time.Time{}
+	u := NewGeoIPUpdater(time.Hour, func(files []string) {
+		updates = append(updates, files)
+	}, func(dbName string, buildTime time.Time) {
+		builds[dbName] = buildTime
+	})
+
+	if err := u.update(); err == nil {
+		t.Errorf("expected an error updating a database missing in the mirror")
+	}
+
+	mirror.publish(t, "GeoLite2-City", fakeDatabase("GeoLite2-City", 1614643200))
+	if err := u.update(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := u.update(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := [][]string{{"GeoLite2-City.mmdb"}}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Errorf("expected the updates %v but returned %v", expected, updates)
+	}
+
+	if !builds["GeoLite2-City"].Equal(time.Unix(1614643200, 0)) {
+		t.Errorf("expected the build time of the database but returned %v", builds)
+	}
+
+	delays := []time.Duration{}
+	for failures := 1; failures <= 8; failures++ {
+		delays = append(delays, u.retryDelay(failures))
+	}
+
+	expectedDelays := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute,
+		16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour}
+	if !reflect.DeepEqual(delays, expectedDelays) {
+		t.Errorf("expected the retry delays %v but returned %v", expectedDelays, delays)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// geoIPMinRetryDelay is the delay before retrying a failed update,
+	// doubled after each failure up to the update interval
+	geoIPMinRetryDelay = 1 * time.Minute
+)
+
+// GeoIPUpdater downloads the MaxMind databases periodically
+type GeoIPUpdater struct {
+	interval time.Duration
+
+	// onUpdate is called with the databases on disk after a database changed
+	onUpdate func(files []string)
+	// onDatabase is called with the build time of each database on disk after an update
+	onDatabase func(dbName string, buildTime time.Time)
+
+	minRetryDelay time.Duration
+}
+
+// NewGeoIPUpdater creates a new GeoIPUpdater checking for new databases every interval
+func NewGeoIPUpdater(interval time.Duration, onUpdate func([]string), onDatabase func(string, time.Time)) *GeoIPUpdater {
+	return &GeoIPUpdater{
+		interval:      interval,
+		onUpdate:      onUpdate,
+		onDatabase:    onDatabase,
+		minRetryDelay: geoIPMinRetryDelay,
+	}
+}
+
+// Run updates the databases until the stop channel is closed. The first update
+// happens after the interval, or after the retry delay if a database is missing.
+func (u *GeoIPUpdater) Run(stopCh <-chan struct{}) {
+	u.reportDatabases()
+
+	delay := u.interval
+	failures := 0
+	if !GeoLite2DBExists() {
+		delay = u.retryDelay(1)
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := u.update(); err != nil {
+			failures++
+			delay = u.retryDelay(failures)
+			klog.Warningf("Error updating GeoIP2 databases, retrying in %v: %v", delay, err)
+			continue
+		}
+
+		failures = 0
+		delay = u.interval
+	}
+}
+
+// retryDelay returns the delay before the next update after a number of failures
+func (u *GeoIPUpdater) retryDelay(failures int) time.Duration {
+	delay := u.minRetryDelay
+	for i := 1; i < failures && delay < u.interval; i++ {
+		delay *= 2
+	}
+
+	if delay > u.interval {
+		delay = u.interval
+	}
+
+	return delay
+}
+
+// update downloads all the databases, even after an error in one of them,
+// and notifies the change only if the content of a database changed
+func (u *GeoIPUpdater) update() error {
+	var firstErr error
+	changed := false
+	for _, dbName := range strings.Split(MaxmindEditionIDs, ",") {
+		dbChanged, err := downloadDatabase(dbName)
+		if err != nil {
+			klog.Warningf("Error updating GeoIP2 database %v: %v", dbName, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		changed = changed || dbChanged
+	}
+
+	if changed {
+		u.onUpdate(GeoIPDatabases())
+		u.reportDatabases()
+	}
+
+	return firstErr
+}
+
+func (u *GeoIPUpdater) reportDatabases() {
+	for _, file := range GeoIPDatabases() {
+		buildTime, err := GeoIPDatabaseBuildTime(file)
+		if err != nil {
+			klog.Warningf("Error reading the metadata of GeoIP2 database %v: %v", file, err)
+			continue
+		}
+
+		u.onDatabase(strings.TrimSuffix(file, dbExtension), buildTime)
+	}
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ModSecurityAuditLogPath is the path of the ModSecurity audit log in JSON
+// format read by the controller when the audit events are enabled
+var ModSecurityAuditLogPath = "/var/log/audit/modsec_audit.json"
+
+const (
+	// ModSecurityIngressRuleID is the ID of the rule rendered in each location
+	// that tags the audit events with the Ingress of the location
+	ModSecurityIngressRuleID = "99990"
+	// ModSecurityIngressTag prefixes the tag with the Ingress of an audit
+	// event, as <namespace>/<name>
+	ModSecurityIngressTag = "ingress-nginx/ingress="
+)
+
+const (
+	// modSecurityAuditInterval is the interval between reads of the audit log
+	modSecurityAuditInterval = 1 * time.Second
+	// modSecurityAuditMaxSize is the size of the audit log truncated after a read
+	modSecurityAuditMaxSize = 16 * 1024 * 1024
+)
+
+// ModSecurityAuditEvent contains a request that matched ModSecurity rules
+type ModSecurityAuditEvent struct {
+	Namespace string                 `json:"namespace"`
+	Ingress   string                 `json:"ingress"`
+	RequestID string                 `json:"requestID"`
+	Time      string                 `json:"time"`
+	ClientIP  string                 `json:"clientIP"`
+	Host      string                 `json:"host"`
+	Method    string                 `json:"method"`
+	URI       string                 `json:"uri"`
+	Status    int                    `json:"status"`
+	Rules     []ModSecurityRuleMatch `json:"rules"`
+}
+
+// ModSecurityRuleMatch contains a rule matched by a request
+type ModSecurityRuleMatch struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Severity string   `json:"severity,omitempty"`
+	Data     string   `json:"data,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// RuleIDs returns the IDs of the rules matched by the request
+func (e *ModSecurityAuditEvent) RuleIDs() []string {
+	ids := make([]string, 0, len(e.Rules))
+	for _, rule := range e.Rules {
+		if rule.ID != "" {
+			ids = append(ids, rule.ID)
+		}
+	}
+
+	return ids
+}
+
+// modSecurityAuditEntry is an entry of the audit log written by libmodsecurity
+type modSecurityAuditEntry struct {
+	Transaction struct {
+		ClientIP  string `json:"client_ip"`
+		TimeStamp string `json:"time_stamp"`
+		UniqueID  string `json:"unique_id"`
+		Request   struct {
+			Method  string            `json:"method"`
+			URI     string            `json:"uri"`
+			Headers map[string]string `json:"headers"`
+		} `json:"request"`
+		Response struct {
+			HTTPCode int `json:"http_code"`
+		} `json:"response"`
+		Messages []struct {
+			Message string `json:"message"`
+			Details struct {
+				RuleID   string   `json:"ruleId"`
+				Severity string   `json:"severity"`
+				Data     string   `json:"data"`
+				Tags     []string `json:"tags"`
+			} `json:"details"`
+		} `json:"messages"`
+	} `json:"transaction"`
+}
+
+// parseModSecurityAuditEvent parses an entry of the audit log. The Ingress
+// of the event is read from the tag of the rule ModSecurityIngressRuleID.
+func parseModSecurityAuditEvent(line []byte) (*ModSecurityAuditEvent, error) {
+	entry := &modSecurityAuditEntry{}
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, err
+	}
+
+	transaction := entry.Transaction
+	event := &ModSecurityAuditEvent{
+		RequestID: transaction.UniqueID,
+		Time:      transaction.TimeStamp,
+		ClientIP:  transaction.ClientIP,
+		Method:    transaction.Request.Method,
+		URI:       transaction.Request.URI,
+		Status:    transaction.Response.HTTPCode,
+	}
+
+	for name, value := range transaction.Request.Headers {
+		if strings.EqualFold(name, "Host") {
+			event.Host = value
+			break
+		}
+	}
+
+	for _, message := range transaction.Messages {
+		if message.Details.RuleID == ModSecurityIngressRuleID {
+			for _, tag := range message.Details.Tags {
+				if !strings.HasPrefix(tag, ModSecurityIngressTag) {
+					continue
+				}
+
+				if parts := strings.SplitN(strings.TrimPrefix(tag, ModSecurityIngressTag), "/", 2); len(parts) == 2 {
+					event.Namespace = parts[0]
+					event.Ingress = parts[1]
+				}
+			}
+
+			continue
+		}
+
+		event.Rules = append(event.Rules, ModSecurityRuleMatch{
+			ID:       message.Details.RuleID,
+			Message:  message.Message,
+			Severity: message.Details.Severity,
+			Data:     message.Details.Data,
+			Tags:     message.Details.Tags,
+		})
+	}
+
+	return event, nil
+}
+
+// ModSecurityAuditReader reads the events of the ModSecurity audit log
+type ModSecurityAuditReader struct {
+	path     string
+	interval time.Duration
+	maxSize  int64
+
+	// onEvent is called with each event appended to the audit log
+	onEvent func(*ModSecurityAuditEvent)
+
+	initialized bool
+	offset      int64
+	// partial contains the last line read, not terminated yet
+	partial []byte
+}
+
+// NewModSecurityAuditReader creates a new ModSecurityAuditReader of the audit log in ModSecurityAuditLogPath
+func NewModSecurityAuditReader(onEvent func(*ModSecurityAuditEvent)) *ModSecurityAuditReader {
+	return &ModSecurityAuditReader{
+		path:     ModSecurityAuditLogPath,
+		interval: modSecurityAuditInterval,
+		maxSize:  modSecurityAuditMaxSize,
+		onEvent:  onEvent,
+	}
+}
+
+// Run reads the events appended to the audit log until the stop channel is
+// closed. The events written before the first read are skipped.
+func (r *ModSecurityAuditReader) Run(stopCh <-chan struct{}) {
+	for {
+		if err := r.read(); err != nil {
+			klog.Warningf("Error reading ModSecurity audit log %v: %v", r.path, err)
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// read parses the lines appended to the audit log since the last read. The
+// audit log is truncated when it exceeds the maximum size, so NGINX does not
+// fill the disk. The file cannot be rotated because libmodsecurity does not
+// reopen it, so the audit log is only truncated when its size did not change
+// since the read. Events written between that check and the truncation are
+// lost.
+func (r *ModSecurityAuditReader) read() error {
+	f, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		r.initialized = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := info.Size()
+	if !r.initialized {
+		r.initialized = true
+		r.offset = size
+		return nil
+	}
+
+	if size < r.offset {
+		// the audit log was truncated or replaced
+		r.offset = 0
+		r.partial = nil
+	}
+
+	if size == r.offset {
+		return nil
+	}
+
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, size-r.offset))
+	if err != nil {
+		return err
+	}
+
+	r.offset += int64(len(data))
+	data = append(r.partial, data...)
+
+	lines := bytes.Split(data, []byte("\n"))
+	r.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		event, err := parseModSecurityAuditEvent(line)
+		if err != nil {
+			klog.Warningf("Error parsing ModSecurity audit event: %v", err)
+			continue
+		}
+
+		r.onEvent(event)
+	}
+
+	if r.offset >= r.maxSize && len(r.partial) == 0 {
+		info, err := os.Stat(r.path)
+		if err != nil {
+			return err
+		}
+
+		if info.Size() != r.offset {
+			// the new events are read first
+			return nil
+		}
+
+		if err := os.Truncate(r.path, 0); err != nil {
+			return err
+		}
+
+		r.offset = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// auditEntry returns an entry of the audit log like the ones written by libmodsecurity,
+// with the message of the rule tagging the event with the Ingress when it is not empty
+func auditEntry(ing, uniqueID string, ruleIDs ...string) string {
+	var messages []string
+	for _, id := range ruleIDs {
+		messages = append(messages, fmt.Sprintf(`{"message":"rule %v","details":{"ruleId":"%v","severity":"2","data":"","tags":["attack-sqli"]}}`, id, id))
+	}
+
+	if ing != "" {
+		messages = append(messages, fmt.Sprintf(`{"message":"","details":{"ruleId":"%v","severity":"","data":"","tags":["%v%v"]}}`,
+			ModSecurityIngressRuleID, ModSecurityIngressTag, ing))
+	}
+
+	return fmt.Sprintf(`{"transaction":{"client_ip":"10.0.0.1","time_stamp":"Mon Mar  8 10:00:00 2021","unique_id":"%v",`+
+		`"request":{"method":"GET","uri":"/?id=1%%27","headers":{"host":"example.com"}},`+
+		`"response":{"http_code":403},"messages":[%v]}}`, uniqueID, strings.Join(messages, ","))
+}
+
+func TestParseModSecurityAuditEvent(t *testing.T) {
+	event, err := parseModSecurityAuditEvent([]byte(auditEntry("tenant-a/app", "abc123", "942100", "949110")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &ModSecurityAuditEvent{
+		Namespace: "tenant-a",
+		Ingress:   "app",
+		RequestID: "abc123",
+		Time:      "Mon Mar  8 10:00:00 2021",
+		ClientIP:  "10.0.0.1",
+		Host:      "example.com",
+		Method:    "GET",
+		URI:       "/?id=1%27",
+		Status:    403,
+		Rules: []ModSecurityRuleMatch{
+			{ID: "942100", Message: "rule 942100", Severity: "2", Tags: []string{"attack-sqli"}},
+			{ID: "949110", Message: "rule 949110", Severity: "2", Tags: []string{"attack-sqli"}},
+		},
+	}
+	if !reflect.DeepEqual(event, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, event)
+	}
+
+	if ids := event.RuleIDs(); !reflect.DeepEqual(ids, []string{"942100", "949110"}) {
+		t.Errorf("unexpected rule IDs %v", ids)
+	}
+
+	event, err = parseModSecurityAuditEvent([]byte(auditEntry("", "abc123", "942100")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Namespace != "" || event.Ingress != "" || event.RequestID != "abc123" {
+		t.Errorf("expected an event without Ingress but returned %+v", event)
+	}
+
+	if _, err := parseModSecurityAuditEvent([]byte("{")); err == nil {
+		t.Errorf("expected an error parsing an invalid entry")
+	}
+}
+
+func TestModSecurityAuditReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modsecurity")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logPath := path.Join(dir, "modsec_audit.json")
+
+	var requestIDs []string
+	// written while the events are read, like the events logged by NGINX
+	var concurrentEntry string
+	reader := &ModSecurityAuditReader{
+		path:    logPath,
+		maxSize: 1024 * 1024,
+	}
+
+	appendLog := func(data string) {
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer f.Close()
+
+		if _, err := f.WriteString(data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	reader.onEvent = func(event *ModSecurityAuditEvent) {
+		requestIDs = append(requestIDs, event.RequestID)
+		if concurrentEntry != "" {
+			appendLog(concurrentEntry)
+			concurrentEntry = ""
+		}
+	}
+
+	read := func(expected ...string) {
+		requestIDs = nil
+		if err := reader.read(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(requestIDs) != len(expected) || (len(expected) > 0 && !reflect.DeepEqual(requestIDs, expected)) {
+			t.Errorf("expected the events %v but returned %v", expected, requestIDs)
+		}
+	}
+
+	// the events written before the first read are skipped
+	appendLog(auditEntry("default/app", "old") + "\n")
+	read()
+
+	entry := auditEntry("default/app", "2")
+	appendLog(auditEntry("default/app", "1") + "\n" + entry[:20])
+	read("1")
+
+	// partial lines are parsed once complete, invalid lines are skipped
+	appendLog(entry[20:] + "\n" + "invalid\n")
+	read("2")
+
+	// the audit log is read from the start after it is truncated
+	if err := os.Truncate(logPath, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	appendLog(auditEntry("default/app", "3") + "\n")
+	read("3")
+
+	// the audit log is truncated once it exceeds the maximum size,
+	// but not while events are appended
+	reader.maxSize = 1
+	concurrentEntry = auditEntry("default/app", "5") + "\n"
+	appendLog(auditEntry("default/app", "4") + "\n")
+	read("4")
+	read("5")
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("expected the audit log to be truncated but the size is %v", info.Size())
+	}
+
+	appendLog(auditEntry("default/app", "6") + "\n")
+	read("6")
+}
@@ -19,8 +19,9 @@ limitations under the License.
 package runtime
 
 import (
+	"bufio"
 	"io/ioutil"
-	"math"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -29,35 +30,119 @@ import (
 	libcontainercgroups "github.com/opencontainers/runc/libcontainer/cgroups"
 )
 
+// cgroupV2Root is the mount point of the unified cgroup v2 hierarchy
+var cgroupV2Root = "/sys/fs/cgroup"
+
 // NumCPU returns the number of logical CPUs usable by the current process.
-// If CPU cgroups limits are configured, use cfs_quota_us / cfs_period_us
-// as formula
+// If CPU cgroups limits are configured, use the CPU quota of the cgroup,
+// from cpu.max (v2) or cfs_quota_us / cfs_period_us (v1)
 //  https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt
 func NumCPU() int {
-	cpus := runtime.NumCPU()
+	return DetectResources().CPUs
+}
+
+// DetectResources returns the CPUs and memory available to the current
+// process, from the cgroup v1 or v2 limits and the allowed CPUs
+func DetectResources() Resources {
+	// runtime.NumCPU already honors the CPUs the process is allowed to run on
+	resources := Resources{
+		CPUs:   runtime.NumCPU(),
+		CPUSet: allowedCPUs(),
+	}
+
+	limitCPUs := 0
+	if isCgroup2UnifiedMode() {
+		resources.CgroupVersion = 2
+
+		cgroupPath := cgroupV2Path()
+		if cpus, ok := parseCPUMax(readCgroupFile(cgroupPath, "cpu.max")); ok {
+			limitCPUs = cpus
+		}
+
+		resources.MemoryLimit = parseMemoryLimit(readCgroupFile(cgroupPath, "memory.max"))
+	} else if cgroupPath, err := libcontainercgroups.FindCgroupMountpoint("", "cpu"); err == nil {
+		resources.CgroupVersion = 1
+
+		cpuQuota := readCgroupFileToInt64(cgroupPath, "cpu.cfs_quota_us")
+		cpuPeriod := readCgroupFileToInt64(cgroupPath, "cpu.cfs_period_us")
+		if cpus, ok := quotaCPUs(cpuQuota, cpuPeriod); ok {
+			limitCPUs = cpus
+		}
+
+		if memoryPath, err := libcontainercgroups.FindCgroupMountpoint("", "memory"); err == nil {
+			resources.MemoryLimit = parseMemoryLimit(readCgroupFile(memoryPath, "memory.limit_in_bytes"))
+		}
+	}
+
+	if limitCPUs > 0 && limitCPUs < resources.CPUs {
+		resources.CPUs = limitCPUs
+	}
+
+	return resources
+}
 
-	cgroupPath, err := libcontainercgroups.FindCgroupMountpoint("", "cpu")
+// isCgroup2UnifiedMode returns true if the cgroup v2 hierarchy is mounted
+func isCgroup2UnifiedMode() bool {
+	_, err := os.Stat(filepath.Join(cgroupV2Root, "cgroup.controllers"))
+	return err == nil
+}
+
+// cgroupV2Path returns the directory of the cgroup v2 of the current process.
+// Inside a cgroup namespace the cgroup of the process is the root.
+func cgroupV2Path() string {
+	file, err := os.Open("/proc/self/cgroup")
 	if err != nil {
-		return cpus
+		return cgroupV2Root
 	}
+	defer file.Close()
 
-	cpuQuota := readCgroupFileToInt64(cgroupPath, "cpu.cfs_quota_us")
-	cpuPeriod := readCgroupFileToInt64(cgroupPath, "cpu.cfs_period_us")
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// the entry of cgroup v2 has the format "0::$PATH"
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "0::") {
+			continue
+		}
 
-	if cpuQuota == -1 || cpuPeriod == -1 {
-		return cpus
+		path := filepath.Join(cgroupV2Root, strings.TrimPrefix(line, "0::"))
+		if _, err := os.Stat(filepath.Join(path, "cpu.max")); err == nil {
+			return path
+		}
 	}
 
-	return int(math.Ceil(float64(cpuQuota) / float64(cpuPeriod)))
+	return cgroupV2Root
 }
 
-func readCgroupFileToInt64(cgroupPath, cgroupFile string) int64 {
+// allowedCPUs returns the CPUs the current process is allowed to run on
+func allowedCPUs() []int {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Cpus_allowed_list:") {
+			return parseCPUList(strings.TrimPrefix(line, "Cpus_allowed_list:"))
+		}
+	}
+
+	return nil
+}
+
+func readCgroupFile(cgroupPath, cgroupFile string) string {
 	contents, err := ioutil.ReadFile(filepath.Join(cgroupPath, cgroupFile))
 	if err != nil {
-		return -1
+		return ""
 	}
 
-	strValue := strings.TrimSpace(string(contents))
+	return strings.TrimSpace(string(contents))
+}
+
+func readCgroupFileToInt64(cgroupPath, cgroupFile string) int64 {
+	strValue := readCgroupFile(cgroupPath, cgroupFile)
 	if value, err := strconv.ParseInt(strValue, 10, 64); err == nil {
 		return value
 	}
@@ -26,3 +26,15 @@ import (
 func NumCPU() int {
 	return runtime.NumCPU()
 }
+
+// DetectResources returns the CPUs available to the current process.
+// The cgroup limits are only detected in Linux.
+func DetectResources() Resources {
+	cpus := runtime.NumCPU()
+	resources := Resources{CPUs: cpus}
+	for cpu := 0; cpu < cpus; cpu++ {
+		resources.CPUSet = append(resources.CPUSet, cpu)
+	}
+
+	return resources
+}
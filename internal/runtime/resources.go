@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxMemoryLimit is the highest memory limit considered a real limit.
+// cgroup v1 reports a value close to the maximum int64 without a limit.
+const maxMemoryLimit = 1 << 62
+
+// Resources contains the resources available to the current process
+type Resources struct {
+	// CgroupVersion is the version of the cgroup hierarchy, 0 if unknown
+	CgroupVersion int
+	// CPUs is the number of CPUs usable by the process, from the CPU
+	// quota of the cgroup and the CPUs the process is allowed to run on
+	CPUs int
+	// CPUSet contains the IDs of the CPUs the process is allowed to run on
+	CPUSet []int
+	// MemoryLimit is the memory limit of the cgroup in bytes, 0 without a limit
+	MemoryLimit int64
+}
+
+// parseCPUMax returns the number of CPUs of a CPU quota
+// in the format of the cgroup v2 file cpu.max, "$MAX $PERIOD"
+func parseCPUMax(content string) (int, bool) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+
+	quota, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	period, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return quotaCPUs(quota, period)
+}
+
+// quotaCPUs returns the number of CPUs of a CPU quota, using the formula quota / period
+// rounded up (https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt)
+func quotaCPUs(quota, period int64) (int, bool) {
+	if quota <= 0 || period <= 0 {
+		return 0, false
+	}
+
+	return int(math.Ceil(float64(quota) / float64(period))), true
+}
+
+// parseCPUList parses a list of CPUs like "0-3,8,10-11", the format
+// of the files cpuset.cpus and the field Cpus_allowed_list of /proc/self/status
+func parseCPUList(content string) []int {
+	var cpus []int
+	for _, item := range strings.Split(strings.TrimSpace(content), ",") {
+		if item == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil
+			}
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	sort.Ints(cpus)
+	return cpus
+}
+
+// parseMemoryLimit parses the memory limit of a cgroup, from the files
+// memory.max (v2) or memory.limit_in_bytes (v1). It returns 0 without a limit.
+func parseMemoryLimit(content string) int64 {
+	content = strings.TrimSpace(content)
+	if content == "" || content == "max" {
+		return 0
+	}
+
+	limit, err := strconv.ParseInt(content, 10, 64)
+	if err != nil || limit <= 0 || limit >= maxMemoryLimit {
+		return 0
+	}
+
+	return limit
+}
+
+// CPUAffinityMask returns the mask of the given CPUs used by the
+// NGINX directive worker_cpu_affinity, with the first CPU on the right
+func CPUAffinityMask(cpus []int) string {
+	if len(cpus) == 0 {
+		return ""
+	}
+
+	highest := 0
+	for _, cpu := range cpus {
+		if cpu > highest {
+			highest = cpu
+		}
+	}
+
+	mask := []byte(strings.Repeat("0", highest+1))
+	for _, cpu := range cpus {
+		mask[highest-cpu] = '1'
+	}
+
+	return string(mask)
+}
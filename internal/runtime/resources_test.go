@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCPUMax(t *testing.T) {
+	testCases := []struct {
+		content string
+		cpus    int
+		ok      bool
+	}{
+		{"max 100000", 0, false},
+		{"200000 100000", 2, true},
+		{"150000 100000", 2, true},
+		{"50000 100000", 1, true},
+		{"invalid", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range testCases {
+		cpus, ok := parseCPUMax(tc.content)
+		if cpus != tc.cpus || ok != tc.ok {
+			t.Errorf("%q: expected %v %v but returned %v %v", tc.content, tc.cpus, tc.ok, cpus, ok)
+		}
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	testCases := []struct {
+		content string
+		cpus    []int
+	}{
+		{"0-3", []int{0, 1, 2, 3}},
+		{" 8,0-1,10-11\n", []int{0, 1, 8, 10, 11}},
+		{"5", []int{5}},
+		{"3-1", nil},
+		{"a-b", nil},
+		{"", nil},
+	}
+
+	for _, tc := range testCases {
+		if cpus := parseCPUList(tc.content); !reflect.DeepEqual(cpus, tc.cpus) {
+			t.Errorf("%q: expected %v but returned %v", tc.content, tc.cpus, cpus)
+		}
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	testCases := []struct {
+		content string
+		limit   int64
+	}{
+		{"max", 0},
+		{"536870912\n", 536870912},
+		{"9223372036854771712", 0},
+		{"", 0},
+	}
+
+	for _, tc := range testCases {
+		if limit := parseMemoryLimit(tc.content); limit != tc.limit {
+			t.Errorf("%q: expected %v but returned %v", tc.content, tc.limit, limit)
+		}
+	}
+}
+
+func TestCPUAffinityMask(t *testing.T) {
+	testCases := []struct {
+		cpus []int
+		mask string
+	}{
+		{nil, ""},
+		{[]int{0}, "1"},
+		{[]int{0, 1, 2, 3}, "1111"},
+		{[]int{2, 3}, "1100"},
+		{[]int{1, 4}, "10010"},
+	}
+
+	for _, tc := range testCases {
+		if mask := CPUAffinityMask(tc.cpus); mask != tc.mask {
+			t.Errorf("%v: expected %q but returned %q", tc.cpus, tc.mask, mask)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package task
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -47,6 +48,14 @@ type Queue struct {
 	fn func(obj interface{}) (interface{}, error)
 	// lastSync is the Unix epoch time of the last execution of 'sync'
 	lastSync int64
+	// minInterval is the minimum time between two executions of 'sync'.
+	// Elements inserted while waiting are coalesced in a single execution.
+	minInterval time.Duration
+	// maxDelay limits the time an element waits for the minimum interval
+	maxDelay time.Duration
+	// shutdownCh is closed when the queue is shutting down
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
 }
 
 // Element represents one item of the queue
@@ -54,6 +63,8 @@ type Element struct {
 	Key         interface{}
 	Timestamp   int64
 	IsSkippable bool
+	// Added is the Unix epoch time when the element was inserted
+	Added int64
 }
 
 // Run starts processing elements in the queue
@@ -79,7 +90,8 @@ func (t *Queue) enqueue(obj interface{}, skippable bool) {
 		return
 	}
 
-	ts := time.Now().UnixNano()
+	now := time.Now().UnixNano()
+	ts := now
 	if !skippable {
 		// make sure the timestamp is bigger than lastSync
 		ts = time.Now().Add(24 * time.Hour).UnixNano()
@@ -93,6 +105,7 @@ func (t *Queue) enqueue(obj interface{}, skippable bool) {
 	t.queue.Add(Element{
 		Key:       key,
 		Timestamp: ts,
+		Added:     now,
 	})
 }
 
@@ -115,7 +128,6 @@ func (t *Queue) worker() {
 			}
 			return
 		}
-		ts := time.Now().UnixNano()
 
 		item := key.(Element)
 		if t.lastSync > item.Timestamp {
@@ -125,12 +137,17 @@ func (t *Queue) worker() {
 			continue
 		}
 
+		// elements inserted while waiting are older than ts and skipped
+		t.coalesce(item)
+		ts := time.Now().UnixNano()
+
 		klog.V(3).InfoS("syncing", "key", item.Key)
 		if err := t.sync(key); err != nil {
 			klog.ErrorS(err, "requeuing", "key", item.Key)
 			t.queue.AddRateLimited(Element{
 				Key:       item.Key,
 				Timestamp: time.Now().UnixNano(),
+				Added:     item.Added,
 			})
 		} else {
 			t.queue.Forget(key)
@@ -141,6 +158,41 @@ func (t *Queue) worker() {
 	}
 }
 
+// coalesce waits until the minimum interval since the last execution
+// of sync elapses, or the element reaches the maximum delay.
+func (t *Queue) coalesce(item Element) {
+	delay := t.coalesceDelay(item, time.Now())
+	if delay <= 0 {
+		return
+	}
+
+	klog.V(3).InfoS("coalescing changes", "key", item.Key, "delay", delay)
+	select {
+	case <-time.After(delay):
+	case <-t.shutdownCh:
+	}
+}
+
+// coalesceDelay returns the time to wait before processing an element
+func (t *Queue) coalesceDelay(item Element, now time.Time) time.Duration {
+	if t.minInterval <= 0 || t.lastSync == 0 {
+		return 0
+	}
+
+	delay := time.Unix(0, t.lastSync).Add(t.minInterval).Sub(now)
+	if t.maxDelay > 0 && item.Added > 0 {
+		if remaining := time.Unix(0, item.Added).Add(t.maxDelay).Sub(now); remaining < delay {
+			delay = remaining
+		}
+	}
+
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
+
 func isClosed(ch <-chan bool) bool {
 	select {
 	case <-ch:
@@ -153,6 +205,9 @@ func isClosed(ch <-chan bool) bool {
 
 // Shutdown shuts down the work queue and waits for the worker to ACK
 func (t *Queue) Shutdown() {
+	t.shutdownOnce.Do(func() {
+		close(t.shutdownCh)
+	})
 	t.queue.ShutDown()
 	<-t.workerDone
 }
@@ -170,11 +225,35 @@ func NewTaskQueue(syncFn func(interface{}) error) *Queue {
 
 // NewCustomTaskQueue ...
 func NewCustomTaskQueue(syncFn func(interface{}) error, fn func(interface{}) (interface{}, error)) *Queue {
+	return newQueue("", syncFn, fn)
+}
+
+// NewNamedTaskQueue creates a new task queue with the given sync function.
+// The name identifies the metrics of the queue.
+func NewNamedTaskQueue(name string, syncFn func(interface{}) error) *Queue {
+	return newQueue(name, syncFn, nil)
+}
+
+// NewCoalescingTaskQueue creates a new named task queue that waits at least
+// minInterval between two executions of the sync function. The elements
+// inserted in the meantime are processed by a single execution.
+// maxDelay limits the time an element waits since it was inserted;
+// zero means no limit.
+func NewCoalescingTaskQueue(name string, syncFn func(interface{}) error, minInterval, maxDelay time.Duration) *Queue {
+	q := newQueue(name, syncFn, nil)
+	q.minInterval = minInterval
+	q.maxDelay = maxDelay
+
+	return q
+}
+
+func newQueue(name string, syncFn func(interface{}) error, fn func(interface{}) (interface{}, error)) *Queue {
 	q := &Queue{
-		queue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
 		sync:       syncFn,
 		workerDone: make(chan bool),
 		fn:         fn,
+		shutdownCh: make(chan struct{}),
 	}
 
 	if fn == nil {
@@ -157,3 +157,65 @@ func TestSkipEnqueue(t *testing.T) {
 	// shutdown queue before exit
 	q.Shutdown()
 }
+
+func TestCoalesceDelay(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name        string
+		minInterval time.Duration
+		maxDelay    time.Duration
+		lastSync    time.Time
+		added       time.Time
+		expected    time.Duration
+	}{
+		{"without minimum interval", 0, 0, now.Add(-time.Second), now, 0},
+		{"first sync", 10 * time.Second, 0, time.Time{}, now, 0},
+		{"interval elapsed", 10 * time.Second, 0, now.Add(-20 * time.Second), now, 0},
+		{"waits the rest of the interval", 10 * time.Second, 0, now.Add(-4 * time.Second), now, 6 * time.Second},
+		{"limited by the maximum delay", 10 * time.Second, 5 * time.Second, now.Add(-4 * time.Second), now.Add(-2 * time.Second), 3 * time.Second},
+		{"maximum delay reached", 10 * time.Second, 5 * time.Second, now.Add(-4 * time.Second), now.Add(-6 * time.Second), 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := NewCoalescingTaskQueue("", mockSynFn, tc.minInterval, tc.maxDelay)
+			if !tc.lastSync.IsZero() {
+				q.lastSync = tc.lastSync.UnixNano()
+			}
+
+			delay := q.coalesceDelay(Element{Added: tc.added.UnixNano()}, now)
+			if delay != tc.expected {
+				t.Errorf("expected a delay of %v but returned %v", tc.expected, delay)
+			}
+		})
+	}
+}
+
+func TestCoalescingQueue(t *testing.T) {
+	// initialize result
+	atomic.StoreUint32(&sr, 0)
+	q := NewCoalescingTaskQueue("", mockSynFn, 200*time.Millisecond, 0)
+	stopCh := make(chan struct{})
+	// run queue
+	go q.Run(time.Second, stopCh)
+
+	q.EnqueueSkippableTask(GetDummyObject("first"))
+	time.Sleep(time.Millisecond * 10)
+	if atomic.LoadUint32(&sr) != 1 {
+		t.Errorf("sr should be 1, but is %d", sr)
+	}
+
+	// changes received during the minimum interval use a single sync
+	for i := 0; i < 5; i++ {
+		q.EnqueueSkippableTask(GetDummyObject(fmt.Sprintf("change-%v", i)))
+	}
+
+	time.Sleep(time.Millisecond * 400)
+	if atomic.LoadUint32(&sr) != 2 {
+		t.Errorf("sr should be 2, but is %d", sr)
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
@@ -25,6 +25,34 @@ function _M.get_general_data()
   return configuration_data:get("general")
 end
 
+function _M.get_locations_data()
+  return configuration_data:get("locations")
+end
+
+function _M.get_error_pages_data()
+  return configuration_data:get("error_pages")
+end
+
+function _M.is_draining()
+  return configuration_data:get("draining") == true
+end
+
+function _M.get_raw_locations_last_synced_at()
+  local raw_locations_last_synced_at = configuration_data:get("raw_locations_last_synced_at")
+  if raw_locations_last_synced_at == nil then
+    raw_locations_last_synced_at = 1
+  end
+  return raw_locations_last_synced_at
+end
+
+function _M.get_raw_error_pages_last_synced_at()
+  local raw_error_pages_last_synced_at = configuration_data:get("raw_error_pages_last_synced_at")
+  if raw_error_pages_last_synced_at == nil then
+    raw_error_pages_last_synced_at = 1
+  end
+  return raw_error_pages_last_synced_at
+end
+
 function _M.get_raw_backends_last_synced_at()
   local raw_backends_last_synced_at = configuration_data:get("raw_backends_last_synced_at")
   if raw_backends_last_synced_at == nil then
@@ -220,6 +248,89 @@ local function handle_backends()
   ngx.status = ngx.HTTP_CREATED
 end
 
+local function handle_locations()
+  if ngx.var.request_method == "GET" then
+    ngx.status = ngx.HTTP_OK
+    ngx.print(_M.get_locations_data())
+    return
+  end
+
+  local locations = fetch_request_body()
+  if not locations then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local success, err = configuration_data:set("locations", locations)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating locations: " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  -- ngx.now() instead of ngx.time() allows more than one update per second
+  ngx.update_time()
+  success, err = configuration_data:set("raw_locations_last_synced_at", ngx.now())
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating when locations sync: " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+local function handle_error_pages()
+  if ngx.var.request_method == "GET" then
+    ngx.status = ngx.HTTP_OK
+    ngx.print(_M.get_error_pages_data())
+    return
+  end
+
+  local error_pages = fetch_request_body()
+  if not error_pages then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local success, err = configuration_data:set("error_pages", error_pages)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating error pages: " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  ngx.update_time()
+  success, err = configuration_data:set("raw_error_pages_last_synced_at", ngx.now())
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating when error pages sync: " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+local function handle_drain()
+  if ngx.var.request_method == "GET" then
+    ngx.status = ngx.HTTP_OK
+    ngx.print(tostring(_M.is_draining()))
+    return
+  end
+
+  -- draining starts during the shutdown of the controller and never stops
+  local success, err = configuration_data:safe_set("draining", true)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error starting the drain: " .. tostring(err))
+    ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
 function _M.call()
   if ngx.var.request_method ~= "POST" and ngx.var.request_method ~= "GET" then
     ngx.status = ngx.HTTP_BAD_REQUEST
@@ -247,6 +358,21 @@ function _M.call()
     return
   end
 
+  if ngx.var.request_uri == "/configuration/locations" then
+    handle_locations()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/error-pages" then
+    handle_error_pages()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/drain" then
+    handle_drain()
+    return
+  end
+
   ngx.status = ngx.HTTP_NOT_FOUND
   ngx.print("Not found!")
 end
@@ -0,0 +1,165 @@
+local cjson = require("cjson.safe")
+local resty_ipmatcher = require("resty.ipmatcher")
+local configuration = require("configuration")
+local util = require("util")
+
+local ngx = ngx
+local ipairs = ipairs
+local pairs = pairs
+local string_sub = string.sub
+
+-- routes are synced from the shared dictionary every second
+local LOCATIONS_SYNC_INTERVAL = 1
+
+-- used when a route does not define a global rate limit
+local NO_GLOBAL_THROTTLE = { namespace = "", limit = 0, window_size = 0, ignored_cidrs = {} }
+
+local _M = {}
+
+-- routes of each server. The controller sorts the routes of a server in
+-- the order used to match a request: exact locations first, followed by
+-- prefix locations from the longest to the shortest path.
+local servers = {}
+local locations_last_synced_at = 0
+
+local function prepare_route(route)
+  if route.whitelist and #route.whitelist > 0 then
+    local matcher, err = resty_ipmatcher.new(route.whitelist)
+    if not matcher then
+      ngx.log(ngx.ERR, "failed to initialize resty-ipmatcher for location ",
+              route.path, ": ", err)
+      -- deny the requests instead of allowing all the clients
+      route.denied = true
+    end
+    route.whitelist_matcher = matcher
+  end
+
+  if route.redirect then
+    route.redirect.parsed_url = util.parse_complex_value(route.redirect.url)
+  end
+
+  local throttle = route.globalThrottle
+  if throttle then
+    route.global_throttle = {
+      namespace = throttle.namespace,
+      limit = throttle.limit,
+      window_size = throttle.windowSize,
+      key = util.parse_complex_value(throttle.key or ""),
+      ignored_cidrs = throttle.ignoredCIDRs or {},
+    }
+  end
+end
+
+local function sync_locations()
+  local raw_locations_last_synced_at = configuration.get_raw_locations_last_synced_at()
+  if raw_locations_last_synced_at <= locations_last_synced_at then
+    return
+  end
+
+  local locations_data = configuration.get_locations_data()
+  if not locations_data then
+    servers = {}
+    return
+  end
+
+  local new_servers, err = cjson.decode(locations_data)
+  if not new_servers then
+    ngx.log(ngx.ERR, "could not parse locations data: ", err)
+    return
+  end
+
+  for _, routes in pairs(new_servers) do
+    for _, route in ipairs(routes) do
+      prepare_route(route)
+    end
+  end
+
+  servers = new_servers
+  locations_last_synced_at = raw_locations_last_synced_at
+end
+
+local function find_route(routes, uri)
+  for _, route in ipairs(routes) do
+    if route.exact then
+      if uri == route.path then
+        return route
+      end
+    elseif string_sub(uri, 1, #route.path) == route.path then
+      return route
+    end
+  end
+
+  return nil
+end
+
+local function is_allowed(route)
+  if not route.whitelist_matcher then
+    return true
+  end
+
+  local allowed, err = route.whitelist_matcher:match(ngx.var.remote_addr)
+  if err then
+    ngx.log(ngx.ERR, "failed to match ip '", ngx.var.remote_addr, "': ", err)
+    return false
+  end
+
+  return allowed
+end
+
+function _M.init_worker()
+  sync_locations()
+
+  local ok, err = ngx.timer.every(LOCATIONS_SYNC_INTERVAL, sync_locations)
+  if not ok then
+    ngx.log(ngx.ERR, "error when setting up timer.every for sync_locations: ", err)
+  end
+end
+
+-- rewrite routes the request to the location of the server matching the
+-- URI and applies the settings of the location. It returns the location
+-- configuration used by lua_ingress.rewrite. Until the routes of the server
+-- are synced the requests are rejected, since the whitelists and redirects
+-- of the routed locations are not rendered in nginx.conf.
+function _M.rewrite(hostname, location_config)
+  local routes = servers[hostname]
+  if not routes then
+    ngx.log(ngx.WARN, "routes of server ", hostname, " not synced yet, rejecting the request")
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local route = find_route(routes, ngx.var.uri)
+  if not route then
+    return location_config
+  end
+
+  ngx.var.proxy_upstream_name = route.upstream
+  ngx.var.proxy_host = route.upstream
+  ngx.var.namespace = route.namespace
+  ngx.var.ingress_name = route.ingress
+  ngx.var.service_name = route.service
+  ngx.var.service_port = route.servicePort
+  ngx.var.location_path = route.locationPath
+
+  if route.denied then
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  if not is_allowed(route) then
+    return ngx.exit(ngx.HTTP_FORBIDDEN)
+  end
+
+  if route.redirect then
+    return ngx.redirect(util.generate_var_value(route.redirect.parsed_url), route.redirect.code)
+  end
+
+  location_config.global_throttle = route.global_throttle or NO_GLOBAL_THROTTLE
+
+  return location_config
+end
+
+setmetatable(_M, {__index = {
+  sync_locations = sync_locations,
+  get_servers = function() return servers end,
+}})
+
+return _M
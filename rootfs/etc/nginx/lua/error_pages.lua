@@ -0,0 +1,154 @@
+local cjson = require("cjson.safe")
+local configuration = require("configuration")
+
+local ngx = ngx
+local ipairs = ipairs
+local tonumber = tonumber
+local tostring = tostring
+local type = type
+local string_gmatch = string.gmatch
+local string_lower = string.lower
+local string_match = string.match
+local table_concat = table.concat
+local table_sort = table.sort
+
+local _M = {}
+
+-- custom error pages of each ConfigMap by status code, synced from the
+-- shared dictionary when a page is served
+local error_pages = {}
+local error_pages_last_synced_at = 0
+
+local function sync_error_pages()
+  local raw_error_pages_last_synced_at = configuration.get_raw_error_pages_last_synced_at()
+  if raw_error_pages_last_synced_at <= error_pages_last_synced_at then
+    return
+  end
+
+  local error_pages_data = configuration.get_error_pages_data()
+  if not error_pages_data then
+    error_pages = {}
+    return
+  end
+
+  local new_error_pages, err = cjson.decode(error_pages_data)
+  if not new_error_pages then
+    ngx.log(ngx.ERR, "could not parse error pages data: ", err)
+    return
+  end
+
+  error_pages = new_error_pages
+  error_pages_last_synced_at = raw_error_pages_last_synced_at
+end
+
+-- parse_accept returns the media ranges of an Accept header sorted by
+-- quality, keeping the order of the header for the same quality
+local function parse_accept(accept)
+  local ranges = {}
+  if not accept then
+    return ranges
+  end
+
+  if type(accept) == "table" then
+    accept = table_concat(accept, ",")
+  end
+
+  for part in string_gmatch(accept, "[^,]+") do
+    local media_range = string_match(part, "^%s*([^;%s]+)")
+    local q = tonumber(string_match(part, ";%s*q=([%d.]+)")) or 1
+    if media_range and q > 0 then
+      ranges[#ranges + 1] = { media_range = string_lower(media_range), q = q, position = #ranges }
+    end
+  end
+
+  table_sort(ranges, function(a, b)
+    if a.q ~= b.q then
+      return a.q > b.q
+    end
+    return a.position < b.position
+  end)
+
+  return ranges
+end
+
+local function matches(media_range, content_type)
+  if media_range == "*/*" or media_range == content_type then
+    return true
+  end
+
+  local media_type = string_match(media_range, "^([^/]+)/%*$")
+  return media_type ~= nil and media_type == string_match(content_type, "^[^/]+")
+end
+
+-- select_page returns the page with the content type preferred by the
+-- client. The first page, HTML when available, is returned when the
+-- client does not accept any of the pages.
+local function select_page(pages, accept)
+  for _, range in ipairs(parse_accept(accept)) do
+    for _, page in ipairs(pages) do
+      if matches(range.media_range, page.contentType) then
+        return page
+      end
+    end
+  end
+
+  return pages[1]
+end
+
+-- render replaces the variables of the body of a page, which alternates
+-- text and names of variables
+local function render(page, status)
+  local parts = {}
+  for i, part in ipairs(page.body) do
+    if i % 2 == 1 then
+      parts[i] = part
+    elseif part == "status" then
+      parts[i] = tostring(status)
+    elseif part == "request_id" then
+      parts[i] = ngx.var.req_id or ""
+    else
+      parts[i] = ""
+    end
+  end
+
+  return table_concat(parts)
+end
+
+-- serve sends the custom error page of the status code from the ConfigMap
+-- in the variable custom_error_pages. The request continues to the default
+-- backend when the ConfigMap does not contain a page for the status code.
+function _M.serve(status)
+  local name = ngx.var.custom_error_pages
+  if not name or name == "" then
+    return
+  end
+
+  sync_error_pages()
+
+  local pages = error_pages[name]
+  if not pages then
+    return
+  end
+
+  local status_pages = pages[tostring(status)] or pages.default
+  if not status_pages or #status_pages == 0 then
+    return
+  end
+
+  local page = select_page(status_pages, ngx.var.http_accept)
+  local body = render(page, status)
+
+  ngx.status = status
+  ngx.header["Content-Type"] = page.contentType
+  ngx.header["Content-Length"] = #body
+  ngx.print(body)
+
+  return ngx.exit(ngx.HTTP_OK)
+end
+
+setmetatable(_M, {__index = {
+  sync_error_pages = sync_error_pages,
+  select_page = select_page,
+}})
+
+return _M
@@ -3,9 +3,12 @@ local ngx_re_split = require("ngx.re").split
 local certificate_configured_for_current_request =
   require("certificate").configured_for_current_request
 local global_throttle = require("global_throttle")
+local configuration = require("configuration")
 
 local ngx = ngx
 local io = io
+local ipairs = ipairs
+local pairs = pairs
 local math = math
 local string = string
 local original_randomseed = math.randomseed
@@ -98,6 +101,36 @@ local function parse_x_forwarded_host()
   return hosts[1]
 end
 
+-- apply_query_params modifies the query string of the request.
+-- Operations are applied in the order: remove, rename and add.
+local function apply_query_params(query_params)
+  if not query_params then
+    return
+  end
+
+  local args, err = ngx.req.get_uri_args()
+  if err then
+    ngx.log(ngx.WARN, "could not read query string: ", err)
+  end
+
+  for _, name in ipairs(query_params.remove) do
+    args[name] = nil
+  end
+
+  for old_name, new_name in pairs(query_params.rename) do
+    if args[old_name] ~= nil then
+      args[new_name] = args[old_name]
+      args[old_name] = nil
+    end
+  end
+
+  for name, value in pairs(query_params.add) do
+    args[name] = value
+  end
+
+  ngx.req.set_uri_args(args)
+end
+
 function _M.init_worker()
   randomseed()
 end
@@ -110,6 +143,14 @@ end
 -- This is where we do variable assignments to be used in subsequent
 -- phases or redirection
 function _M.rewrite(location_config)
+  -- while draining, connections opened after the controller was removed
+  -- from the endpoints of the Service are closed without a response.
+  -- HTTP/2 connections are closed by NGINX with a GOAWAY frame on quit.
+  if configuration.is_draining() and ngx.req.http_version() < 2
+      and ngx.var.connection_requests == "1" then
+    return ngx.exit(444)
+  end
+
   ngx.var.pass_access_scheme = ngx.var.scheme
 
   ngx.var.best_http_host = ngx.var.http_host or ngx.var.host
@@ -162,10 +203,17 @@ function _M.rewrite(location_config)
     return ngx_redirect(uri, config.http_redirect_code)
   end
 
+  apply_query_params(location_config.query_params)
+
   global_throttle.throttle(config.global_throttle, location_config.global_throttle)
 end
 
 function _M.header()
+  if configuration.is_draining() and ngx.req.http_version() < 2 then
+    -- ask the clients of keepalive connections to reconnect to another replica
+    ngx.header["Connection"] = "close"
+  end
+
   if config.hsts and ngx.var.scheme == "https" and certificate_configured_for_current_request then
     local value = "max-age=" .. config.hsts_max_age
     if config.hsts_include_subdomains then
@@ -36,6 +36,7 @@ local function metrics()
     path = ngx.var.location_path or "-",
 
     method = ngx.var.request_method or "-",
+    protocol = ngx.var.server_protocol or "-",
     status = ngx.var.status or "-",
     requestLength = tonumber(ngx.var.request_length) or -1,
     requestTime = tonumber(ngx.var.request_time) or -1,
@@ -305,4 +305,29 @@ describe("Configuration", function()
       assert.same(ngx.HTTP_CREATED, ngx.status)
     end)
   end)
+
+  describe("handle_drain()", function()
+    after_each(function()
+      ngx.shared.configuration_data:delete("draining")
+    end)
+
+    it("starts draining on a POST request", function()
+      ngx.var.request_method = "POST"
+      ngx.var.request_uri = "/configuration/drain"
+
+      assert.is_false(configuration.is_draining())
+      assert.has_no.errors(configuration.call)
+      assert.same(ngx.HTTP_CREATED, ngx.status)
+      assert.is_true(configuration.is_draining())
+    end)
+
+    it("returns the drain state on a GET request", function()
+      ngx.var.request_method = "GET"
+      ngx.var.request_uri = "/configuration/drain"
+
+      local s = spy.on(ngx, "print")
+      assert.has_no.errors(configuration.call)
+      assert.spy(s).was_called_with("false")
+    end)
+  end)
 end)
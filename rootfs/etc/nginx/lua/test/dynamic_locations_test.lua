@@ -0,0 +1,146 @@
+local cjson = require("cjson.safe")
+
+local LOCATION_CONFIG = { force_ssl_redirect = false, global_throttle = { namespace = "root" } }
+
+local function get_locations()
+  return {
+    ["example.com"] = {
+      { path = "/health", exact = true, upstream = "default-health-80", namespace = "default",
+        ingress = "example", service = "health", servicePort = "80", locationPath = "/health" },
+      { path = "/private", upstream = "default-private-80", namespace = "default",
+        ingress = "example", service = "private", servicePort = "80", locationPath = "/private",
+        whitelist = { "10.0.0.0/8" } },
+      { path = "/maintenance", upstream = "default-maintenance-80", namespace = "default",
+        ingress = "example", service = "maintenance", servicePort = "80", locationPath = "/maintenance",
+        denied = true },
+      { path = "/old", upstream = "default-old-80", namespace = "default",
+        ingress = "example", service = "old", servicePort = "80", locationPath = "/old",
+        redirect = { url = "https://example.org$request_uri", code = 301 } },
+      { path = "/api", upstream = "default-api-80", namespace = "default",
+        ingress = "example", service = "api", servicePort = "80", locationPath = "/api",
+        globalThrottle = { namespace = "api", limit = 10, windowSize = 60, key = "$remote_addr" } },
+      { path = "/", upstream = "default-root-80", namespace = "default",
+        ingress = "example", service = "root", servicePort = "80", locationPath = "/" },
+    },
+  }
+end
+
+local function set_locations(locations)
+  ngx.shared.configuration_data:set("locations", cjson.encode(locations))
+  ngx.update_time()
+  ngx.shared.configuration_data:set("raw_locations_last_synced_at", ngx.now())
+end
+
+describe("dynamic_locations", function()
+  local dynamic_locations
+  local snapshot
+
+  before_each(function()
+    snapshot = assert:snapshot()
+
+    ngx.var = { remote_addr = "127.0.0.1", uri = "/", request_uri = "/" }
+    set_locations(get_locations())
+
+    dynamic_locations = require_without_cache("dynamic_locations")
+    dynamic_locations.sync_locations()
+  end)
+
+  after_each(function()
+    snapshot:revert()
+
+    ngx.shared.configuration_data:delete("locations")
+    ngx.shared.configuration_data:delete("raw_locations_last_synced_at")
+    reset_ngx()
+  end)
+
+  describe("sync_locations()", function()
+    it("syncs the routes of the servers", function()
+      local servers = dynamic_locations.get_servers()
+      assert.are.same(6, #servers["example.com"])
+      assert.is_not_nil(servers["example.com"][2].whitelist_matcher)
+      assert.are.same(10, servers["example.com"][5].global_throttle.limit)
+    end)
+
+    it("does not replace the routes when the data is invalid", function()
+      stub(ngx, "log")
+
+      ngx.shared.configuration_data:set("locations", "{invalid")
+      ngx.shared.configuration_data:set("raw_locations_last_synced_at", ngx.now() + 1)
+      dynamic_locations.sync_locations()
+
+      assert.stub(ngx.log).was_called()
+      assert.are.same(6, #dynamic_locations.get_servers()["example.com"])
+    end)
+  end)
+
+  describe("rewrite()", function()
+    it("rejects the requests when the routes of the server are not synced", function()
+      stub(ngx, "exit")
+      stub(ngx, "log")
+
+      dynamic_locations.rewrite("static.example.com", LOCATION_CONFIG)
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_SERVICE_UNAVAILABLE)
+      assert.is_nil(ngx.var.proxy_upstream_name)
+    end)
+
+    it("routes the request to the longest matching prefix", function()
+      ngx.var.uri = "/api/v1/users"
+
+      local location_config = dynamic_locations.rewrite("example.com", { global_throttle = {} })
+
+      assert.are.same("default-api-80", ngx.var.proxy_upstream_name)
+      assert.are.same("api", ngx.var.service_name)
+      assert.are.same("/api", ngx.var.location_path)
+      assert.are.same("api", location_config.global_throttle.namespace)
+      assert.are.same(60, location_config.global_throttle.window_size)
+    end)
+
+    it("routes the request to an exact location only when the path matches", function()
+      ngx.var.uri = "/health"
+      dynamic_locations.rewrite("example.com", { global_throttle = {} })
+      assert.are.same("default-health-80", ngx.var.proxy_upstream_name)
+
+      ngx.var.uri = "/healthz"
+      local location_config = dynamic_locations.rewrite("example.com", { global_throttle = { namespace = "root" } })
+      assert.are.same("default-root-80", ngx.var.proxy_upstream_name)
+      assert.are.same(0, location_config.global_throttle.limit)
+    end)
+
+    it("rejects requests of denied locations", function()
+      stub(ngx, "exit")
+      ngx.var.uri = "/maintenance"
+
+      dynamic_locations.rewrite("example.com", { global_throttle = {} })
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_SERVICE_UNAVAILABLE)
+    end)
+
+    it("rejects requests from clients not in the whitelist", function()
+      stub(ngx, "exit")
+      ngx.var.uri = "/private"
+
+      dynamic_locations.rewrite("example.com", { global_throttle = {} })
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_FORBIDDEN)
+    end)
+
+    it("allows requests from clients in the whitelist", function()
+      stub(ngx, "exit")
+      ngx.var.uri = "/private"
+      ngx.var.remote_addr = "10.0.0.1"
+
+      dynamic_locations.rewrite("example.com", { global_throttle = {} })
+      assert.stub(ngx.exit).was_not_called()
+      assert.are.same("default-private-80", ngx.var.proxy_upstream_name)
+    end)
+
+    it("redirects requests of locations with a redirect", function()
+      stub(ngx, "redirect")
+      ngx.var.uri = "/old/page"
+      ngx.var.request_uri = "/old/page?a=b"
+
+      dynamic_locations.rewrite("example.com", { global_throttle = {} })
+      assert.stub(ngx.redirect).was_called_with("https://example.org/old/page?a=b", 301)
+    end)
+  end)
+end)
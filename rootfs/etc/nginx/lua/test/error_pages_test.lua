@@ -0,0 +1,130 @@
+local cjson = require("cjson.safe")
+
+local function get_error_pages()
+  return {
+    ["default/error-pages"] = {
+      ["404"] = {
+        { contentType = "text/html", body = { "<h1>", "status", " not found</h1><p>", "request_id", "</p>" } },
+        { contentType = "application/json", body = { "{\"code\": ", "status", "}" } },
+      },
+      ["default"] = {
+        { contentType = "text/plain", body = { "error ", "status", "" } },
+      },
+    },
+    ["default/only-json"] = {
+      ["503"] = {
+        { contentType = "application/json", body = { "{\"code\": ", "status", "}" } },
+      },
+    },
+  }
+end
+
+local function set_error_pages(error_pages)
+  ngx.shared.configuration_data:set("error_pages", cjson.encode(error_pages))
+  ngx.update_time()
+  ngx.shared.configuration_data:set("raw_error_pages_last_synced_at", ngx.now())
+end
+
+describe("error_pages", function()
+  local error_pages
+  local snapshot
+
+  before_each(function()
+    snapshot = assert:snapshot()
+
+    ngx.var = { custom_error_pages = "default/error-pages", req_id = "abc123" }
+    ngx.header = {}
+    set_error_pages(get_error_pages())
+
+    stub(ngx, "print")
+    stub(ngx, "exit")
+
+    error_pages = require_without_cache("error_pages")
+  end)
+
+  after_each(function()
+    snapshot:revert()
+
+    ngx.shared.configuration_data:delete("error_pages")
+    ngx.shared.configuration_data:delete("raw_error_pages_last_synced_at")
+    reset_ngx()
+  end)
+
+  describe("serve()", function()
+    it("serves the HTML page when the client accepts any content type", function()
+      ngx.var.http_accept = "*/*"
+
+      error_pages.serve(404)
+
+      assert.are.same(404, ngx.status)
+      assert.are.same("text/html", ngx.header["Content-Type"])
+      assert.stub(ngx.print).was_called_with("<h1>404 not found</h1><p>abc123</p>")
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_OK)
+    end)
+
+    it("serves the page with the content type preferred by the client", function()
+      ngx.var.http_accept = "text/html;q=0.8, application/json"
+
+      error_pages.serve(404)
+
+      assert.are.same("application/json", ngx.header["Content-Type"])
+      assert.stub(ngx.print).was_called_with("{\"code\": 404}")
+    end)
+
+    it("serves the default page for status codes without pages", function()
+      error_pages.serve(502)
+
+      assert.are.same(502, ngx.status)
+      assert.stub(ngx.print).was_called_with("error 502")
+    end)
+
+    it("serves the first page when the client does not accept any page", function()
+      ngx.var.custom_error_pages = "default/only-json"
+      ngx.var.http_accept = "text/html"
+
+      error_pages.serve(503)
+
+      assert.are.same("application/json", ngx.header["Content-Type"])
+    end)
+
+    it("does not serve a page when the ConfigMap has no page for the status code", function()
+      ngx.var.custom_error_pages = "default/only-json"
+
+      error_pages.serve(404)
+
+      assert.stub(ngx.print).was_not_called()
+      assert.stub(ngx.exit).was_not_called()
+    end)
+
+    it("does not serve a page when the location has no custom error pages", function()
+      ngx.var.custom_error_pages = nil
+
+      error_pages.serve(404)
+
+      assert.stub(ngx.print).was_not_called()
+      assert.stub(ngx.exit).was_not_called()
+    end)
+
+    it("serves the pages of the last update", function()
+      local updated = get_error_pages()
+      updated["default/error-pages"]["404"] = {
+        { contentType = "text/plain", body = { "gone" } },
+      }
+      ngx.shared.configuration_data:set("error_pages", cjson.encode(updated))
+      ngx.shared.configuration_data:set("raw_error_pages_last_synced_at", ngx.now() + 1)
+
+      error_pages.serve(404)
+
+      assert.stub(ngx.print).was_called_with("gone")
+    end)
+  end)
+
+  describe("select_page()", function()
+    it("matches media ranges with a wildcard subtype", function()
+      local pages = get_error_pages()["default/error-pages"]["404"]
+
+      assert.are.same("application/json", error_pages.select_page(pages, "application/*").contentType)
+      assert.are.same("text/html", error_pages.select_page(pages, "application/json;q=0, text/*").contentType)
+    end)
+  end)
+end)
@@ -85,6 +85,7 @@ describe("Monitor", function()
         location_path = "/",
 
         request_method = "GET",
+        server_protocol = "HTTP/3.0",
         status = "200",
         request_length = "256",
         request_time = "0.04",
@@ -117,6 +118,7 @@ describe("Monitor", function()
           path = "/",
 
           method = "GET",
+          protocol = "HTTP/3.0",
           status = "200",
           requestLength = 256,
           requestTime = 0.04,
@@ -134,6 +136,7 @@ describe("Monitor", function()
           path = "/",
 
           method = "POST",
+          protocol = "HTTP/3.0",
           status = "201",
           requestLength = 256,
           requestTime = 0.04,
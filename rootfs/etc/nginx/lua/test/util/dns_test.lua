@@ -32,6 +32,12 @@ describe("dns.lookup", function()
     dns_lookup("example.com")
   end)
 
+  it("returns IP addresses without querying the DNS server", function()
+    helpers.mock_resty_dns_new(function(...) error("unexpected query") end)
+    assert.are.same({ "192.0.2.1" }, dns_lookup("192.0.2.1"))
+    assert.are.same({ "2001:db8::1" }, dns_lookup("2001:db8::1"))
+  end)
+
   describe("when there's an error", function()
     it("returns host when resolver can not be instantiated", function()
       helpers.mock_resty_dns_new(function(...) return nil, "an error" end)
@@ -37,6 +37,11 @@ local function is_fully_qualified(host)
   return host:sub(-1) == "."
 end
 
+-- hostnames can not contain ":", so any host containing it is an IPv6 address
+local function is_ip_address(host)
+  return host:find(":", 1, true) ~= nil or ngx.re.find(host, "^[0-9.]+$", "jo") ~= nil
+end
+
 local function a_records_and_min_ttl(answers)
   local addresses = {}
   local ttl = MAXIMUM_TTL_VALUE -- maximum value according to https://tools.ietf.org/html/rfc2181
@@ -89,6 +94,12 @@ local function resolve_host(r, host)
 end
 
 function _M.lookup(host)
+  -- ExternalName services are resolved by the controller
+  -- when --enable-external-name-resolution is set
+  if is_ip_address(host) then
+    return { host }
+  end
+
   local cached_addresses = cache:get(host)
   if cached_addresses then
     return cached_addresses